@@ -32,13 +32,86 @@ func main() {
 
 	fmt.Println("🐘 Success! Connected to PostgreSQL.")
 
-	db.AutoMigrate(
+	err = db.AutoMigrate(
 		&domain.User{}, &domain.Ticket{}, &domain.Order{},
 		&domain.Event{}, &domain.AuditLog{}, &domain.PointTransaction{},
+		&domain.PaymentEvent{}, &domain.ScanEvent{},
+		&domain.Job{}, &domain.Notification{},
+		&domain.TicketTier{}, &domain.OrderItem{},
+		&domain.PromoCode{}, &domain.OrderLine{}, &domain.ChargePolicy{},
+		&domain.Cart{}, &domain.CartItem{}, &domain.IdempotencyKey{}, &domain.RefundRequest{},
+		&domain.Refund{}, &domain.RefundTicket{}, &domain.TicketTransfer{},
+		&domain.ResaleListing{}, &domain.ResalePayout{}, &domain.WaitlistEntry{}, &domain.AccessCode{}, &domain.TierUnlock{},
+		&domain.Venue{}, &domain.SeatMap{}, &domain.SeatSection{}, &domain.SeatRow{}, &domain.Seat{}, &domain.EventSeat{},
 	)
+	if err != nil {
+		log.Fatal("Failed to migrate the schema:", err)
+	}
 
 	repo := repository.NewDBRepo(db)
-	bookingSvc := service.NewBookingService(repo)
+	if err := repo.MigrateMoneyColumns(); err != nil {
+		log.Fatal("Failed to migrate prices to sen:", err)
+	}
+	if migrated, err := repo.MigrateToTierInventory(); err != nil {
+		log.Fatal("Failed to migrate ticket inventory to tiers:", err)
+	} else if migrated > 0 {
+		fmt.Printf("🎟️  Inventory: moved %d events to tier counters\n", migrated)
+	}
+	if err := repo.MigrateOrderDiscounts(); err != nil {
+		log.Fatal("Failed to migrate order discounts:", err)
+	}
+	if err := repo.BackfillTicketHolders(); err != nil {
+		log.Fatal("Failed to backfill ticket holders:", err)
+	}
+	if err := repo.BackfillEventStatus(); err != nil {
+		log.Fatal("Failed to backfill event status:", err)
+	}
+	if migrated, unreadable, err := repo.BackfillEventSchedules(); err != nil {
+		log.Fatal("Failed to backfill event schedules:", err)
+	} else if migrated > 0 || len(unreadable) > 0 {
+		fmt.Printf("🗓️  Schedules: migrated %d events, could not read dates for %v\n", migrated, unreadable)
+	}
+
+	// Payment Gateway: Billplz, or the local mock when explicitly asked for. The mock
+	// mounts an unauthenticated "pay" page, so a missing setting must never fall back to it.
+	var gateway service.PaymentGateway
+	switch os.Getenv("PAYMENT_GATEWAY") {
+	case "billplz", "":
+		if os.Getenv("BILLPLZ_API_KEY") == "" {
+			log.Fatal("BILLPLZ_API_KEY is not set (use PAYMENT_GATEWAY=mock for local development)")
+		}
+		gateway = service.NewBillplzGateway(
+			os.Getenv("BILLPLZ_API_KEY"),
+			os.Getenv("BILLPLZ_COLLECTION_ID"),
+			os.Getenv("BILLPLZ_X_SIGNATURE"),
+			os.Getenv("TEMP_URL"),
+			os.Getenv("BILLPLZ_SANDBOX") == "true",
+		)
+		fmt.Println("💳 Payments: Billplz")
+	case "mock":
+		gateway = service.NewMockGateway(os.Getenv("TEMP_URL"), os.Getenv("BILLPLZ_X_SIGNATURE"))
+		fmt.Println("⚠️  Payments: Mock Billplz (local), anyone can mark orders paid")
+	default:
+		log.Fatal("PAYMENT_GATEWAY must be billplz or mock, got ", os.Getenv("PAYMENT_GATEWAY"))
+	}
+
+	// QR ticket credentials (Ed25519)
+	signer, err := service.NewTicketSignerFromEnv()
+	if err != nil {
+		log.Fatal("Invalid ticket signing keys:", err)
+	}
+	if os.Getenv("TICKET_SIGNING_KEYS") == "" {
+		fmt.Println("⚠️  TICKET_SIGNING_KEYS not set: using a throwaway key, QR codes reset on restart")
+	}
+
+	// Virtual waiting rooms live in this process; a shared store can replace it
+	queue := service.NewMemoryQueueStore()
+
+	bookingSvc := service.NewBookingService(repo, gateway, signer, queue)
+	bookingSvc.StartQueueAdmitter()
+
+	// Pick up background jobs (e.g. event cancellations) interrupted by the last shutdown
+	bookingSvc.ResumeJobs()
 	// Start Background Worker
 	go func() {
 		for {
@@ -47,13 +120,25 @@ func main() {
 			if err == nil && released > 0 {
 				fmt.Printf("🧹 Cleanup: Released %d tickets from expired orders\n", released)
 			}
+			if dropped, err := repo.CleanupExpiredCarts(); err == nil && dropped > 0 {
+				fmt.Printf("🧹 Cleanup: Emptied %d items from abandoned carts\n", dropped)
+			}
+			repo.CleanupIdempotencyKeys(domain.IdempotencyKeyTTL)
+			// Released stock is offered to waitlists before anything can reopen for sale
+			bookingSvc.OfferWaitlists()
+			reopened, err := repo.ReopenSoldOutEvents()
+			if err == nil {
+				for _, id := range reopened {
+					repo.RecordLog(0, "EVENT_STATUS", fmt.Sprint(id), "sold_out → on_sale: expired holds released stock")
+				}
+			}
 		}
 	}()
 
 	r := gin.Default()
 
 	// 🚀 Call our new Routes function!
-	api.SetupRoutes(r, repo, bookingSvc)
+	api.SetupRoutes(r, repo, bookingSvc, gateway)
 
 	r.Run(":8080")
 }
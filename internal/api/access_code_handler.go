@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /access-codes/redeem
+func HandleRedeemAccessCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		tiers, err := bookingSvc.RedeemAccessCode(c.MustGet("userID").(uint), input.Code)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Access code accepted", "tiers": tiers})
+	}
+}
+
+// POST /admin/access-codes
+func HandleCreateAccessCodes(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req domain.AccessCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		codes, err := bookingSvc.CreateAccessCodes(req, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, codes)
+	}
+}
+
+// GET /admin/access-codes?event_id=
+func HandleListAccessCodes(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if raw := c.Query("event_id"); raw != "" {
+			if _, err := fmt.Sscanf(raw, "%d", &eventID); err != nil {
+				c.JSON(400, gin.H{"error": "Invalid Event ID"})
+				return
+			}
+		}
+
+		codes, err := bookingSvc.ListAccessCodes(eventID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load access codes"})
+			return
+		}
+		c.JSON(200, codes)
+	}
+}
+
+// DELETE /admin/access-codes/:id
+func HandleDeactivateAccessCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Access Code ID"})
+			return
+		}
+
+		code, err := bookingSvc.DeactivateAccessCode(id, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, code)
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/middleware"
 	"neptunes-tix/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -11,10 +13,8 @@ import (
 // This interface ensures this file only demands the exact methods it needs
 type AdminRepo interface {
 	GetAdminStats() (map[string]interface{}, error)
-	// ScanTicket(ticketID string) (*domain.Ticket, error)
 	SearchCustomerByName(name string) ([]domain.User, error)
 	GetUnscannedByEmail(email string) ([]domain.Ticket, error)
-	BulkCheckIn(ticketIDs []string) error
 	RecordLog(userID uint, action, targetID, details string)
 	CreateEventStock(req domain.CreateEventRequest) error
 	GetEventDetails(eventID uint) (*domain.EventDetail, error) // 🚀 Add this line
@@ -33,7 +33,8 @@ func HandleAdminStats(repo AdminRepo) gin.HandlerFunc {
 
 func HandleTicketCheckin(bookingSvc *service.BookingService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ticketID := c.Param("id")
+		// The path segment is the scanned QR payload: a signed ticket credential
+		credential := c.Param("id")
 
 		eventIDStr := c.Query("event_id")
 		if eventIDStr == "" {
@@ -47,16 +48,32 @@ func HandleTicketCheckin(bookingSvc *service.BookingService) gin.HandlerFunc {
 			return
 		}
 
-		// 🚀 Uses the service to enforce the "Wrong Event" business rule
-		ticket, err := bookingSvc.CheckInTicket(ticketID, eventID)
+		// Which scanner is this? Recorded on the scan log so losers know who won
+		scanner := service.Scanner{
+			OperatorID: c.MustGet("userID").(uint),
+			Gate:       c.Query("gate"),
+			DeviceID:   c.GetHeader("X-Device-ID"),
+		}
+
+		// 🚀 Uses the service to enforce the "Wrong Event" business rule and entry policy
+		scan, err := bookingSvc.CheckInTicket(credential, eventID, scanner)
 		if err != nil {
 			c.JSON(409, gin.H{"error": err.Error()})
 			return
 		}
 
+		message := "Check-in successful!"
+		switch scan.Result {
+		case domain.ScanResultReentered:
+			message = "Welcome back! Re-entry allowed."
+		case domain.ScanResultExited:
+			message = "Exit recorded."
+		}
+
 		c.JSON(200, gin.H{
-			"message": "Check-in successful!",
-			"data":    ticket,
+			"message": message,
+			"result":  scan.Result,
+			"data":    scan.Ticket,
 		})
 	}
 }
@@ -85,7 +102,7 @@ func HandleTicketLookup(repo AdminRepo) gin.HandlerFunc {
 	}
 }
 
-func HandleBulkCheckin(repo AdminRepo) gin.HandlerFunc {
+func HandleBulkCheckin(bookingSvc *service.BookingService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("userID").(uint)
 		var req struct {
@@ -95,15 +112,19 @@ func HandleBulkCheckin(repo AdminRepo) gin.HandlerFunc {
 			c.JSON(400, gin.H{"error": "No tickets selected"})
 			return
 		}
-		if err := repo.BulkCheckIn(req.TicketIDs); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to update tickets"})
-			return
-		}
 
-		details := fmt.Sprintf("Checked in %d tickets via email lookup", len(req.TicketIDs))
-		repo.RecordLog(userID, "BULK_CHECKIN", "MULTIPLE", details)
+		// Same entry policy and scan log as the gates; each ticket gets its own result
+		scanner := service.Scanner{
+			OperatorID: userID,
+			Gate:       c.DefaultQuery("gate", "help desk"),
+			DeviceID:   c.GetHeader("X-Device-ID"),
+		}
+		scans, admitted := bookingSvc.BulkCheckIn(req.TicketIDs, scanner)
 
-		c.JSON(200, gin.H{"message": "Checked in " + fmt.Sprint(len(req.TicketIDs)) + " guests!"})
+		c.JSON(200, gin.H{
+			"message": "Checked in " + fmt.Sprint(admitted) + " guests!",
+			"results": scans,
+		})
 	}
 }
 
@@ -114,6 +135,10 @@ func HandleCreateEvent(repo AdminRepo) gin.HandlerFunc {
 			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
+		if _, err := req.NewEvent(); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		if err := repo.CreateEventStock(req); err != nil {
 			c.JSON(500, gin.H{"error": "Failed to generate stock"})
 			return
@@ -135,7 +160,10 @@ func HandleDeleteTicket(bookingSvc *service.BookingService) gin.HandlerFunc {
 type CheckoutInput struct {
 	EventID      uint                   `json:"event_id" binding:"required"`
 	RedeemPoints int                    `json:"redeem_points"`
-	Items        []service.CheckoutItem `json:"items" binding:"required,gt=0"`
+	Items        []service.CheckoutItem `json:"items" binding:"dive"`
+	SeatIDs      []uint                 `json:"seat_ids"`    // reserved seating
+	ListingIDs   []uint                 `json:"listing_ids"` // fans' resale tickets
+	PromoCode    string                 `json:"promo_code"`
 }
 
 func HandleCheckout(bookingSvc *service.BookingService) gin.HandlerFunc {
@@ -167,12 +195,19 @@ func HandleCheckout(bookingSvc *service.BookingService) gin.HandlerFunc {
 		}
 
 		// 🚀 The service now handles multiple items in a single transaction
-		order, err := bookingSvc.CreateMultiItemOrder(userID, input.EventID, input.Items, input.RedeemPoints)
+		order, err := bookingSvc.CreateMultiItemOrder(userID, input.EventID, input.Items, input.SeatIDs, input.ListingIDs, input.RedeemPoints, input.PromoCode, queueTokens(c))
 		if err != nil {
+			// Rule refusals carry a code the app can react to (e.g. show "opens at")
+			var refusal *domain.CheckoutError
+			if errors.As(err, &refusal) {
+				c.JSON(422, gin.H{"error": refusal.Message, "code": refusal.Code})
+				return
+			}
 			c.JSON(500, gin.H{"error": "Checkout failed: " + err.Error()})
 			return
 		}
 
+		c.Set(middleware.IdempotencyOrderKey, order.ID)
 		c.JSON(201, gin.H{
 			"order_id":    order.ID,
 			"payment_url": order.PaymentURL,
@@ -275,3 +310,110 @@ func HandleGetEventDetails(repo AdminRepo) gin.HandlerFunc {
 		c.JSON(200, details)
 	}
 }
+
+func HandleTicketScans(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scans, err := bookingSvc.GetTicketScans(c.Param("id"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load scan history"})
+			return
+		}
+		c.JSON(200, scans)
+	}
+}
+
+func HandleEventStatus(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		var req struct {
+			Status string `json:"status" binding:"required"`
+			Reason string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID := c.MustGet("userID").(uint)
+
+		// Cancelling means refunds, so it always goes through the cancellation job
+		if req.Status == domain.EventCancelled {
+			job, err := bookingSvc.CancelEvent(eventID, userID, req.Reason)
+			if err != nil {
+				c.JSON(409, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(202, gin.H{"message": "Event cancelled, refunds are being processed", "job": job})
+			return
+		}
+
+		event, err := bookingSvc.TransitionEvent(eventID, req.Status, userID, req.Reason)
+		if err != nil {
+			c.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Event is now " + event.Status, "data": event})
+	}
+}
+
+func HandleCancelEvent(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		var req struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "A cancellation reason is required"})
+			return
+		}
+
+		job, err := bookingSvc.CancelEvent(eventID, c.MustGet("userID").(uint), req.Reason)
+		if err != nil {
+			c.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(202, gin.H{"message": "Event cancelled, refunds are being processed", "job": job})
+	}
+}
+
+func HandleGetJob(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var jobID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &jobID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Job ID"})
+			return
+		}
+		job, err := bookingSvc.GetJob(jobID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(200, job)
+	}
+}
+
+func HandleRetryJob(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var jobID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &jobID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Job ID"})
+			return
+		}
+		job, err := bookingSvc.RetryJob(jobID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(202, job)
+	}
+}
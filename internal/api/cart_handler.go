@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/middleware"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /cart
+func HandleGetCart(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cart, err := bookingSvc.GetCart(c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load cart"})
+			return
+		}
+		c.JSON(200, cart)
+	}
+}
+
+// POST /cart/items
+func HandleAddCartItem(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req domain.AddCartItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		cart, err := bookingSvc.AddToCart(c.MustGet("userID").(uint), req, queueTokens(c))
+		if err != nil {
+			respondCheckoutError(c, err)
+			return
+		}
+		c.JSON(200, cart)
+	}
+}
+
+// PUT /cart/items/:id
+func HandleUpdateCartItem(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var itemID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &itemID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Cart Item ID"})
+			return
+		}
+		var input struct {
+			Quantity int `json:"quantity" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		cart, err := bookingSvc.UpdateCartItem(c.MustGet("userID").(uint), itemID, input.Quantity, queueTokens(c))
+		if err != nil {
+			respondCheckoutError(c, err)
+			return
+		}
+		c.JSON(200, cart)
+	}
+}
+
+// DELETE /cart/items/:id
+func HandleRemoveCartItem(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var itemID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &itemID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Cart Item ID"})
+			return
+		}
+
+		cart, err := bookingSvc.RemoveCartItem(c.MustGet("userID").(uint), itemID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, cart)
+	}
+}
+
+// DELETE /cart
+func HandleClearCart(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := bookingSvc.ClearCart(c.MustGet("userID").(uint)); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to clear cart"})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Cart cleared"})
+	}
+}
+
+// POST /cart/checkout
+func HandleCartCheckout(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			RedeemPoints int    `json:"redeem_points"`
+			PromoCode    string `json:"promo_code"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		order, err := bookingSvc.CheckoutCart(c.MustGet("userID").(uint), input.RedeemPoints, input.PromoCode, queueTokens(c))
+		if err != nil {
+			var refusal *domain.CheckoutError
+			if errors.As(err, &refusal) {
+				c.JSON(422, gin.H{"error": refusal.Message, "code": refusal.Code})
+				return
+			}
+			c.JSON(500, gin.H{"error": "Checkout failed: " + err.Error()})
+			return
+		}
+
+		c.Set(middleware.IdempotencyOrderKey, order.ID)
+		c.JSON(201, gin.H{
+			"order_id":    order.ID,
+			"payment_url": order.PaymentURL,
+			"total":       order.TotalAmount,
+		})
+	}
+}
+
+// respondCheckoutError sends rule refusals as 422 with their code, anything else as 400
+func respondCheckoutError(c *gin.Context, err error) {
+	var refusal *domain.CheckoutError
+	if errors.As(err, &refusal) {
+		c.JSON(422, gin.H{"error": refusal.Message, "code": refusal.Code})
+		return
+	}
+	c.JSON(400, gin.H{"error": err.Error()})
+}
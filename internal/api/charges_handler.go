@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PUT /admin/charges                              → global default
+// PUT /admin/events/:id/charges                   → whole event
+// PUT /admin/events/:id/tiers/:category/charges   → one tier
+func HandleSetChargePolicy(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID *uint
+		if raw := c.Param("id"); raw != "" {
+			var id uint
+			if _, err := fmt.Sscanf(raw, "%d", &id); err != nil {
+				c.JSON(400, gin.H{"error": "Invalid Event ID"})
+				return
+			}
+			eventID = &id
+		}
+
+		var req domain.ChargePolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		policy, err := bookingSvc.SetChargePolicy(eventID, c.Param("category"), req, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, policy)
+	}
+}
+
+// GET /admin/events/:id/charges
+func HandleGetChargePolicies(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		policies, err := bookingSvc.GetChargePolicies(eventID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load charge policies"})
+			return
+		}
+		c.JSON(200, policies)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Billplz POSTs here (callback_url). Anything without a valid x_signature is rejected.
+// Replays get the original result back with a 200 so the gateway stops retrying.
+func HandlePaymentWebhook(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := c.Request.ParseForm(); err != nil {
+			c.JSON(400, gin.H{"error": "Malformed callback"})
+			return
+		}
+
+		event, err := bookingSvc.HandlePaymentCallback(c.Request.PostForm)
+		if errors.Is(err, service.ErrInvalidSignature) {
+			c.JSON(403, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			// Non-2xx makes Billplz retry; the failed event is picked up again on redelivery
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{
+			"result":     event.Result,
+			"deliveries": event.Deliveries,
+		})
+	}
+}
+
+// The buyer's browser lands here (redirect_url) with billplz[...] query params.
+func HandlePaymentRedirect(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		order, err := bookingSvc.HandlePaymentRedirect(c.Request.URL.Query())
+		if errors.Is(err, service.ErrInvalidSignature) {
+			c.Data(403, "text/html; charset=utf-8", []byte("<h1>Invalid payment signature</h1>"))
+			return
+		}
+		if err != nil {
+			// Errors can echo the query string back, so never put them in the page raw
+			c.Data(500, "text/html; charset=utf-8", []byte("<h1>Something went wrong</h1><p>"+html.EscapeString(err.Error())+"</p>"))
+			return
+		}
+
+		// Paid, possibly with undeliverable resale tickets already being refunded
+		if order.Status != "paid" && order.Status != "partially_refunded" && order.Status != "refund_pending" {
+			c.Data(200, "text/html; charset=utf-8", []byte("<h1>Payment Not Completed</h1><p>You can close this window and try again from the app.</p>"))
+			return
+		}
+		c.Data(200, "text/html; charset=utf-8", []byte("<h1>Payment Successful!</h1><p>You can close this window and return to the app.</p>"))
+	}
+}
+
+// --- LOCAL MOCK GATEWAY ---
+
+func HandleMockBillPage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		billID := html.EscapeString(c.Param("id"))
+		page := fmt.Sprintf(`
+        <html>
+            <body style="font-family: sans-serif; text-align: center; padding: 50px;">
+                <h1>Mock Billplz Gateway</h1>
+                <p>Bill ID: <strong>%s</strong></p>
+                <div style="margin-top: 20px;">
+                    <form action="/mock-billplz/pay/%s" method="POST">
+                        <button type="submit" style="background: #28a745; color: white; padding: 15px 30px; border: none; border-radius: 5px; font-size: 18px; cursor: pointer;">
+                            Simulate Successful Payment
+                        </button>
+                    </form>
+                    <br/>
+                    <a href="#" style="color: red;">Cancel Payment</a>
+                </div>
+            </body>
+        </html>
+    `, billID, billID)
+		c.Data(200, "text/html; charset=utf-8", []byte(page))
+	}
+}
+
+// Plays the part of Billplz: signs a callback and pushes it through the same
+// verification path the real webhook uses, then redirects the browser like Billplz would.
+func HandleMockBillPay(repo domain.TicketRepository, gateway *service.MockGateway, bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		billID := c.Param("id")
+		order, err := repo.GetOrderByBillID(billID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "Bill not found"})
+			return
+		}
+
+		callback := gateway.SimulateCallback(billID, order.TotalAmount, true)
+		if _, err := bookingSvc.HandlePaymentCallback(callback); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		redirect := gateway.SimulateRedirect(billID, true, callback.Get("paid_at"))
+		c.Redirect(302, "/payments/redirect?"+redirect.Encode())
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /admin/promo-codes
+func HandleCreatePromoCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req domain.PromoCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		promo, err := bookingSvc.CreatePromoCode(req, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, promo)
+	}
+}
+
+// GET /admin/promo-codes
+func HandleListPromoCodes(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		promos, err := bookingSvc.ListPromoCodes()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load promo codes"})
+			return
+		}
+		c.JSON(200, promos)
+	}
+}
+
+// GET /admin/promo-codes/:id
+func HandleGetPromoCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var promoID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &promoID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Promo Code ID"})
+			return
+		}
+
+		promo, stats, err := bookingSvc.GetPromoCode(promoID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"promo_code": promo, "stats": stats})
+	}
+}
+
+// PUT /admin/promo-codes/:id
+func HandleUpdatePromoCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var promoID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &promoID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Promo Code ID"})
+			return
+		}
+
+		var req domain.PromoCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		promo, err := bookingSvc.UpdatePromoCode(promoID, req, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, promo)
+	}
+}
+
+// DELETE /admin/promo-codes/:id
+func HandleDeletePromoCode(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var promoID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &promoID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Promo Code ID"})
+			return
+		}
+
+		if err := bookingSvc.DeletePromoCode(promoID, c.MustGet("userID").(uint)); err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Promo code deleted"})
+	}
+}
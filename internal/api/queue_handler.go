@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueTokenHeader carries the buyer's admitted waiting room tickets to checkout and to
+// the cart (which holds stock), one per event with a waiting room (repeat the header
+// or separate them with commas)
+const QueueTokenHeader = "X-Queue-Token"
+
+// POST /events/:id/queue
+func HandleJoinQueue(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		ticket, err := bookingSvc.JoinQueue(c.MustGet("userID").(uint), eventID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, ticket)
+	}
+}
+
+// GET /events/:id/queue (token in X-Queue-Token or ?token=)
+func HandleQueueStatus(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		ticket, err := bookingSvc.GetQueueTicket(c.MustGet("userID").(uint), eventID, queueToken(c))
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, ticket)
+	}
+}
+
+// DELETE /events/:id/queue (token in X-Queue-Token or ?token=)
+func HandleLeaveQueue(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		if err := bookingSvc.LeaveQueue(c.MustGet("userID").(uint), eventID, queueToken(c)); err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "You have left the queue"})
+	}
+}
+
+func queueToken(c *gin.Context) string {
+	if token := c.Query("token"); token != "" {
+		return token
+	}
+	return c.GetHeader(QueueTokenHeader)
+}
+
+// queueTokens collects every token sent to a checkout
+func queueTokens(c *gin.Context) []string {
+	var tokens []string
+	for _, value := range c.Request.Header.Values(QueueTokenHeader) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
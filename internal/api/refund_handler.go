@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /orders/:id/cancel
+func HandleCancelOrder(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var orderID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &orderID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Order ID"})
+			return
+		}
+
+		if err := bookingSvc.CancelPendingOrder(c.MustGet("userID").(uint), orderID); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Order cancelled, your tickets have been released"})
+	}
+}
+
+// POST /orders/:id/refund-request
+func HandleRequestRefund(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var orderID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &orderID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Order ID"})
+			return
+		}
+		var input struct {
+			TicketIDs []string `json:"ticket_ids"` // empty: the whole order
+			Reason    string   `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		req, err := bookingSvc.RequestRefund(c.MustGet("userID").(uint), orderID, input.TicketIDs, input.Reason)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, req)
+	}
+}
+
+// GET /my-refund-requests
+func HandleMyRefundRequests(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqs, err := bookingSvc.GetUserRefundRequests(c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load refund requests"})
+			return
+		}
+		c.JSON(200, reqs)
+	}
+}
+
+// GET /admin/refund-requests?status=requested
+func HandleListRefundRequests(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqs, err := bookingSvc.ListRefundRequests(c.Query("status"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load refund requests"})
+			return
+		}
+		c.JSON(200, reqs)
+	}
+}
+
+// POST /admin/refund-requests/:id/approve and /reject
+func HandleReviewRefundRequest(bookingSvc *service.BookingService, approve bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &requestID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Refund Request ID"})
+			return
+		}
+		var input struct {
+			Note string `json:"note"`
+		}
+		c.ShouldBindJSON(&input) // the note is optional for approvals
+
+		actorID := c.MustGet("userID").(uint)
+		review := bookingSvc.RejectRefundRequest
+		if approve {
+			review = bookingSvc.ApproveRefundRequest
+		}
+		req, err := review(requestID, actorID, input.Note)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, req)
+	}
+}
+
+// POST /admin/orders/:id/refunds
+func HandleRefundOrderTickets(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var orderID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &orderID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Order ID"})
+			return
+		}
+		var input struct {
+			TicketIDs []string `json:"ticket_ids"` // empty: every ticket still valid
+			Reason    string   `json:"reason" binding:"required"`
+			Restock   *bool    `json:"restock"` // default true
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		restock := input.Restock == nil || *input.Restock
+
+		refund, err := bookingSvc.RefundOrderTickets(orderID, input.TicketIDs, input.Reason, restock, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, refund)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /resale?event_id=
+func HandleResaleListings(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if raw := c.Query("event_id"); raw != "" {
+			if _, err := fmt.Sscanf(raw, "%d", &eventID); err != nil {
+				c.JSON(400, gin.H{"error": "Invalid Event ID"})
+				return
+			}
+		}
+
+		listings, err := bookingSvc.GetResaleListings(eventID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load resale listings"})
+			return
+		}
+		c.JSON(200, gin.H{"data": listings})
+	}
+}
+
+// POST /my-tickets/:id/resale
+func HandleListForResale(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Price domain.Money `json:"price"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		listing, err := bookingSvc.ListTicketForResale(c.MustGet("userID").(uint), c.Param("id"), input.Price)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, listing)
+	}
+}
+
+// DELETE /resale/:id
+func HandleCancelResaleListing(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var listingID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &listingID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Listing ID"})
+			return
+		}
+
+		listing, err := bookingSvc.CancelResaleListing(c.MustGet("userID").(uint), listingID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, listing)
+	}
+}
+
+// GET /my-resale
+func HandleMyResale(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listings, payouts, err := bookingSvc.GetUserResale(c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load your resale listings"})
+			return
+		}
+		c.JSON(200, gin.H{"listings": listings, "payouts": payouts})
+	}
+}
+
+// GET /admin/resale/payouts?status=pending
+func HandleListResalePayouts(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payouts, err := bookingSvc.ListResalePayouts(c.Query("status"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load payouts"})
+			return
+		}
+		c.JSON(200, payouts)
+	}
+}
+
+// POST /admin/resale/payouts/:id/paid
+func HandleMarkPayoutPaid(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var payoutID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &payoutID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Payout ID"})
+			return
+		}
+		var input struct {
+			Reference string `json:"reference" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		payout, err := bookingSvc.MarkPayoutPaid(payoutID, c.MustGet("userID").(uint), input.Reference)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, payout)
+	}
+}
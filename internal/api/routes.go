@@ -11,7 +11,7 @@ import (
 )
 
 // SetupRoutes wires up all the HTTP endpoints
-func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService) {
+func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService, gateway service.PaymentGateway) {
 
 	// 1. Cast rawRepo so the Admin Handlers can use it
 	adminRepo := rawRepo.(AdminRepo)
@@ -54,9 +54,10 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 		c.JSON(200, gin.H{"total": total, "data": tickets})
 	})
 
-	r.GET("/marketplace", func(c *gin.Context) {
+	// Logged-in users also see the code-only tiers they have unlocked
+	r.GET("/marketplace", middleware.OptionalAuth(), func(c *gin.Context) {
 		search := c.Query("q")
-		tickets, err := repo.GetMarketplace(search)
+		tickets, err := repo.GetMarketplace(search, c.GetUint("userID"))
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to load marketplace"})
 			return
@@ -64,47 +65,21 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 		c.JSON(200, gin.H{"data": tickets})
 	})
 
-	// BILLING & CHECKOUT ROUTES
-	r.POST("/payments/webhook", func(c *gin.Context) {
-		orderID := c.PostForm("order_id")
-		isPaid := c.PostForm("paid") == "true"
-		if isPaid {
-			bookingSvc.FinalizePayment(orderID)
-		}
-		c.Status(200)
-	})
+	// Fan-to-fan resale; buying goes through /checkout with listing_ids
+	r.GET("/resale", HandleResaleListings(bookingSvc))
 
-	r.GET("/mock-billplz/:id", func(c *gin.Context) {
-		orderID := c.Param("id")
-		html := fmt.Sprintf(`
-        <html>
-            <body style="font-family: sans-serif; text-align: center; padding: 50px;">
-                <h1>Mock Billplz Gateway</h1>
-                <p>Order ID: <strong>%s</strong></p>
-                <div style="margin-top: 20px;">
-                    <form action="/mock-billplz/pay/%s" method="POST">
-                        <button type="submit" style="background: #28a745; color: white; padding: 15px 30px; border: none; border-radius: 5px; font-size: 18px; cursor: pointer;">
-                            Simulate Successful Payment
-                        </button>
-                    </form>
-                    <br/>
-                    <a href="#" style="color: red;">Cancel Payment</a>
-                </div>
-            </body>
-        </html>
-    `, orderID, orderID)
-		c.Data(200, "text/html; charset=utf-8", []byte(html))
-	})
+	// Live seat map for reserved-seating events
+	r.GET("/events/:id/seats", HandleEventSeats(bookingSvc))
 
-	r.POST("/mock-billplz/pay/:id", func(c *gin.Context) {
-		orderID := c.Param("id")
-		err := bookingSvc.FinalizePayment(orderID)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		c.Writer.Write([]byte("<h1>Payment Successful!</h1><p>You can close this window and return to the app.</p>"))
-	})
+	// BILLING & CHECKOUT ROUTES
+	r.POST("/payments/webhook", HandlePaymentWebhook(bookingSvc))
+	r.GET("/payments/redirect", HandlePaymentRedirect(bookingSvc))
+
+	// Local stand-in for Billplz, only mounted with PAYMENT_GATEWAY=mock
+	if mock, ok := gateway.(*service.MockGateway); ok {
+		r.GET("/mock-billplz/:id", HandleMockBillPage())
+		r.POST("/mock-billplz/pay/:id", HandleMockBillPay(repo, mock, bookingSvc))
+	}
 
 	// --- 🛡️ AUTHENTICATED USER ROUTES ---
 	userAuth := r.Group("/")
@@ -128,9 +103,17 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 				c.JSON(404, gin.H{"error": "Order not found"})
 				return
 			}
+			// Receipt totals alongside the lines they come from
+			breakdown := domain.Breakdown(order.Lines)
+			order.Breakdown = &breakdown
 			c.JSON(200, order)
 		})
 
+		// Walk away from an unpaid order, or ask for a paid one to be refunded
+		userAuth.POST("/orders/:id/cancel", HandleCancelOrder(bookingSvc))
+		userAuth.POST("/orders/:id/refund-request", HandleRequestRefund(bookingSvc))
+		userAuth.GET("/my-refund-requests", HandleMyRefundRequests(bookingSvc))
+
 		userAuth.GET("/my-tickets", func(c *gin.Context) {
 			userID := c.MustGet("userID").(uint)
 			tickets, err := repo.GetUserTickets(userID)
@@ -141,6 +124,44 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 			c.JSON(200, tickets)
 		})
 
+		// Signed QR payload for one of the user's paid tickets
+		userAuth.GET("/my-tickets/:id/credential", func(c *gin.Context) {
+			userID := c.MustGet("userID").(uint)
+			credential, err := bookingSvc.IssueTicketCredential(userID, c.Param("id"))
+			if err != nil {
+				c.JSON(404, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(200, gin.H{"ticket_id": c.Param("id"), "credential": credential})
+		})
+
+		// Give a ticket to another account: the recipient accepts, the QR code is reissued
+		userAuth.POST("/my-tickets/:id/transfer", HandleStartTransfer(bookingSvc))
+		userAuth.GET("/my-tickets/:id/transfers", HandleMyTicketTransfers(bookingSvc))
+		userAuth.GET("/my-transfers", HandleMyTransfers(bookingSvc))
+		userAuth.POST("/transfers/:id/accept", HandleRespondTransfer(bookingSvc, domain.TransferAccepted))
+		userAuth.POST("/transfers/:id/decline", HandleRespondTransfer(bookingSvc, domain.TransferDeclined))
+		userAuth.POST("/transfers/:id/cancel", HandleRespondTransfer(bookingSvc, domain.TransferCancelled))
+
+		// Resell a ticket to other fans, within the event's price cap
+		userAuth.POST("/my-tickets/:id/resale", HandleListForResale(bookingSvc))
+		userAuth.DELETE("/resale/:id", HandleCancelResaleListing(bookingSvc))
+		userAuth.GET("/my-resale", HandleMyResale(bookingSvc))
+
+		// Presale access codes unlock code-only tiers (marketplace and checkout)
+		userAuth.POST("/access-codes/redeem", HandleRedeemAccessCode(bookingSvc))
+
+		// Virtual waiting room for busy on-sales; checkout then sends the token in X-Queue-Token
+		userAuth.POST("/events/:id/queue", HandleJoinQueue(bookingSvc))
+		userAuth.GET("/events/:id/queue", HandleQueueStatus(bookingSvc))
+		userAuth.DELETE("/events/:id/queue", HandleLeaveQueue(bookingSvc))
+
+		// Waitlists for sold out tiers; offers are bought through /checkout as usual
+		userAuth.POST("/events/:id/tiers/:category/waitlist", HandleJoinWaitlist(bookingSvc))
+		userAuth.GET("/events/:id/tiers/:category/waitlist", HandleWaitlistPosition(bookingSvc))
+		userAuth.DELETE("/events/:id/tiers/:category/waitlist", HandleLeaveWaitlist(bookingSvc))
+		userAuth.GET("/my-waitlists", HandleMyWaitlists(bookingSvc))
+
 		userAuth.PUT("/my-profile", func(c *gin.Context) {
 			userID := c.MustGet("userID").(uint)
 
@@ -175,6 +196,25 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 			c.JSON(200, user)
 		})
 
+		userAuth.GET("/my-notifications", func(c *gin.Context) {
+			userID := c.MustGet("userID").(uint)
+			notifications, err := bookingSvc.GetNotifications(userID)
+			if err != nil {
+				c.JSON(500, gin.H{"error": "Failed to load notifications"})
+				return
+			}
+			c.JSON(200, notifications)
+		})
+
+		userAuth.POST("/my-notifications/read", func(c *gin.Context) {
+			userID := c.MustGet("userID").(uint)
+			if err := bookingSvc.MarkNotificationsRead(userID); err != nil {
+				c.JSON(500, gin.H{"error": "Failed to update notifications"})
+				return
+			}
+			c.JSON(200, gin.H{"message": "All caught up!"})
+		})
+
 		userAuth.GET("/users/me/points", func(c *gin.Context) {
 			userID := c.MustGet("userID").(uint)
 			history, err := repo.GetPointHistory(userID)
@@ -186,7 +226,16 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 		})
 
 		// 🚀 THE MAGIC: Routing to the newly created, multi-item handler
-		userAuth.POST("/checkout", HandleCheckout(bookingSvc))
+		// Retried with the same Idempotency-Key, a checkout returns the first order instead of placing another
+		userAuth.POST("/checkout", middleware.Idempotent(repo), HandleCheckout(bookingSvc))
+
+		// Cart across several events, paid as one order
+		userAuth.GET("/cart", HandleGetCart(bookingSvc))
+		userAuth.DELETE("/cart", HandleClearCart(bookingSvc))
+		userAuth.POST("/cart/items", HandleAddCartItem(bookingSvc))
+		userAuth.PUT("/cart/items/:id", HandleUpdateCartItem(bookingSvc))
+		userAuth.DELETE("/cart/items/:id", HandleRemoveCartItem(bookingSvc))
+		userAuth.POST("/cart/checkout", middleware.Idempotent(repo), HandleCartCheckout(bookingSvc))
 
 		userAuth.GET("/orders/:id/status", func(c *gin.Context) {
 			id := c.Param("id")
@@ -214,7 +263,13 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 		adminAuth.PATCH("/tickets/:id/checkin", middleware.RolesRequired("agent", "admin"), HandleTicketCheckin(bookingSvc))
 		adminAuth.GET("/agent/search-customer", middleware.RolesRequired("agent", "admin"), HandleSearchCustomer(adminRepo))
 		adminAuth.GET("/admin/tickets/lookup", middleware.RolesRequired("agent", "admin"), HandleTicketLookup(adminRepo))
-		adminAuth.POST("/admin/tickets/bulk-checkin", middleware.RolesRequired("agent", "admin"), HandleBulkCheckin(adminRepo))
+		adminAuth.POST("/admin/tickets/bulk-checkin", middleware.RolesRequired("agent", "admin"), HandleBulkCheckin(bookingSvc))
+		adminAuth.GET("/admin/tickets/:id/scans", middleware.RolesRequired("agent", "admin"), HandleTicketScans(bookingSvc))
+		adminAuth.GET("/admin/tickets/:id/transfers", middleware.RolesRequired("agent", "admin"), HandleTicketTransfers(bookingSvc))
+
+		// Offline gate devices: download before the doors open, upload when back online
+		adminAuth.GET("/scanner/events/:id/manifest", middleware.RolesRequired("agent", "admin"), HandleScannerManifest(bookingSvc))
+		adminAuth.POST("/scanner/events/:id/sync", middleware.RolesRequired("agent", "admin"), HandleScannerSync(bookingSvc))
 
 		// Admin Only Routes
 		adminOnly := adminAuth.Group("/")
@@ -224,6 +279,32 @@ func SetupRoutes(r *gin.Engine, rawRepo any, bookingSvc *service.BookingService)
 			adminOnly.DELETE("/tickets/:id", HandleDeleteTicket(bookingSvc))
 			adminOnly.GET("/admin/events/:id", HandleGetEventDetails(adminRepo))
 			adminOnly.PUT("/admin/events/:id", HandleUpdateEvent(bookingSvc))
+			adminOnly.POST("/admin/events/:id/status", HandleEventStatus(bookingSvc))
+			adminOnly.POST("/admin/events/:id/cancel", HandleCancelEvent(bookingSvc))
+			adminOnly.GET("/admin/jobs/:id", HandleGetJob(bookingSvc))
+			adminOnly.POST("/admin/jobs/:id/retry", HandleRetryJob(bookingSvc))
+			adminOnly.POST("/admin/venues", HandleCreateVenue(bookingSvc))
+			adminOnly.GET("/admin/venues/:id", HandleGetVenue(bookingSvc))
+			adminOnly.POST("/admin/promo-codes", HandleCreatePromoCode(bookingSvc))
+			adminOnly.GET("/admin/promo-codes", HandleListPromoCodes(bookingSvc))
+			adminOnly.GET("/admin/promo-codes/:id", HandleGetPromoCode(bookingSvc))
+			adminOnly.PUT("/admin/promo-codes/:id", HandleUpdatePromoCode(bookingSvc))
+			adminOnly.DELETE("/admin/promo-codes/:id", HandleDeletePromoCode(bookingSvc))
+
+			// Presale access codes for code-only tiers
+			adminOnly.POST("/admin/access-codes", HandleCreateAccessCodes(bookingSvc))
+			adminOnly.GET("/admin/access-codes", HandleListAccessCodes(bookingSvc))
+			adminOnly.DELETE("/admin/access-codes/:id", HandleDeactivateAccessCode(bookingSvc))
+			adminOnly.GET("/admin/refund-requests", HandleListRefundRequests(bookingSvc))
+			adminOnly.POST("/admin/refund-requests/:id/approve", HandleReviewRefundRequest(bookingSvc, true))
+			adminOnly.POST("/admin/refund-requests/:id/reject", HandleReviewRefundRequest(bookingSvc, false))
+			adminOnly.POST("/admin/orders/:id/refunds", HandleRefundOrderTickets(bookingSvc))
+			adminOnly.GET("/admin/resale/payouts", HandleListResalePayouts(bookingSvc))
+			adminOnly.POST("/admin/resale/payouts/:id/paid", HandleMarkPayoutPaid(bookingSvc))
+			adminOnly.PUT("/admin/charges", HandleSetChargePolicy(bookingSvc))
+			adminOnly.GET("/admin/events/:id/charges", HandleGetChargePolicies(bookingSvc))
+			adminOnly.PUT("/admin/events/:id/charges", HandleSetChargePolicy(bookingSvc))
+			adminOnly.PUT("/admin/events/:id/tiers/:category/charges", HandleSetChargePolicy(bookingSvc))
 		}
 	}
 }
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /scanner/events/:id/manifest?since=<unix seconds>
+func HandleScannerManifest(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		var since time.Time
+		if raw := c.Query("since"); raw != "" {
+			secs, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "since must be a unix timestamp"})
+				return
+			}
+			since = time.Unix(secs, 0)
+		}
+
+		manifest, err := bookingSvc.GetScannerManifest(eventID, since)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, manifest)
+	}
+}
+
+// POST /scanner/events/:id/sync
+func HandleScannerSync(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		var batch service.ScanBatch
+		if err := c.ShouldBindJSON(&batch); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		operatorID := c.MustGet("userID").(uint)
+		report, err := bookingSvc.SyncScans(eventID, operatorID, batch)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, report)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /admin/venues
+func HandleCreateVenue(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req domain.CreateVenueRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		venue, err := bookingSvc.CreateVenue(req, c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, venue)
+	}
+}
+
+// GET /admin/venues/:id
+func HandleGetVenue(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var venueID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &venueID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Venue ID"})
+			return
+		}
+
+		venue, err := bookingSvc.GetVenue(venueID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, venue)
+	}
+}
+
+// GET /events/:id/seats
+func HandleEventSeats(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		seats, err := bookingSvc.GetSeatAvailability(eventID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"event_id": eventID, "seats": seats})
+	}
+}
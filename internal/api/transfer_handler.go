@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /my-tickets/:id/transfer
+func HandleStartTransfer(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Email string `json:"email" binding:"required,email"`
+			Note  string `json:"note"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		transfer, err := bookingSvc.StartTicketTransfer(c.MustGet("userID").(uint), c.Param("id"), input.Email, input.Note)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, transfer)
+	}
+}
+
+// GET /my-tickets/:id/transfers
+func HandleMyTicketTransfers(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transfers, err := bookingSvc.GetTicketTransfers(c.MustGet("userID").(uint), c.Param("id"))
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, transfers)
+	}
+}
+
+// GET /my-transfers
+func HandleMyTransfers(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transfers, err := bookingSvc.GetUserTransfers(c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load transfers"})
+			return
+		}
+		c.JSON(200, transfers)
+	}
+}
+
+// POST /transfers/:id/accept, /decline and /cancel
+func HandleRespondTransfer(bookingSvc *service.BookingService, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var transferID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &transferID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Transfer ID"})
+			return
+		}
+
+		respond := bookingSvc.CancelTicketTransfer
+		switch action {
+		case domain.TransferAccepted:
+			respond = bookingSvc.AcceptTicketTransfer
+		case domain.TransferDeclined:
+			respond = bookingSvc.DeclineTicketTransfer
+		}
+		transfer, err := respond(c.MustGet("userID").(uint), transferID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, transfer)
+	}
+}
+
+// GET /admin/tickets/:id/transfers
+func HandleTicketTransfers(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transfers, err := bookingSvc.GetTicketTransferHistory(c.Param("id"))
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, transfers)
+	}
+}
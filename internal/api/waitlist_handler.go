@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"neptunes-tix/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /events/:id/tiers/:category/waitlist
+func HandleJoinWaitlist(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+		var input struct {
+			Quantity int `json:"quantity" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		entry, err := bookingSvc.JoinWaitlist(c.MustGet("userID").(uint), eventID, c.Param("category"), input.Quantity)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(201, entry)
+	}
+}
+
+// GET /events/:id/tiers/:category/waitlist
+func HandleWaitlistPosition(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		entry, err := bookingSvc.GetWaitlistPosition(c.MustGet("userID").(uint), eventID, c.Param("category"))
+		if err != nil {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, entry)
+	}
+}
+
+// DELETE /events/:id/tiers/:category/waitlist
+func HandleLeaveWaitlist(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eventID uint
+		if _, err := fmt.Sscanf(c.Param("id"), "%d", &eventID); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid Event ID"})
+			return
+		}
+
+		if err := bookingSvc.LeaveWaitlist(c.MustGet("userID").(uint), eventID, c.Param("category")); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "You have left the waitlist"})
+	}
+}
+
+// GET /my-waitlists
+func HandleMyWaitlists(bookingSvc *service.BookingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := bookingSvc.GetUserWaitlists(c.MustGet("userID").(uint))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load waitlists"})
+			return
+		}
+		c.JSON(200, entries)
+	}
+}
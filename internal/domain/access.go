@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Checkout error codes for restricted tiers (see TicketTier.CheckAccess)
+const (
+	CodeTierLocked     = "TIER_LOCKED"     // needs an access code the buyer hasn't redeemed
+	CodeTierRestricted = "TIER_RESTRICTED" // for other roles or a higher loyalty level
+)
+
+// Loyalty levels, lowest first. A user's level follows their points balance.
+const (
+	LoyaltyMember   = "member"
+	LoyaltySilver   = "silver"
+	LoyaltyGold     = "gold"
+	LoyaltyPlatinum = "platinum"
+)
+
+var loyaltyThresholds = []struct {
+	level  string
+	points int
+}{
+	{LoyaltyMember, 0},
+	{LoyaltySilver, 1000},
+	{LoyaltyGold, 5000},
+	{LoyaltyPlatinum, 20000},
+}
+
+func ValidLoyaltyLevel(level string) bool {
+	return loyaltyRank(level) >= 0
+}
+
+// LoyaltyLevelFor is the level a points balance reaches
+func LoyaltyLevelFor(points int) string {
+	level := LoyaltyMember
+	for _, t := range loyaltyThresholds {
+		if points >= t.points {
+			level = t.level
+		}
+	}
+	return level
+}
+
+func loyaltyRank(level string) int {
+	for i, t := range loyaltyThresholds {
+		if t.level == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateAccess checks the tier's access restrictions
+func (t *TicketTier) ValidateAccess() error {
+	if t.MinLoyaltyLevel != "" && !ValidLoyaltyLevel(t.MinLoyaltyLevel) {
+		return fmt.Errorf("tier '%s': min_loyalty_level must be member, silver, gold or platinum", t.Category)
+	}
+	for _, role := range t.AllowedRoles {
+		if strings.TrimSpace(role) == "" {
+			return fmt.Errorf("tier '%s': allowed_roles cannot contain a blank role", t.Category)
+		}
+	}
+	return nil
+}
+
+// Restricted says whether buying the tier needs more than being logged in
+func (t *TicketTier) Restricted() bool {
+	return t.RequiresCode || len(t.AllowedRoles) > 0 || t.MinLoyaltyLevel != ""
+}
+
+// CheckAccess says whether the user may buy the tier at all; unlocked is whether
+// they redeemed an access code for it
+func (t *TicketTier) CheckAccess(user *User, unlocked bool) error {
+	if t.RequiresCode && !unlocked {
+		return &CheckoutError{CodeTierLocked, fmt.Sprintf("%s needs an access code", t.Category)}
+	}
+	if len(t.AllowedRoles) > 0 && !slices.Contains(t.AllowedRoles, user.Role) {
+		return &CheckoutError{CodeTierRestricted, fmt.Sprintf("%s is only for %s", t.Category, strings.Join(t.AllowedRoles, ", "))}
+	}
+	if t.MinLoyaltyLevel != "" && loyaltyRank(LoyaltyLevelFor(user.Points)) < loyaltyRank(t.MinLoyaltyLevel) {
+		return &CheckoutError{CodeTierRestricted, fmt.Sprintf("%s is only for %s members and above", t.Category, t.MinLoyaltyLevel)}
+	}
+	return nil
+}
+
+// AccessCode unlocks code-only tiers of one event for whoever redeems it. MaxUses 1
+// makes it single-use (one per fan-club member); 0 means any number of people.
+type AccessCode struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	Code      string       `gorm:"uniqueIndex" json:"code"` // stored upper-case
+	EventID   uint         `gorm:"index" json:"event_id"`
+	Tiers     []TicketTier `json:"tiers,omitempty" gorm:"many2many:access_code_tiers"`
+	MaxUses   int          `json:"max_uses"` // 0 = unlimited
+	Uses      int          `json:"uses"`     // users who redeemed it
+	Active    bool         `json:"active"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+	Note      string       `json:"note"` // who it was given to, e.g. "fan club batch 1"
+	CreatedBy uint         `json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// TierUnlock records that a user may see and buy a code-only tier
+type TierUnlock struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_tier_unlock" json:"user_id"`
+	TierID       uint      `gorm:"uniqueIndex:idx_tier_unlock" json:"tier_id"`
+	AccessCodeID uint      `gorm:"index" json:"access_code_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AccessCodeRequest creates access codes for code-only tiers of an event (admin).
+// Without a Code, Count random codes are generated with the same settings.
+type AccessCodeRequest struct {
+	EventID    uint       `json:"event_id" binding:"required"`
+	Categories []string   `json:"categories" binding:"required"`
+	Code       string     `json:"code"`
+	Count      int        `json:"count"`    // generated codes, default 1
+	MaxUses    int        `json:"max_uses"` // 1 = single-use, 0 = unlimited
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Note       string     `json:"note"`
+}
+
+// Codes validates the request and returns the codes to create
+func (r AccessCodeRequest) Codes() ([]string, error) {
+	if r.MaxUses < 0 {
+		return nil, fmt.Errorf("max_uses cannot be negative")
+	}
+	if len(r.Categories) == 0 {
+		return nil, fmt.Errorf("an access code needs at least one category")
+	}
+	if r.Code != "" {
+		code := NormalizeAccessCode(r.Code)
+		if code == "" || strings.ContainsAny(code, " \t") {
+			return nil, fmt.Errorf("code cannot be blank or contain spaces")
+		}
+		if r.Count > 1 {
+			return nil, fmt.Errorf("count only applies to generated codes")
+		}
+		return []string{code}, nil
+	}
+
+	count := max(r.Count, 1)
+	if count > 1000 {
+		return nil, fmt.Errorf("at most 1000 codes can be generated at once")
+	}
+	codes := make([]string, count)
+	for i := range codes {
+		codes[i] = newAccessCode()
+	}
+	return codes, nil
+}
+
+func NormalizeAccessCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newAccessCode is 10 characters from an alphabet without look-alikes (0/O, 1/I)
+func newAccessCode() string {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	b := make([]byte, 10)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
+
+// Usable says why a code can't be redeemed at `now`, if it can't
+func (a *AccessCode) Usable(now time.Time) error {
+	if !a.Active {
+		return fmt.Errorf("this access code is no longer valid")
+	}
+	if a.ExpiresAt != nil && !now.Before(*a.ExpiresAt) {
+		return fmt.Errorf("this access code expired")
+	}
+	if a.MaxUses > 0 && a.Uses >= a.MaxUses {
+		return fmt.Errorf("this access code has already been used")
+	}
+	return nil
+}
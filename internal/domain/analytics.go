@@ -1,15 +1,15 @@
 package domain
 
 type EventStat struct {
-	EventID   uint    `json:"event_id"`
-	EventName string  `json:"event_name"`
-	Revenue   float64 `json:"revenue"`
-	Sold      int64   `json:"sold"`
-	Scanned   int64   `json:"scanned"`
+	EventID   uint   `json:"event_id"`
+	EventName string `json:"event_name"`
+	Revenue   Money  `json:"revenue"`
+	Sold      int64  `json:"sold"`
+	Scanned   int64  `json:"scanned"`
 }
 
 type DashboardStats struct {
-	TotalRevenue float64     `json:"total_revenue"`
+	TotalRevenue Money       `json:"total_revenue"`
 	TotalSold    int64       `json:"total_sold"`
 	TotalScanned int64       `json:"total_scanned"`
 	Events       []EventStat `json:"events"`
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+)
+
+// CartHoldTime is how long a cart keeps its contents after the last change. What
+// is in the cart is held for the user (tier stock, and seats) until then, so it
+// can't sell out before checkout; once it runs out the holds are released.
+const CartHoldTime = 30 * time.Minute
+
+// Cart is a user's basket across any number of events. There is at most one per
+// user; checking it out turns everything in it into a single Order.
+type Cart struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"uniqueIndex" json:"user_id"`
+	Items     []CartItem `json:"items"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartItem is a quantity of a general admission tier, or one reserved seat
+type CartItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CartID    uint      `gorm:"index" json:"cart_id"`
+	EventID   uint      `json:"event_id"`
+	Category  string    `json:"category"`
+	Quantity  int       `json:"quantity"`
+	SeatID    *uint     `json:"seat_id,omitempty"`
+	SeatLabel string    `json:"seat_label,omitempty"`
+	UnitPrice Money     `json:"unit_price" gorm:"-"` // current list price, for display
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *Cart) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// AddCartItemRequest adds a quantity of a tier, or a single seat by seat_id
+type AddCartItemRequest struct {
+	EventID  uint   `json:"event_id" binding:"required"`
+	Category string `json:"category"`
+	Quantity int    `json:"quantity" binding:"min=0"`
+	SeatID   *uint  `json:"seat_id"`
+}
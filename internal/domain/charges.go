@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChargePolicy is the booking fee and tax charged on tickets. A policy with a TierID
+// beats one for the whole event, which beats the global one (both IDs nil).
+type ChargePolicy struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EventID    *uint     `gorm:"index" json:"event_id"`
+	TierID     *uint     `gorm:"index" json:"tier_id"`
+	FeeFixed   Money     `json:"fee_fixed" gorm:"column:fee_fixed_sen"` // per ticket
+	FeePercent float64   `json:"fee_percent"`                           // % of the ticket price after promo discounts
+	TaxName    string    `json:"tax_name"`                              // printed on the receipt, e.g. "SST"
+	TaxRate    float64   `json:"tax_rate"`                              // % of ticket + fee
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ChargePolicyRequest struct {
+	FeeFixed   Money   `json:"fee_fixed"`
+	FeePercent float64 `json:"fee_percent"`
+	TaxName    string  `json:"tax_name"`
+	TaxRate    float64 `json:"tax_rate"`
+}
+
+func (r ChargePolicyRequest) ApplyTo(p *ChargePolicy) error {
+	if r.FeeFixed.IsNegative() || r.FeePercent < 0 || r.FeePercent > 100 || r.TaxRate < 0 || r.TaxRate > 100 {
+		return fmt.Errorf("fees and tax rates must be between 0 and 100")
+	}
+	p.FeeFixed = r.FeeFixed
+	p.FeePercent = r.FeePercent
+	p.TaxName = r.TaxName
+	if p.TaxName == "" {
+		p.TaxName = "SST"
+	}
+	p.TaxRate = r.TaxRate
+	return nil
+}
+
+// ResolveChargePolicy picks the most specific policy for an order item
+func ResolveChargePolicy(policies []ChargePolicy, item OrderItem) ChargePolicy {
+	var event, global *ChargePolicy
+	for i := range policies {
+		p := &policies[i]
+		switch {
+		case p.TierID != nil && *p.TierID == item.TierID:
+			return *p
+		case p.TierID == nil && p.EventID != nil && *p.EventID == item.EventID:
+			event = p
+		case p.TierID == nil && p.EventID == nil:
+			global = p
+		}
+	}
+	if event != nil {
+		return *event
+	}
+	if global != nil {
+		return *global
+	}
+	return ChargePolicy{TaxName: "SST", FeeFixed: Sen(0)}
+}
+
+// Order line kinds. Discount lines are negative.
+const (
+	LineTicket   = "ticket"
+	LineFee      = "fee"
+	LineTax      = "tax"
+	LineDiscount = "discount"
+)
+
+// OrderLine is one row of the receipt; an order's lines always add up to its TotalAmount
+type OrderLine struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrderID     uint      `gorm:"index" json:"order_id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	OrderItemID *uint     `json:"order_item_id,omitempty"`
+	Quantity    int       `json:"quantity,omitempty"`
+	UnitAmount  Money     `json:"unit_amount" gorm:"column:unit_amount_sen"`
+	Amount      Money     `json:"amount" gorm:"column:amount_sen"`
+	Source      string    `json:"source,omitempty"` // discounts only: promo, points
+	PromoCodeID *uint     `gorm:"index" json:"promo_code_id,omitempty"`
+	Code        string    `json:"code,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OrderBreakdown is the receipt summary of an order's lines
+type OrderBreakdown struct {
+	Subtotal  Money `json:"subtotal"`
+	Fees      Money `json:"fees"`
+	Tax       Money `json:"tax"`
+	Discounts Money `json:"discounts"` // negative
+	Total     Money `json:"total"`
+}
+
+func Breakdown(lines []OrderLine) OrderBreakdown {
+	b := OrderBreakdown{Subtotal: Sen(0), Fees: Sen(0), Tax: Sen(0), Discounts: Sen(0)}
+	for _, l := range lines {
+		switch l.Kind {
+		case LineTicket:
+			b.Subtotal = b.Subtotal.Add(l.Amount)
+		case LineFee:
+			b.Fees = b.Fees.Add(l.Amount)
+		case LineTax:
+			b.Tax = b.Tax.Add(l.Amount)
+		case LineDiscount:
+			b.Discounts = b.Discounts.Add(l.Amount)
+		}
+	}
+	b.Total = b.Subtotal.Add(b.Fees).Add(b.Tax).Add(b.Discounts)
+	return b
+}
+
+// PriceOrder turns held items into receipt lines:
+//
+//  1. a ticket line per item at list price
+//  2. the promo discount, spread over the items it covers
+//  3. per item, the fee (on the discounted price) and tax (on discounted price + fee)
+//  4. points, last, like a payment: they never reduce the taxable amount
+//
+// promo may be nil. Returns the lines (without OrderID) and the total.
+func PriceOrder(items []OrderItem, promo *PromoCode, promoAmount Money, policies []ChargePolicy, points int) ([]OrderLine, Money) {
+	var lines []OrderLine
+	bases := make([]Money, len(items))
+	for i, item := range items {
+		itemID := item.ID
+		bases[i] = item.UnitPrice.Times(item.Quantity)
+		lines = append(lines, OrderLine{
+			Kind:        LineTicket,
+			Description: fmt.Sprintf("%d x %s", item.Quantity, item.Category),
+			OrderItemID: &itemID,
+			Quantity:    item.Quantity,
+			UnitAmount:  item.UnitPrice,
+			Amount:      bases[i],
+		})
+	}
+
+	if promo != nil && promoAmount.IsPositive() {
+		lines = append(lines, OrderLine{
+			Kind:        LineDiscount,
+			Description: promo.Describe(),
+			Amount:      promoAmount.Neg(),
+			Source:      DiscountPromo,
+			PromoCodeID: &promo.ID,
+			Code:        promo.Code,
+		})
+		// Pro rata over covered items; the last one takes the rounding remainder
+		eligible := Sen(0)
+		last := -1
+		for i, item := range items {
+			if promo.Covers(item) {
+				eligible = eligible.Add(bases[i])
+				last = i
+			}
+		}
+		left := promoAmount
+		for i, item := range items {
+			if !promo.Covers(item) {
+				continue
+			}
+			share := left
+			if i != last {
+				share = promoAmount.Share(bases[i], eligible)
+			}
+			bases[i] = bases[i].Sub(share)
+			left = left.Sub(share)
+		}
+	}
+
+	for i, item := range items {
+		itemID := item.ID
+		policy := ResolveChargePolicy(policies, item)
+		fee := policy.FeeFixed.Times(item.Quantity).Add(bases[i].Percent(policy.FeePercent))
+		if fee.IsPositive() {
+			lines = append(lines, OrderLine{
+				Kind:        LineFee,
+				Description: fmt.Sprintf("Booking fee, %s", item.Category),
+				OrderItemID: &itemID,
+				Amount:      fee,
+			})
+		}
+		if tax := bases[i].Add(fee).Percent(policy.TaxRate); tax.IsPositive() {
+			lines = append(lines, OrderLine{
+				Kind:        LineTax,
+				Description: fmt.Sprintf("%s %g%%, %s", policy.TaxName, policy.TaxRate, item.Category),
+				OrderItemID: &itemID,
+				Amount:      tax,
+			})
+		}
+	}
+
+	// 100 points = RM1, so a point is worth one sen
+	total := Breakdown(lines).Total
+	if pointsValue := MinMoney(Sen(int64(points)), total); pointsValue.IsPositive() {
+		lines = append(lines, OrderLine{
+			Kind:        LineDiscount,
+			Description: fmt.Sprintf("%d points redeemed", pointsValue.Sen),
+			Amount:      pointsValue.Neg(),
+			Source:      DiscountPoints,
+		})
+		total = total.Sub(pointsValue)
+	}
+	return lines, total
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// summarize keeps what PriceOrder decides: kind, which item, and how much
+func summarize(lines []OrderLine) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		item := uint(0)
+		if l.OrderItemID != nil {
+			item = *l.OrderItemID
+		}
+		out[i] = fmt.Sprintf("%s #%d %d", l.Kind, item, l.Amount.Sen)
+	}
+	return out
+}
+
+func TestPriceOrder(t *testing.T) {
+	thirds := []OrderItem{
+		{ID: 1, EventID: 1, TierID: 10, Category: "A", UnitPrice: Sen(3333), Quantity: 1},
+		{ID: 2, EventID: 1, TierID: 10, Category: "A", UnitPrice: Sen(3333), Quantity: 1},
+		{ID: 3, EventID: 1, TierID: 11, Category: "B", UnitPrice: Sen(3334), Quantity: 1},
+	}
+	wholeOrder := &PromoCode{Code: "TENOFF", Kind: PromoFixed, Amount: Sen(1000)}
+	tierB := &PromoCode{Code: "BONLY", Kind: PromoFixed, Amount: Sen(1000), Tiers: []TicketTier{{ID: 11}}}
+	sst := []ChargePolicy{{FeeFixed: Sen(100), FeePercent: 5, TaxName: "SST", TaxRate: 6}}
+	halfFee := []ChargePolicy{{FeeFixed: Sen(0), FeePercent: 50}}
+
+	tests := []struct {
+		name        string
+		items       []OrderItem
+		promo       *PromoCode
+		promoAmount Money
+		policies    []ChargePolicy
+		points      int
+		wantLines   []string
+		wantTotal   int64
+	}{
+		{
+			name:      "fixed and percent fee, tax on ticket plus fee",
+			items:     []OrderItem{{ID: 1, UnitPrice: Sen(5000), Quantity: 2}},
+			policies:  sst,
+			wantLines: []string{"ticket #1 10000", "fee #1 700", "tax #1 642"}, // fee 2*100 + 5%; tax 6% of 10700
+			wantTotal: 11342,
+		},
+		{
+			name:        "promo spread pro rata, last covered item takes the remainder",
+			items:       thirds,
+			promo:       wholeOrder,
+			promoAmount: Sen(1000),
+			policies:    halfFee,
+			// Shares 333, 333 and 334: every base ends up 3000, so every fee is 1500
+			wantLines: []string{"ticket #1 3333", "ticket #2 3333", "ticket #3 3334", "discount #0 -1000", "fee #1 1500", "fee #2 1500", "fee #3 1500"},
+			wantTotal: 13500,
+		},
+		{
+			name:        "fee on the discounted price only for covered items",
+			items:       thirds,
+			promo:       tierB,
+			promoAmount: Sen(1000),
+			policies:    halfFee,
+			wantLines:   []string{"ticket #1 3333", "ticket #2 3333", "ticket #3 3334", "discount #0 -1000", "fee #1 1667", "fee #2 1667", "fee #3 1167"},
+			wantTotal:   13501,
+		},
+		{
+			name:        "promo amount of zero adds no line",
+			items:       []OrderItem{{ID: 1, UnitPrice: Sen(5000), Quantity: 1}},
+			promo:       wholeOrder,
+			promoAmount: Sen(0),
+			wantLines:   []string{"ticket #1 5000"},
+			wantTotal:   5000,
+		},
+		{
+			name:      "points come off after tax",
+			items:     []OrderItem{{ID: 1, UnitPrice: Sen(1000), Quantity: 1}},
+			policies:  []ChargePolicy{{TaxName: "SST", TaxRate: 6}},
+			points:    500,
+			wantLines: []string{"ticket #1 1000", "tax #1 60", "discount #0 -500"},
+			wantTotal: 560,
+		},
+		{
+			name:      "points capped at the total",
+			items:     []OrderItem{{ID: 1, UnitPrice: Sen(1000), Quantity: 1}},
+			policies:  []ChargePolicy{{TaxName: "SST", TaxRate: 6}},
+			points:    5000,
+			wantLines: []string{"ticket #1 1000", "tax #1 60", "discount #0 -1060"},
+			wantTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, total := PriceOrder(tt.items, tt.promo, tt.promoAmount, tt.policies, tt.points)
+			if got := summarize(lines); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("lines = %q, want %q", got, tt.wantLines)
+			}
+			if total.Sen != tt.wantTotal {
+				t.Errorf("total = %s, want %s", total, Sen(tt.wantTotal))
+			}
+			if b := Breakdown(lines); b.Total.Sen != total.Sen {
+				t.Errorf("lines add up to %s, total is %s", b.Total, total)
+			}
+		})
+	}
+}
+
+func TestResolveChargePolicy(t *testing.T) {
+	event, tier := uint(1), uint(10)
+	policies := []ChargePolicy{
+		{ID: 1, TaxRate: 6},
+		{ID: 2, EventID: &event, TaxRate: 8},
+		{ID: 3, EventID: &event, TierID: &tier, TaxRate: 10},
+	}
+
+	tests := []struct {
+		name     string
+		policies []ChargePolicy
+		item     OrderItem
+		wantID   uint
+	}{
+		{name: "tier beats event", policies: policies, item: OrderItem{EventID: 1, TierID: 10}, wantID: 3},
+		{name: "event beats global", policies: policies, item: OrderItem{EventID: 1, TierID: 11}, wantID: 2},
+		{name: "global", policies: policies, item: OrderItem{EventID: 2, TierID: 20}, wantID: 1},
+		{name: "none configured", policies: nil, item: OrderItem{EventID: 2, TierID: 20}, wantID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveChargePolicy(tt.policies, tt.item); got.ID != tt.wantID {
+				t.Errorf("ResolveChargePolicy() = policy %d, want %d", got.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -1,51 +1,199 @@
 package domain
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Event struct {
 	gorm.Model
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Venue       string   `json:"venue"`
-	Date        string   `json:"date"`
-	LocationURL string   `json:"location_url"`
-	DoorsOpen   string   `json:"doors_open"`
-	Tickets     []Ticket `json:"-"`
+	Name                string     `json:"name"`
+	Description         string     `json:"description"`
+	Venue               string     `json:"venue"`
+	Date                string     `json:"date"` // legacy, kept in step with StartsAt
+	LocationURL         string     `json:"location_url"`
+	DoorsOpen           string     `json:"doors_open"`                                   // legacy, kept in step with DoorsOpenAt
+	TimeZone            string     `json:"time_zone" gorm:"default:'Asia/Kuala_Lumpur'"` // IANA zone of the venue
+	StartsAt            *time.Time `json:"starts_at" gorm:"index"`
+	EndsAt              *time.Time `json:"ends_at"`
+	DoorsOpenAt         *time.Time `json:"doors_open_at"`
+	Status              string     `json:"status" gorm:"index"`                       // See event_status.go
+	EntryPolicy         string     `json:"entry_policy" gorm:"default:'single'"`      // single, reentry, in_out
+	SeatMapID           *uint      `json:"seat_map_id"`                               // reserved seating; nil means general admission
+	RefundPolicy        string     `json:"refund_policy" gorm:"default:'refundable'"` // See refund.go
+	RefundCutoffHours   int        `json:"refund_cutoff_hours"`                       // no refund requests this close to the start
+	TransferCutoffHours int        `json:"transfer_cutoff_hours"`                     // no ticket transfers this close to the start, see transfer.go
+	ResaleEnabled       bool       `json:"resale_enabled"`                            // fans may resell tickets, see resale.go
+	ResaleCapPercent    int        `json:"resale_cap_percent" gorm:"default:100"`     // resale price cap, % of face value
+	QueueEnabled        bool       `json:"queue_enabled"`                             // checkout only through the virtual waiting room, see queue.go
+	QueueAdmitRate      int        `json:"queue_admit_rate"`                          // users let into checkout per second
+	TierInventory       bool       `json:"-" gorm:"default:false"`                    // stock counted on TicketTier, see MigrateToTierInventory
+	Tickets             []Ticket   `json:"-"`
 }
 
 type CreateEventRequest struct {
-	EventName   string       `json:"event_name" binding:"required"`
-	Description string       `json:"description"`
-	Venue       string       `json:"venue"`
-	Date        string       `json:"date"`
-	Tiers       []TicketTier `json:"tiers" binding:"required"`
-	LocationURL string       `json:"location_url"`
-	DoorsOpen   string       `json:"doors_open"`
+	EventName           string       `json:"event_name" binding:"required"`
+	Description         string       `json:"description"`
+	Venue               string       `json:"venue"`
+	Tiers               []TicketTier `json:"tiers" binding:"required"`
+	LocationURL         string       `json:"location_url"`
+	EntryPolicy         string       `json:"entry_policy"`
+	Status              string       `json:"status"` // Optional starting status, defaults to draft
+	SeatMapID           *uint        `json:"seat_map_id"`
+	RefundPolicy        string       `json:"refund_policy"` // defaults to refundable
+	RefundCutoffHours   int          `json:"refund_cutoff_hours"`
+	TransferCutoffHours int          `json:"transfer_cutoff_hours"`
+	ResaleEnabled       bool         `json:"resale_enabled"`
+	ResaleCapPercent    *int         `json:"resale_cap_percent"` // defaults to 100
+	QueueEnabled        bool         `json:"queue_enabled"`
+	QueueAdmitRate      *int         `json:"queue_admit_rate"` // defaults to 50
+	ScheduleInput
+}
+
+// NewEvent builds and validates the Event a create request describes
+func (r CreateEventRequest) NewEvent() (*Event, error) {
+	event := &Event{
+		Name:        r.EventName,
+		Description: r.Description,
+		Venue:       r.Venue,
+		LocationURL: r.LocationURL,
+		EntryPolicy: r.EntryPolicy,
+		Status:      EventDraft,
+	}
+	if r.Status != "" && r.Status != EventDraft {
+		if err := event.CanTransitionTo(r.Status); err != nil {
+			return nil, err
+		}
+		event.Status = r.Status
+	}
+	if event.EntryPolicy == "" {
+		event.EntryPolicy = EntryPolicySingle
+	}
+	if err := event.ApplyRefundPolicy(r.RefundPolicy, &r.RefundCutoffHours); err != nil {
+		return nil, err
+	}
+	if err := event.ApplyTransferCutoff(&r.TransferCutoffHours); err != nil {
+		return nil, err
+	}
+	if err := event.ApplyResaleSettings(&r.ResaleEnabled, r.ResaleCapPercent); err != nil {
+		return nil, err
+	}
+	if err := event.ApplyQueueSettings(&r.QueueEnabled, r.QueueAdmitRate); err != nil {
+		return nil, err
+	}
+	if !ValidEntryPolicy(event.EntryPolicy) {
+		return nil, fmt.Errorf("entry_policy must be single, reentry or in_out")
+	}
+	if err := event.ApplySchedule(r.ScheduleInput); err != nil {
+		return nil, err
+	}
+	event.SeatMapID = r.SeatMapID
+	if err := ValidateNewTiers(r.Tiers, r.SeatMapID != nil); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Seated tiers take their capacity from the seat map, so they carry no Quantity
+func ValidateNewTiers(tiers []TicketTier, seated bool) error {
+	seen := map[string]bool{}
+	for _, tier := range tiers {
+		if tier.Category == "" {
+			return fmt.Errorf("every tier needs a category")
+		}
+		if seen[tier.Category] {
+			return fmt.Errorf("category '%s' is listed twice", tier.Category)
+		}
+		seen[tier.Category] = true
+		if !seated && tier.Quantity <= 0 {
+			return fmt.Errorf("tier '%s' needs a quantity above zero", tier.Category)
+		}
+		if tier.Price.IsNegative() {
+			return fmt.Errorf("tier '%s' cannot have a negative price", tier.Category)
+		}
+		if err := tier.ValidateRules(); err != nil {
+			return err
+		}
+		if err := tier.ValidateAccess(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type UpdateEventRequest struct {
-	Name        string `json:"name"`
-	Venue       string `json:"venue"`
-	Date        string `json:"date"`
-	Description string `json:"description"`
-	LocationURL string `json:"location_url"`
+	Name                string `json:"name"`
+	Venue               string `json:"venue"`
+	Description         string `json:"description"`
+	LocationURL         string `json:"location_url"`
+	EntryPolicy         string `json:"entry_policy"`
+	RefundPolicy        string `json:"refund_policy"`
+	RefundCutoffHours   *int   `json:"refund_cutoff_hours"`
+	TransferCutoffHours *int   `json:"transfer_cutoff_hours"`
+	ResaleEnabled       *bool  `json:"resale_enabled"`
+	ResaleCapPercent    *int   `json:"resale_cap_percent"`
+	QueueEnabled        *bool  `json:"queue_enabled"`
+	QueueAdmitRate      *int   `json:"queue_admit_rate"`
+	ScheduleInput
 	// 🚀 Actions for Tiers
 	AddTiers    []TicketTier `json:"add_tiers"`    // New categories to create
 	AddStock    []TicketTier `json:"add_stock"`    // Add more tickets to existing category
+	TierRules   []TicketTier `json:"tier_rules"`   // Replace sales window/limits of existing categories
 	RemoveTiers []string     `json:"remove_tiers"` // Categories to delete entirely
 }
 
+// TicketTier is one category of an event's inventory. Stock is tracked with counters
+// (capacity/sold/held) instead of a pre-generated row per seat; Ticket rows are only
+// written once an order is paid. In create/update requests, Quantity carries the
+// number of seats to create or add.
 type TicketTier struct {
-	Category string  `json:"category"`
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
+	ID       uint   `gorm:"primaryKey" json:"id,omitempty"`
+	EventID  uint   `gorm:"uniqueIndex:idx_tier_event_category" json:"event_id,omitempty"`
+	Category string `gorm:"uniqueIndex:idx_tier_event_category" json:"category"`
+	Price    Money  `json:"price" gorm:"column:price_sen"`
+	Capacity int    `json:"capacity" gorm:"check:chk_tier_counters,capacity >= sold + held AND sold >= 0 AND held >= 0"`
+	Sold     int    `json:"sold"`
+	Held     int    `json:"held"`
+	Seated   bool   `json:"seated"` // one seat per unit, sold by seat ID instead of quantity
+	Quantity int    `json:"quantity,omitempty" gorm:"-"`
+
+	// Sales rules (see tier_rules.go). Zero values mean "no limit".
+	SalesStartAt *time.Time `json:"sales_start_at"`
+	SalesEndAt   *time.Time `json:"sales_end_at"`
+	MinPerOrder  int        `json:"min_per_order"`
+	MaxPerOrder  int        `json:"max_per_order"`
+	MaxPerUser   int        `json:"max_per_user"`
+
+	// Access restrictions (see access.go). A code-only tier is hidden until unlocked.
+	RequiresCode    bool     `json:"requires_code"`
+	AllowedRoles    []string `json:"allowed_roles,omitempty" gorm:"serializer:json"`
+	MinLoyaltyLevel string   `json:"min_loyalty_level,omitempty"`
+
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+func (t *TicketTier) Available() int {
+	return t.Capacity - t.Sold - t.Held
 }
 
 type TierStats struct {
-	Category string  `json:"category"`
-	Price    float64 `json:"price"`
-	Stock    int     `json:"stock"`
-	Sold     int     `json:"sold"`
+	Category     string     `json:"category"`
+	Price        Money      `json:"price"`
+	Stock        int        `json:"stock"`
+	Sold         int        `json:"sold"`
+	Held         int        `json:"held"`
+	SalesStartAt *time.Time `json:"sales_start_at"`
+	SalesEndAt   *time.Time `json:"sales_end_at"`
+	MinPerOrder  int        `json:"min_per_order"`
+	MaxPerOrder  int        `json:"max_per_order"`
+	MaxPerUser   int        `json:"max_per_user"`
+
+	RequiresCode    bool     `json:"requires_code"`
+	AllowedRoles    []string `json:"allowed_roles,omitempty" gorm:"serializer:json"`
+	MinLoyaltyLevel string   `json:"min_loyalty_level,omitempty"`
 }
 
 // 2. The main response struct
@@ -0,0 +1,55 @@
+package domain
+
+import "fmt"
+
+// Event lifecycle. New events start as drafts and nothing is for sale until on_sale.
+const (
+	EventDraft     = "draft"     // admins only
+	EventPublished = "published" // announced, visible, not purchasable yet
+	EventOnSale    = "on_sale"
+	EventSoldOut   = "sold_out" // set automatically when the last ticket is held
+	EventPostponed = "postponed"
+	EventCancelled = "cancelled" // terminal
+)
+
+var eventTransitions = map[string][]string{
+	EventDraft:     {EventPublished, EventOnSale, EventCancelled},
+	EventPublished: {EventOnSale, EventPostponed, EventCancelled},
+	EventOnSale:    {EventPublished, EventSoldOut, EventPostponed, EventCancelled},
+	EventSoldOut:   {EventOnSale, EventPostponed, EventCancelled},
+	EventPostponed: {EventPublished, EventOnSale, EventCancelled},
+	EventCancelled: {},
+}
+
+// Statuses the public can see in listings
+var VisibleEventStatuses = []string{EventPublished, EventOnSale, EventSoldOut, EventPostponed}
+
+func ValidEventStatus(status string) bool {
+	_, ok := eventTransitions[status]
+	return ok
+}
+
+func (e *Event) CanTransitionTo(to string) error {
+	if !ValidEventStatus(to) {
+		return fmt.Errorf("unknown event status '%s'", to)
+	}
+	for _, allowed := range eventTransitions[e.Status] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot move event from %s to %s", e.Status, to)
+}
+
+func (e *Event) IsPurchasable() bool {
+	return e.Status == EventOnSale
+}
+
+// Doors only open for events that are actually happening as scheduled
+func (e *Event) AllowsCheckIn() bool {
+	switch e.Status {
+	case EventPublished, EventOnSale, EventSoldOut:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// IdempotencyKeyTTL is how long a stored response can be replayed
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKey records one Idempotency-Key a user sent to a mutating endpoint,
+// with a hash of the request and the response that went back. A retry with the same
+// key and body gets that response again instead of running the request twice.
+// The key is unique per user and endpoint (Scope).
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_idempotency_key" json:"user_id"`
+	Scope        string    `gorm:"uniqueIndex:idx_idempotency_key" json:"scope"` // "POST /checkout"
+	Key          string    `gorm:"uniqueIndex:idx_idempotency_key" json:"key"`
+	RequestHash  string    `json:"request_hash"`
+	Completed    bool      `json:"completed"` // false while the first request is still running
+	StatusCode   int       `json:"status_code"`
+	ResponseBody []byte    `json:"-"`
+	OrderID      *uint     `json:"order_id"` // the order the request created, if any
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
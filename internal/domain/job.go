@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+const (
+	JobTypeCancelEvent = "cancel_event"
+
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed" // finished, but some items need another pass (resume it)
+)
+
+// Job is a persisted background task. Cursor records how far it got, so a job
+// interrupted by a restart carries on from there instead of starting over.
+type Job struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Type       string     `gorm:"index" json:"type"`
+	TargetID   string     `gorm:"index" json:"target_id"`
+	Status     string     `gorm:"index" json:"status"`
+	Reason     string     `json:"reason"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Failed     int        `json:"failed"`
+	Cursor     uint       `json:"cursor"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedBy  uint       `json:"created_by"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the only currency we sell in today
+const DefaultCurrency = "MYR"
+
+// Money is an amount in minor units (sen) plus its ISO 4217 currency. All prices,
+// totals, fees and discounts use it, so checkout math is integer math.
+//
+// In the database it is a single BIGINT column of sen (see the *_sen columns); the
+// currency isn't stored yet because everything is MYR. In JSON it stays a plain
+// number of ringgit (12.5 → "12.50") so existing app builds keep working.
+type Money struct {
+	Sen      int64
+	Currency string
+}
+
+func Sen(sen int64) Money {
+	return Money{Sen: sen, Currency: DefaultCurrency}
+}
+
+// RM converts a ringgit amount (from a request or a legacy float column), rounding to the nearest sen
+func RM(ringgit float64) Money {
+	return Sen(int64(math.Round(ringgit * 100)))
+}
+
+func (m Money) Add(o Money) Money { return m.with(m.Sen + o.Sen) }
+func (m Money) Sub(o Money) Money { return m.with(m.Sen - o.Sen) }
+func (m Money) Neg() Money        { return m.with(-m.Sen) }
+func (m Money) Times(n int) Money { return m.with(m.Sen * int64(n)) }
+func (m Money) IsZero() bool      { return m.Sen == 0 }
+func (m Money) IsPositive() bool  { return m.Sen > 0 }
+func (m Money) IsNegative() bool  { return m.Sen < 0 }
+func (m Money) Less(o Money) bool { return m.Sen < o.Sen }
+func (m Money) Ringgit() float64  { return float64(m.Sen) / 100 }
+
+func (m Money) with(sen int64) Money {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Sen: sen, Currency: currency}
+}
+
+// Percent takes pct% of m, rounded half away from zero to the sen. pct is read as the
+// decimal it prints as (6.5, not the float nearest it), so the rounding is exact.
+func (m Money) Percent(pct float64) Money {
+	rate, ok := new(big.Rat).SetString(strconv.FormatFloat(pct, 'f', -1, 64))
+	if !ok {
+		return m.with(0) // NaN or ±Inf
+	}
+	n := new(big.Int).Mul(big.NewInt(m.Sen), rate.Num())
+	d := new(big.Int).Mul(rate.Denom(), big.NewInt(100))
+	return m.with(roundedSen(n, d))
+}
+
+// Share is m * num / den rounded half away from zero to the sen, for splitting an
+// amount pro rata. The product can pass int64, so it is taken in big.Int.
+func (m Money) Share(num, den Money) Money {
+	if den.Sen == 0 {
+		return m.with(0)
+	}
+	n := new(big.Int).Mul(big.NewInt(m.Sen), big.NewInt(num.Sen))
+	return m.with(roundedSen(n, big.NewInt(den.Sen)))
+}
+
+// divRound is n / d rounded half away from zero
+func divRound(n, d *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Abs(r).Lsh(r, 1).Cmp(new(big.Int).Abs(d)) >= 0 {
+		q.Add(q, big.NewInt(int64(n.Sign()*d.Sign())))
+	}
+	return q
+}
+
+// roundedSen is divRound for Percent and Share, pinned to the int64 range (shares of
+// an amount are never larger than the amount, so only a caller bug gets pinned)
+func roundedSen(n, d *big.Int) int64 {
+	q := divRound(n, d)
+	switch {
+	case q.IsInt64():
+		return q.Int64()
+	case q.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
+
+func MinMoney(a, b Money) Money {
+	if a.Sen < b.Sen {
+		return a
+	}
+	return b
+}
+
+func MaxMoney(a, b Money) Money {
+	if a.Sen > b.Sen {
+		return a
+	}
+	return b
+}
+
+// String is for receipts and logs: "RM12.50", "-RM3.00"
+func (m Money) String() string {
+	sign := ""
+	sen := m.Sen
+	if sen < 0 {
+		sign, sen = "-", -sen
+	}
+	prefix := "RM"
+	if m.Currency != "" && m.Currency != DefaultCurrency {
+		prefix = m.Currency + " "
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, prefix, sen/100, sen%100)
+}
+
+// --- JSON: a number of ringgit, as before ---
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	sign := ""
+	sen := m.Sen
+	if sen < 0 {
+		sign, sen = "-", -sen
+	}
+	return []byte(fmt.Sprintf("%s%d.%02d", sign, sen/100, sen%100)), nil
+}
+
+// UnmarshalJSON accepts a number (or numeric string) of ringgit. The decimal is read
+// exactly, not through a float, so half a sen always rounds away from zero (1.005 → 1.01).
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		*m = Sen(0)
+		return nil
+	}
+	ringgit, ok := new(big.Rat).SetString(raw)
+	if !ok || strings.Contains(raw, "/") {
+		return fmt.Errorf("invalid amount %q", raw)
+	}
+	sen := ringgit.Mul(ringgit, big.NewRat(100, 1))
+	whole := divRound(sen.Num(), sen.Denom())
+	if !whole.IsInt64() {
+		return fmt.Errorf("invalid amount %q", raw)
+	}
+	*m = Sen(whole.Int64())
+	return nil
+}
+
+// --- DATABASE: BIGINT sen ---
+
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.Sen, nil
+}
+
+// Scan also reads NUMERIC results (SUM over bigint comes back as numeric)
+func (m *Money) Scan(value interface{}) error {
+	var sen int64
+	switch v := value.(type) {
+	case nil:
+	case int64:
+		sen = v
+	case float64:
+		sen = int64(math.Round(v))
+	case []byte:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q", v)
+		}
+		sen = int64(math.Round(f))
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q", v)
+		}
+		sen = int64(math.Round(f))
+	default:
+		return fmt.Errorf("money: cannot scan %T", value)
+	}
+	*m = Sen(sen)
+	return nil
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Sen(0), "0.00"},
+		{Sen(7), "0.07"},
+		{Sen(1250), "12.50"},
+		{Sen(100000), "1000.00"},
+		{Sen(-300), "-3.00"},
+		{Sen(-5), "-0.05"},
+		{Money{Sen: 42}, "0.42"}, // no currency set
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := json.Marshal(tt.money)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal(%d sen) = %s, want %s", tt.money.Sen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int64
+		wantErr bool
+	}{
+		{raw: `12.5`, want: 1250},
+		{raw: `"12.50"`, want: 1250},
+		{raw: `0`, want: 0},
+		{raw: `null`, want: 0},
+		{raw: `""`, want: 0},
+		{raw: `1e2`, want: 10000},
+		{raw: `19.99`, want: 1999},
+		{raw: `-3`, want: -300},
+		// Half a sen rounds away from zero, including values a float64 can't hold exactly
+		{raw: `1.005`, want: 101},
+		{raw: `10.075`, want: 1008},
+		{raw: `0.285`, want: 29},
+		{raw: `-1.005`, want: -101},
+		{raw: `1.0049`, want: 100},
+		{raw: `"abc"`, wantErr: true},
+		{raw: `"1/3"`, wantErr: true},
+		{raw: `1e30`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.raw), &m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m.Sen != tt.want || m.Currency != DefaultCurrency {
+				t.Errorf("Unmarshal(%s) = %d sen %s, want %d sen %s", tt.raw, m.Sen, m.Currency, tt.want, DefaultCurrency)
+			}
+		})
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	for _, sen := range []int64{0, 1, 99, 1999, 123456789, -1, -250} {
+		data, err := json.Marshal(Sen(sen))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Money
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		if back.Sen != sen {
+			t.Errorf("%d sen came back as %d via %s", sen, back.Sen, data)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int64
+		wantErr bool
+	}{
+		{name: "NULL", value: nil, want: 0},
+		{name: "bigint", value: int64(1250), want: 1250},
+		{name: "float", value: float64(1249.6), want: 1250},
+		{name: "numeric bytes from SUM", value: []byte("1250.0000"), want: 1250},
+		{name: "numeric string", value: "-300", want: -300},
+		{name: "garbage bytes", value: []byte("abc"), wantErr: true},
+		{name: "unsupported type", value: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := m.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && m.Sen != tt.want {
+				t.Errorf("Scan(%v) = %d sen, want %d", tt.value, m.Sen, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyValue(t *testing.T) {
+	v, err := Sen(1250).Value()
+	if err != nil || v != int64(1250) {
+		t.Fatalf("Value() = %v, %v; want int64 1250", v, err)
+	}
+}
+
+func TestRM(t *testing.T) {
+	tests := []struct {
+		ringgit float64
+		want    int64
+	}{
+		{12.5, 1250},
+		{19.99, 1999},
+		{0.1 + 0.2, 30},
+		{-3.333, -333},
+		{2.675, 268},
+	}
+	for _, tt := range tests {
+		if got := RM(tt.ringgit); got.Sen != tt.want {
+			t.Errorf("RM(%v) = %d sen, want %d", tt.ringgit, got.Sen, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want int64
+	}{
+		{"percent rounds half away from zero", Sen(2500).Percent(6.5), 163}, // 162.5
+		{"negative percent", Sen(-2500).Percent(6.5), -163},
+		{"percent read as its decimal", Sen(250).Percent(64.6), 162}, // 161.5, a float product lands just below
+		{"percent under a sen", Sen(5).Percent(0.1), 0},
+		{"share", Sen(1000).Share(Sen(3333), Sen(10000)), 333},
+		{"share of nothing", Sen(1000).Share(Sen(1), Sen(0)), 0},
+		{"share rounds half away from zero", Sen(5).Share(Sen(1), Sen(2)), 3},
+		{"negative share", Sen(-5).Share(Sen(1), Sen(2)), -3},
+		{"share of a negative total", Sen(5).Share(Sen(1), Sen(-2)), -3},
+		{"share past float precision", Sen(9007199254740993).Share(Sen(1), Sen(2)), 4503599627370497}, // 2^53 + 1 has no float64
+		{"share whose product passes int64", Sen(4611686018427387903).Share(Sen(3), Sen(4)), 3458764513820540927},
+		{"times", Sen(3333).Times(3), 9999},
+	}
+	for _, tt := range tests {
+		if tt.got.Sen != tt.want {
+			t.Errorf("%s: got %d sen, want %d", tt.name, tt.got.Sen, tt.want)
+		}
+	}
+	if s := Sen(-1205).String(); s != "-RM12.05" {
+		t.Errorf("String() = %q, want -RM12.05", s)
+	}
+}
@@ -5,19 +5,43 @@ import (
 )
 
 type Order struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	UserID      uint      `json:"user_id"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"` // pending, paid, cancelled
-	Tickets     []Ticket  `json:"tickets"`
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	UserID      uint            `json:"user_id"`
+	TotalAmount Money           `json:"total_amount" gorm:"column:total_amount_sen"`
+	Status      string          `json:"status"` // pending, paid, cancelled, expired, refund_due, refund_requested, refund_processing, refund_pending, partially_refunded, refunded
+	Tickets     []Ticket        `json:"tickets"`
+	Items       []OrderItem     `json:"items"`
+	Lines       []OrderLine     `json:"lines"`
+	Breakdown   *OrderBreakdown `json:"breakdown,omitempty" gorm:"-"`
+	Refunds     []Refund        `json:"refunds,omitempty"`
+
+	RefundedAmount Money `json:"refunded_amount" gorm:"column:refunded_amount_sen"` // sum of completed/pending Refunds
+	ResoldAmount   Money `json:"resold_amount" gorm:"column:resold_amount_sen"`     // paid share of tickets resold to other fans
 
 	// Payment Gateway Integration (Billplz)
-	BillplzID  string `json:"billplz_id"`
+	BillplzID  string `json:"billplz_id" gorm:"index"`
 	PaymentURL string `json:"payment_url"`
+	RefundRef  string `json:"refund_ref,omitempty"`
 
 	// Loyalty System
 	PointsApplied int `json:"points_applied"`
 	PointsEarned  int `json:"points_earned"`
 }
+
+// OrderItem is the stock an order holds against one tier. Tickets for it are only
+// issued when the order is paid.
+type OrderItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrderID   uint      `gorm:"index" json:"order_id"`
+	TierID    uint      `gorm:"index" json:"tier_id"`
+	EventID   uint      `json:"event_id"`
+	Category  string    `json:"category"`
+	UnitPrice Money     `json:"unit_price" gorm:"column:unit_price_sen"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `json:"status"` // See reservation.go
+	CreatedAt time.Time `json:"created_at"`
+
+	ResaleListingID *uint `json:"resale_listing_id,omitempty"` // a fan's ticket instead of tier stock, see resale.go
+}
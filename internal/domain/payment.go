@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Results a PaymentEvent can end up with once we've processed it
+const (
+	PaymentResultFinalized   = "finalized"
+	PaymentResultAlreadyPaid = "already_paid"
+	PaymentResultUnpaid      = "unpaid_ignored"
+	PaymentResultStale       = "stale_ignored"   // unpaid callback arriving after a paid one
+	PaymentResultRefundDue   = "late_refund_due" // paid after the order expired/was cancelled, or its event was
+	PaymentResultFailed      = "failed"
+)
+
+// PaymentEvent is the persisted log of every inbound gateway callback/redirect.
+// TransactionID is unique, so a replayed callback can never be processed twice.
+type PaymentEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TransactionID string    `gorm:"uniqueIndex" json:"transaction_id"`
+	BillID        string    `gorm:"index" json:"bill_id"`
+	OrderID       *uint     `json:"order_id"`
+	Source        string    `json:"source"` // callback or redirect
+	Paid          bool      `json:"paid"`
+	State         string    `json:"state"`
+	PaidAt        string    `json:"paid_at"`
+	Payload       string    `json:"-"`
+	Result        string    `json:"result"`
+	Error         string    `json:"error,omitempty"`
+	Deliveries    int       `json:"deliveries" gorm:"default:1"` // how many times the gateway sent it
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
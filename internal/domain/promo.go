@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Promo code kinds
+const (
+	PromoPercent = "percent" // Percent of the eligible subtotal
+	PromoFixed   = "fixed"   // Amount off the eligible subtotal
+)
+
+// Checkout error codes for promo codes (see CheckoutError)
+const (
+	CodePromoInvalid       = "PROMO_INVALID"
+	CodePromoNotActive     = "PROMO_NOT_ACTIVE"
+	CodePromoExpired       = "PROMO_EXPIRED"
+	CodePromoNotApplicable = "PROMO_NOT_APPLICABLE"
+	CodePromoUsedUp        = "PROMO_USED_UP"
+	CodePromoUserLimit     = "PROMO_USER_LIMIT"
+)
+
+// PromoCode is a discount a buyer can enter at checkout. It can be narrowed to one
+// event and/or specific tiers; with neither it applies to the whole order.
+type PromoCode struct {
+	gorm.Model
+	Code           string       `json:"code" gorm:"uniqueIndex"` // stored upper-case
+	Description    string       `json:"description"`
+	Kind           string       `json:"kind"`
+	Percent        float64      `json:"percent,omitempty"`               // percent codes only
+	Amount         Money        `json:"amount" gorm:"column:amount_sen"` // fixed codes only
+	EventID        *uint        `json:"event_id"`
+	Tiers          []TicketTier `json:"tiers,omitempty" gorm:"many2many:promo_code_tiers"`
+	MaxUses        int          `json:"max_uses"`          // 0 = unlimited
+	MaxUsesPerUser int          `json:"max_uses_per_user"` // 0 = unlimited
+	StartsAt       *time.Time   `json:"starts_at"`
+	ExpiresAt      *time.Time   `json:"expires_at"`
+	Active         bool         `json:"active"`
+	CreatedBy      uint         `json:"created_by"`
+}
+
+// Where a discount line came from (OrderLine.Source), so revenue reports can tell
+// promo money and points apart
+const (
+	DiscountPromo  = "promo"
+	DiscountPoints = "points"
+)
+
+// PromoCodeRequest creates or replaces a promo code (admin)
+type PromoCodeRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	Description    string     `json:"description"`
+	Kind           string     `json:"kind" binding:"required"`
+	Percent        float64    `json:"percent"` // for percent codes
+	Amount         Money      `json:"amount"`  // for fixed codes
+	EventID        *uint      `json:"event_id"`
+	TierIDs        []uint     `json:"tier_ids"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	StartsAt       *time.Time `json:"starts_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Active         *bool      `json:"active"` // defaults to true
+}
+
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ApplyTo validates the request and copies it onto p (tiers are resolved by the caller)
+func (r PromoCodeRequest) ApplyTo(p *PromoCode) error {
+	code := NormalizePromoCode(r.Code)
+	if code == "" || strings.ContainsAny(code, " \t") {
+		return fmt.Errorf("code cannot be blank or contain spaces")
+	}
+	switch r.Kind {
+	case PromoPercent:
+		if r.Percent <= 0 || r.Percent > 100 || !r.Amount.IsZero() {
+			return fmt.Errorf("a percent code needs a percent between 0 and 100 and no amount")
+		}
+	case PromoFixed:
+		if !r.Amount.IsPositive() || r.Percent != 0 {
+			return fmt.Errorf("a fixed code needs an amount above zero and no percent")
+		}
+	default:
+		return fmt.Errorf("kind must be percent or fixed")
+	}
+	if r.MaxUses < 0 || r.MaxUsesPerUser < 0 {
+		return fmt.Errorf("usage caps cannot be negative")
+	}
+	if r.StartsAt != nil && r.ExpiresAt != nil && !r.ExpiresAt.After(*r.StartsAt) {
+		return fmt.Errorf("expires_at must be after starts_at")
+	}
+
+	p.Code = code
+	p.Description = r.Description
+	p.Kind = r.Kind
+	p.Percent = r.Percent
+	p.Amount = r.Amount
+	p.EventID = r.EventID
+	p.MaxUses = r.MaxUses
+	p.MaxUsesPerUser = r.MaxUsesPerUser
+	p.StartsAt = r.StartsAt
+	p.ExpiresAt = r.ExpiresAt
+	p.Active = r.Active == nil || *r.Active
+	return nil
+}
+
+// CheckUsable covers everything about a code that doesn't depend on the order
+func (p *PromoCode) CheckUsable(now time.Time) error {
+	if !p.Active || (p.StartsAt != nil && now.Before(*p.StartsAt)) {
+		return &CheckoutError{CodePromoNotActive, fmt.Sprintf("promo code %s is not active", p.Code)}
+	}
+	if p.ExpiresAt != nil && !now.Before(*p.ExpiresAt) {
+		return &CheckoutError{CodePromoExpired, fmt.Sprintf("promo code %s expired on %s", p.Code, p.ExpiresAt.Format("2 Jan 2006"))}
+	}
+	return nil
+}
+
+func (p *PromoCode) Describe() string {
+	if p.Kind == PromoPercent {
+		return fmt.Sprintf("%s: %g%% off", p.Code, p.Percent)
+	}
+	return fmt.Sprintf("%s: %s off", p.Code, p.Amount)
+}
+
+// Covers reports whether an order item is in the code's scope
+func (p *PromoCode) Covers(item OrderItem) bool {
+	if p.EventID != nil && *p.EventID != item.EventID {
+		return false
+	}
+	if len(p.Tiers) == 0 {
+		return true
+	}
+	for _, tier := range p.Tiers {
+		if tier.ID == item.TierID {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor works out how much the code takes off these items, rounded to the sen
+func (p *PromoCode) DiscountFor(items []OrderItem) (Money, error) {
+	eligible := Sen(0)
+	for _, item := range items {
+		if p.Covers(item) {
+			eligible = eligible.Add(item.UnitPrice.Times(item.Quantity))
+		}
+	}
+	if !eligible.IsPositive() {
+		return Sen(0), &CheckoutError{CodePromoNotApplicable, fmt.Sprintf("promo code %s does not apply to these tickets", p.Code)}
+	}
+
+	discount := p.Amount
+	if p.Kind == PromoPercent {
+		discount = eligible.Percent(p.Percent)
+	}
+	return MinMoney(discount, eligible), nil
+}
+
+// PromoCodeStats attributes paid revenue to a code
+type PromoCodeStats struct {
+	Redemptions   int64 `json:"redemptions"` // orders that used the code, counted like the usage caps
+	DiscountGiven Money `json:"discount_given"`
+	Revenue       Money `json:"revenue"` // what those orders were charged
+}
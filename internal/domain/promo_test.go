@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromoCodeDiscountFor(t *testing.T) {
+	event := uint(1)
+	items := []OrderItem{
+		{EventID: 1, TierID: 10, UnitPrice: Sen(3333), Quantity: 3}, // 99.99
+		{EventID: 2, TierID: 20, UnitPrice: Sen(5000), Quantity: 1},
+	}
+
+	tests := []struct {
+		name     string
+		promo    PromoCode
+		want     Money
+		wantCode string
+	}{
+		{name: "fixed, whole order", promo: PromoCode{Kind: PromoFixed, Amount: Sen(1005)}, want: Sen(1005)},
+		{name: "fixed, capped at the eligible subtotal", promo: PromoCode{Kind: PromoFixed, Amount: Sen(20000), EventID: &event}, want: Sen(9999)},
+		{name: "percent, rounded half up to the sen", promo: PromoCode{Kind: PromoPercent, Percent: 12.5, EventID: &event}, want: Sen(1250)}, // 1249.875
+		{name: "percent of one tier", promo: PromoCode{Kind: PromoPercent, Percent: 10, Tiers: []TicketTier{{ID: 20}}}, want: Sen(500)},
+		{name: "out of scope", promo: PromoCode{Kind: PromoFixed, Amount: Sen(500), Tiers: []TicketTier{{ID: 30}}}, wantCode: CodePromoNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promo.DiscountFor(items)
+			if tt.wantCode != "" {
+				var checkoutErr *CheckoutError
+				if !errors.As(err, &checkoutErr) || checkoutErr.Code != tt.wantCode {
+					t.Fatalf("DiscountFor() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiscountFor() error = %v", err)
+			}
+			if got.Sen != tt.want.Sen {
+				t.Errorf("DiscountFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCodeRequestApplyTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PromoCodeRequest
+		wantErr bool
+	}{
+		{name: "percent", req: PromoCodeRequest{Code: "save10", Kind: PromoPercent, Percent: 10}},
+		{name: "fixed", req: PromoCodeRequest{Code: "rm5", Kind: PromoFixed, Amount: Sen(500)}},
+		{name: "percent over 100", req: PromoCodeRequest{Code: "x", Kind: PromoPercent, Percent: 101}, wantErr: true},
+		{name: "percent with an amount", req: PromoCodeRequest{Code: "x", Kind: PromoPercent, Percent: 10, Amount: Sen(500)}, wantErr: true},
+		{name: "fixed without an amount", req: PromoCodeRequest{Code: "x", Kind: PromoFixed, Percent: 10}, wantErr: true},
+		{name: "fixed below zero", req: PromoCodeRequest{Code: "x", Kind: PromoFixed, Amount: Sen(-500)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var promo PromoCode
+			err := tt.req.ApplyTo(&promo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (promo.Percent != tt.req.Percent || promo.Amount != tt.req.Amount) {
+				t.Errorf("ApplyTo() stored percent %g, amount %s", promo.Percent, promo.Amount)
+			}
+		})
+	}
+}
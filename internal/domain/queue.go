@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultQueueAdmitRate: users let into checkout per second when an event sets no rate
+const DefaultQueueAdmitRate = 50
+
+// QueueAdmissionWindow is how long an admitted queue ticket can be used to check out
+const QueueAdmissionWindow = 10 * time.Minute
+
+// CodeQueueRequired: the event sells through its waiting room and the buyer has no
+// admitted queue ticket for it (see Event.QueueEnabled)
+const CodeQueueRequired = "QUEUE_REQUIRED"
+
+// ApplyQueueSettings switches the virtual waiting room on or off and sets how many
+// users it admits per second; nil leaves a setting as it is
+func (e *Event) ApplyQueueSettings(enabled *bool, admitRate *int) error {
+	if enabled != nil {
+		e.QueueEnabled = *enabled
+	}
+	if admitRate != nil {
+		if *admitRate < 1 {
+			return fmt.Errorf("queue_admit_rate must be at least 1")
+		}
+		e.QueueAdmitRate = *admitRate
+	}
+	if e.QueueAdmitRate == 0 {
+		e.QueueAdmitRate = DefaultQueueAdmitRate
+	}
+	return nil
+}
+
+// AdmitRate is how many queued users the event lets into checkout each second
+func (e *Event) AdmitRate() int {
+	if e.QueueAdmitRate < 1 {
+		return DefaultQueueAdmitRate
+	}
+	return e.QueueAdmitRate
+}
+
+// Queue ticket states
+//
+//	waiting  → admitted  (its turn came: it may check out until ExpiresAt)
+//	admitted → used      (an order was placed with it; one admission buys one order)
+//	used     → admitted  (that checkout failed after all, e.g. the bill was refused)
+//	admitted → expired   (the window passed; join again for a new place)
+const (
+	QueueWaiting  = "waiting"
+	QueueAdmitted = "admitted"
+	QueueUsed     = "used"
+	QueueExpired  = "expired"
+)
+
+// QueueTicket is one user's place in an event's virtual waiting room. It lives in a
+// QueueStore, not the database: the rush it absorbs is exactly the load the database
+// shouldn't see.
+type QueueTicket struct {
+	Token      string     `json:"token"`
+	EventID    uint       `json:"event_id"`
+	UserID     uint       `json:"user_id"`
+	Number     int64      `json:"number"`             // place in line, from 1
+	Position   int64      `json:"position,omitempty"` // people ahead + 1 while waiting
+	Status     string     `json:"status"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	AdmittedAt *time.Time `json:"admitted_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Admitted says whether the ticket may check out at `now`
+func (q *QueueTicket) Admitted(now time.Time) bool {
+	return q.Status == QueueAdmitted && q.InWindow(now)
+}
+
+// InWindow says whether the admission window of an admitted or used ticket is still open
+func (q *QueueTicket) InWindow(now time.Time) bool {
+	return q.ExpiresAt != nil && now.Before(*q.ExpiresAt)
+}
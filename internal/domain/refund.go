@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Event refund policies
+const (
+	RefundPolicyRefundable    = "refundable"     // buyers may ask until RefundCutoffHours before the start
+	RefundPolicyNonRefundable = "non_refundable" // only an event cancellation refunds
+)
+
+func ValidRefundPolicy(policy string) bool {
+	return policy == RefundPolicyRefundable || policy == RefundPolicyNonRefundable
+}
+
+// ApplyRefundPolicy sets the policy and/or cutoff; empty/nil leave them as they are
+func (e *Event) ApplyRefundPolicy(policy string, cutoffHours *int) error {
+	if policy != "" {
+		if !ValidRefundPolicy(policy) {
+			return fmt.Errorf("refund_policy must be refundable or non_refundable")
+		}
+		e.RefundPolicy = policy
+	}
+	if e.RefundPolicy == "" {
+		e.RefundPolicy = RefundPolicyRefundable
+	}
+	if cutoffHours != nil {
+		if *cutoffHours < 0 {
+			return fmt.Errorf("refund_cutoff_hours cannot be negative")
+		}
+		e.RefundCutoffHours = *cutoffHours
+	}
+	return nil
+}
+
+// CheckRefundable says whether a buyer may still ask for their money back
+func (e *Event) CheckRefundable(now time.Time) error {
+	if e.RefundPolicy == RefundPolicyNonRefundable {
+		return fmt.Errorf("tickets for %s are non-refundable", e.Name)
+	}
+	if e.StartsAt == nil {
+		return nil
+	}
+	cutoff := e.StartsAt.Add(-time.Duration(e.RefundCutoffHours) * time.Hour)
+	if !now.Before(cutoff) {
+		if e.RefundCutoffHours == 0 {
+			return fmt.Errorf("%s has already started", e.Name)
+		}
+		return fmt.Errorf("refunds for %s closed %dh before the show", e.Name, e.RefundCutoffHours)
+	}
+	return nil
+}
+
+// Refund request states
+const (
+	RefundRequested = "requested"
+	RefundApproved  = "approved" // see its Refund
+	RefundRejected  = "rejected"
+)
+
+// RefundRequest is a buyer asking for some or all tickets of a paid order to be
+// refunded. While it is open the order sits in refund_requested; an admin approves
+// (which creates a Refund) or rejects it.
+type RefundRequest struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	OrderID    uint       `gorm:"index" json:"order_id"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	TicketIDs  []string   `json:"ticket_ids" gorm:"serializer:json"`
+	Reason     string     `json:"reason"`
+	Status     string     `gorm:"index" json:"status"`
+	ReviewedBy *uint      `json:"reviewed_by,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Amount     Money      `json:"amount" gorm:"column:amount_sen"`
+	RefundID   *uint      `json:"refund_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Refund states
+const (
+	RefundProcessing = "processing" // saved, money not confirmed yet (resumable)
+	RefundCompleted  = "completed"
+	RefundPending    = "pending" // accepted, settled outside the gateway API (e.g. by finance)
+)
+
+// Refund is money given back for specific tickets of an order. Its tickets stop
+// being valid; points earned (and redeemed) on the order are adjusted pro rata.
+type Refund struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	OrderID         uint           `gorm:"index" json:"order_id"`
+	RefundRequestID *uint          `json:"refund_request_id,omitempty"`
+	Amount          Money          `json:"amount" gorm:"column:amount_sen"`
+	PointsReversed  int            `json:"points_reversed"` // earned points taken back
+	PointsRestored  int            `json:"points_restored"` // redeemed points given back
+	Reason          string         `json:"reason"`
+	Restock         bool           `json:"restock"` // tickets went back on sale
+	Status          string         `gorm:"index" json:"status"`
+	Reference       string         `json:"reference,omitempty"`
+	CreatedBy       uint           `json:"created_by"`
+	Tickets         []RefundTicket `json:"tickets"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// RefundTicket is one ticket of a Refund; a ticket can only ever be refunded once
+type RefundTicket struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	RefundID uint   `gorm:"index" json:"refund_id"`
+	TicketID string `gorm:"type:uuid;uniqueIndex" json:"ticket_id"`
+	Amount   Money  `json:"amount" gorm:"column:amount_sen"`
+}
+
+// ItemPaidAmount is an order item's share of what the order actually paid, weighed
+// by its ticket, fee and tax lines like TicketPaidAmounts. Needs Lines loaded.
+func ItemPaidAmount(order *Order, itemID uint) Money {
+	item, gross := Sen(0), Sen(0)
+	for _, line := range order.Lines {
+		if line.OrderItemID == nil || line.Kind == LineDiscount {
+			continue
+		}
+		gross = gross.Add(line.Amount)
+		if *line.OrderItemID == itemID {
+			item = item.Add(line.Amount)
+		}
+	}
+	return order.TotalAmount.Share(item, gross)
+}
+
+// TicketPaidAmounts splits what the order actually paid (TotalAmount, after promo
+// discounts and points) over its tickets. A ticket weighs its item's ticket, fee and
+// tax lines divided by the item's quantity, so a VIP ticket gets back more than a GA
+// one from the same order. Needs Items, Lines and Tickets loaded. Tickets resold to
+// another fan have left order.Tickets but keep their share, so the rest don't grow;
+// with all tickets present the amounts add up to TotalAmount exactly.
+func TicketPaidAmounts(order *Order) map[string]Money {
+	type tierKey struct {
+		eventID  uint
+		category string
+	}
+	type group struct {
+		gross    Money
+		quantity int
+	}
+
+	// 1. Gross per tier: the non-discount lines of its items
+	groups := map[tierKey]*group{}
+	itemKeys := map[uint]tierKey{}
+	issued := 0
+	for _, item := range order.Items {
+		key := tierKey{item.EventID, item.Category}
+		if groups[key] == nil {
+			groups[key] = &group{gross: Sen(0)}
+		}
+		if item.Status == HoldSold {
+			groups[key].quantity += item.Quantity
+			issued += item.Quantity
+		}
+		itemKeys[item.ID] = key
+	}
+	for _, line := range order.Lines {
+		if line.OrderItemID == nil || line.Kind == LineDiscount {
+			continue
+		}
+		if key, ok := itemKeys[*line.OrderItemID]; ok {
+			groups[key].gross = groups[key].gross.Add(line.Amount)
+		}
+	}
+
+	tickets := append([]Ticket(nil), order.Tickets...)
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].ID < tickets[j].ID })
+
+	// 2. One weight per ticket (equal weights for orders from before itemised lines)
+	weight := func(t Ticket) Money {
+		if g := groups[tierKey{t.EventID, t.Category}]; g != nil && g.quantity > 0 {
+			return Sen(g.gross.Sen / int64(g.quantity))
+		}
+		return Sen(0)
+	}
+	total := Sen(0)
+	for _, g := range groups {
+		if g.quantity > 0 {
+			total = total.Add(Sen(g.gross.Sen / int64(g.quantity)).Times(g.quantity))
+		}
+	}
+	if !total.IsPositive() || issued < len(tickets) {
+		weight = func(Ticket) Money { return Sen(1) }
+		issued = len(tickets)
+		total = Sen(int64(issued))
+	}
+
+	// 3. TotalAmount pro rata; the last ticket takes the rounding remainder
+	amounts := make(map[string]Money, len(tickets))
+	left := order.TotalAmount
+	for i, t := range tickets {
+		share := order.TotalAmount.Share(weight(t), total)
+		if i == len(tickets)-1 && len(tickets) == issued {
+			share = left
+		}
+		amounts[t.ID] = share
+		left = left.Sub(share)
+	}
+	return amounts
+}
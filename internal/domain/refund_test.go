@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+)
+
+func itemLine(kind string, itemID uint, sen int64) OrderLine {
+	return OrderLine{Kind: kind, OrderItemID: &itemID, Amount: Sen(sen)}
+}
+
+func TestTicketPaidAmounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  map[string]int64
+	}{
+		{
+			name: "one tier: discount spread evenly over ticket, fee and tax",
+			order: Order{
+				TotalAmount: Sen(9000),
+				Items:       []OrderItem{{ID: 1, EventID: 1, Category: "GA", Quantity: 3, Status: HoldSold}},
+				Lines: []OrderLine{
+					itemLine(LineTicket, 1, 9000), itemLine(LineFee, 1, 300), itemLine(LineTax, 1, 558),
+					{Kind: LineDiscount, Amount: Sen(-858)},
+				},
+				Tickets: []Ticket{{ID: "a", EventID: 1, Category: "GA"}, {ID: "b", EventID: 1, Category: "GA"}, {ID: "c", EventID: 1, Category: "GA"}},
+			},
+			want: map[string]int64{"a": 3000, "b": 3000, "c": 3000},
+		},
+		{
+			name: "a VIP ticket gets back more than a GA one",
+			order: Order{
+				TotalAmount: Sen(27000),
+				Items: []OrderItem{
+					{ID: 1, EventID: 1, Category: "VIP", Quantity: 1, Status: HoldSold},
+					{ID: 2, EventID: 1, Category: "GA", Quantity: 2, Status: HoldSold},
+				},
+				Lines:   []OrderLine{itemLine(LineTicket, 1, 20000), itemLine(LineTicket, 2, 10000), {Kind: LineDiscount, Amount: Sen(-3000)}},
+				Tickets: []Ticket{{ID: "c", EventID: 1, Category: "GA"}, {ID: "a", EventID: 1, Category: "VIP"}, {ID: "b", EventID: 1, Category: "GA"}},
+			},
+			want: map[string]int64{"a": 18000, "b": 4500, "c": 4500},
+		},
+		{
+			name: "the last ticket by ID takes the rounding remainder",
+			order: Order{
+				TotalAmount: Sen(10000),
+				Items:       []OrderItem{{ID: 1, EventID: 1, Category: "GA", Quantity: 3, Status: HoldSold}},
+				Lines:       []OrderLine{itemLine(LineTicket, 1, 10000)},
+				Tickets:     []Ticket{{ID: "b", EventID: 1, Category: "GA"}, {ID: "c", EventID: 1, Category: "GA"}, {ID: "a", EventID: 1, Category: "GA"}},
+			},
+			want: map[string]int64{"a": 3333, "b": 3333, "c": 3334},
+		},
+		{
+			name: "resold tickets keep their share, so the rest don't grow",
+			order: Order{
+				TotalAmount: Sen(10000),
+				Items:       []OrderItem{{ID: 1, EventID: 1, Category: "GA", Quantity: 3, Status: HoldSold}},
+				Lines:       []OrderLine{itemLine(LineTicket, 1, 10000)},
+				Tickets:     []Ticket{{ID: "a", EventID: 1, Category: "GA"}, {ID: "b", EventID: 1, Category: "GA"}},
+			},
+			want: map[string]int64{"a": 3333, "b": 3333},
+		},
+		{
+			name: "orders from before itemised lines split evenly",
+			order: Order{
+				TotalAmount: Sen(1000),
+				Tickets:     []Ticket{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+			},
+			want: map[string]int64{"a": 333, "b": 333, "c": 334},
+		},
+		{
+			name: "released items don't count as issued",
+			order: Order{
+				TotalAmount: Sen(5000),
+				Items: []OrderItem{
+					{ID: 1, EventID: 1, Category: "GA", Quantity: 1, Status: HoldSold},
+					{ID: 2, EventID: 1, Category: "VIP", Quantity: 1, Status: HoldReleased},
+				},
+				Lines:   []OrderLine{itemLine(LineTicket, 1, 5000), itemLine(LineTicket, 2, 20000)},
+				Tickets: []Ticket{{ID: "a", EventID: 1, Category: "GA"}},
+			},
+			want: map[string]int64{"a": 5000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TicketPaidAmounts(&tt.order)
+			if len(got) != len(tt.want) {
+				t.Fatalf("TicketPaidAmounts() = %v, want %v", got, tt.want)
+			}
+			for id, want := range tt.want {
+				if got[id].Sen != want {
+					t.Errorf("ticket %s: %s, want %s", id, got[id], Sen(want))
+				}
+			}
+		})
+	}
+}
+
+func TestItemPaidAmount(t *testing.T) {
+	order := &Order{
+		TotalAmount: Sen(27000),
+		Lines: []OrderLine{
+			itemLine(LineTicket, 1, 20000), itemLine(LineFee, 1, 1000),
+			itemLine(LineTicket, 2, 10000), itemLine(LineFee, 2, 500),
+			{Kind: LineDiscount, Amount: Sen(-4500)},
+		},
+	}
+	if got := ItemPaidAmount(order, 1); got.Sen != 18000 {
+		t.Errorf("item 1 = %s, want RM180.00", got)
+	}
+	if got := ItemPaidAmount(order, 2); got.Sen != 9000 {
+		t.Errorf("item 2 = %s, want RM90.00", got)
+	}
+}
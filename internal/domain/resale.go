@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultResaleCapPercent: without a cap of its own, an event's tickets resell at face value at most
+const DefaultResaleCapPercent = 100
+
+// ApplyResaleSettings switches fan resale on or off and sets its price cap (percent
+// of face value); nil leaves a setting as it is
+func (e *Event) ApplyResaleSettings(enabled *bool, capPercent *int) error {
+	if enabled != nil {
+		e.ResaleEnabled = *enabled
+	}
+	if capPercent != nil {
+		if *capPercent < 1 {
+			return fmt.Errorf("resale_cap_percent must be at least 1")
+		}
+		e.ResaleCapPercent = *capPercent
+	}
+	if e.ResaleCapPercent == 0 {
+		e.ResaleCapPercent = DefaultResaleCapPercent
+	}
+	return nil
+}
+
+// ResalePriceCap is the most a ticket with this face value may be listed for
+func (e *Event) ResalePriceCap(face Money) Money {
+	capPercent := e.ResaleCapPercent
+	if capPercent == 0 {
+		capPercent = DefaultResaleCapPercent
+	}
+	return face.Percent(float64(capPercent))
+}
+
+// CheckResaleOpen says whether tickets for the event can be listed or bought from fans
+func (e *Event) CheckResaleOpen(now time.Time) error {
+	if !e.ResaleEnabled {
+		return fmt.Errorf("resale is not available for %s", e.Name)
+	}
+	if e.Status != EventOnSale && e.Status != EventSoldOut {
+		return fmt.Errorf("resale for %s is closed (%s)", e.Name, e.Status)
+	}
+	if e.StartsAt != nil && !now.Before(*e.StartsAt) {
+		return fmt.Errorf("%s has already started", e.Name)
+	}
+	return nil
+}
+
+// Resale listing states
+//
+//	active    → reserved   (a buyer's checkout holds it, see OrderItem.ResaleListingID)
+//	reserved  → active     (that order expired or was cancelled)
+//	reserved  → sold       (paid: the ticket moves to the buyer, the seller gets a payout)
+//	active    → cancelled  (by the seller, resale switched off, or the ticket refunded)
+const (
+	ListingActive    = "active"
+	ListingReserved  = "reserved"
+	ListingSold      = "sold"
+	ListingCancelled = "cancelled"
+)
+
+// ResaleListing is a holder offering a paid, unused ticket to other fans. While it is
+// listed the ticket's QR code doesn't work (its credential version moves on), so it
+// can't be sold and used at the same time.
+type ResaleListing struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TicketID  string     `gorm:"type:uuid;index" json:"ticket_id"`
+	Ticket    *Ticket    `json:"ticket,omitempty" gorm:"foreignKey:TicketID"`
+	EventID   uint       `gorm:"index" json:"event_id"`
+	Event     *Event     `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Category  string     `json:"category"`
+	SeatLabel string     `json:"seat_label,omitempty"`
+	SellerID  uint       `gorm:"index" json:"seller_id"`
+	FaceValue Money      `json:"face_value" gorm:"column:face_value_sen"`
+	Price     Money      `json:"price" gorm:"column:price_sen"`
+	Status    string     `gorm:"index" json:"status"`
+	OrderID   *uint      `gorm:"index" json:"order_id,omitempty"` // the buyer's order, once reserved
+	BuyerID   *uint      `json:"buyer_id,omitempty"`
+	SoldAt    *time.Time `json:"sold_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Payout states
+const (
+	PayoutPending = "pending" // owed to the seller
+	PayoutPaid    = "paid"
+)
+
+// ResalePayout is what the platform owes a seller for a sold listing: its price. The
+// buyer's booking fees and tax stay with the platform.
+type ResalePayout struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	ListingID uint       `gorm:"uniqueIndex" json:"listing_id"`
+	SellerID  uint       `gorm:"index" json:"seller_id"`
+	OrderID   uint       `json:"order_id"` // the buyer's
+	Amount    Money      `json:"amount" gorm:"column:amount_sen"`
+	Status    string     `gorm:"index" json:"status"`
+	Reference string     `json:"reference,omitempty"`
+	PaidAt    *time.Time `json:"paid_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
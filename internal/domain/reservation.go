@@ -0,0 +1,25 @@
+package domain
+
+// Stock moves through holds on OrderItems, mirrored by the tier counters:
+//
+//	available → held      (checkout: tier.held += n, item is "held")
+//	held      → sold      (payment: tier.held -= n, tier.sold += n, tickets issued)
+//	held      → released  (order expired/cancelled: tier.held -= n)
+//
+// A cart holds its items the same way (tier.held += n, seats "held" with a
+// cart_item_id) until CartHoldTime runs out; checking it out hands those holds to
+// the order as they are (see TakeCartHolds), and anything else gives them back.
+//
+// Every move is a conditional UPDATE on the item's status, so it can only happen once.
+const (
+	HoldHeld     = "held"
+	HoldSold     = "sold"
+	HoldReleased = "released"
+)
+
+// Ticket row states. Rows only exist for paid orders.
+const (
+	TicketSold     = "sold"
+	TicketVoided   = "voided"   // event cancelled; never valid again
+	TicketRefunded = "refunded" // money returned (see Refund); never valid again
+)
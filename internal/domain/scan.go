@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Entry policies an event can pick for how repeat scans of one ticket behave
+const (
+	EntryPolicySingle  = "single"  // first scan admits, every later scan is ALREADY USED
+	EntryPolicyReentry = "reentry" // every valid scan admits (pass-outs without exit scans)
+	EntryPolicyInOut   = "in_out"  // scans alternate between entering and exiting
+)
+
+func ValidEntryPolicy(policy string) bool {
+	switch policy {
+	case EntryPolicySingle, EntryPolicyReentry, EntryPolicyInOut:
+		return true
+	}
+	return false
+}
+
+// Results a ScanEvent can record
+const (
+	ScanResultAdmitted    = "admitted"
+	ScanResultReentered   = "reentered"
+	ScanResultExited      = "exited"
+	ScanResultAlreadyUsed = "already_used"
+	ScanResultWrongEvent  = "wrong_event"
+	ScanResultUnpaid      = "unpaid"
+	ScanResultInvalid     = "invalid"
+)
+
+// ScanEvent is one row per scan attempt, accepted or not. TicketID is empty when the
+// QR code couldn't be read as one of our tickets at all, so it has no foreign key:
+// rejected scans must be logged whatever they carried.
+type ScanEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TicketID   string    `gorm:"index" json:"ticket_id"`
+	EventID    uint      `gorm:"index" json:"event_id"`
+	Result     string    `json:"result"`
+	Reason     string    `json:"reason,omitempty"`
+	Gate       string    `json:"gate"`
+	DeviceID   string    `json:"device_id"`
+	OperatorID uint      `json:"operator_id"`
+	Offline    bool      `json:"offline"` // uploaded through scanner sync
+	ScannedAt  time.Time `json:"scanned_at"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	Ticket *Ticket `json:"ticket,omitempty" gorm:"foreignKey:TicketID;constraint:-"`
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Venues default to Malaysian time unless the organiser says otherwise
+const DefaultTimeZone = "Asia/Kuala_Lumpur"
+
+// ScheduleInput is the scheduling part of create/update event requests. The
+// structured fields take either RFC3339 ("2026-12-31T20:00:00+08:00") or a local
+// wall-clock time ("2026-12-31T20:00") read in the event's time zone. Date/DoorsOpen
+// are the old free-form strings the app still sends; they're used only when the
+// structured fields are missing.
+type ScheduleInput struct {
+	TimeZone    string `json:"time_zone"`
+	StartsAt    string `json:"starts_at"`
+	EndsAt      string `json:"ends_at"`
+	DoorsOpenAt string `json:"doors_open_at"`
+
+	Date      string `json:"date"`       // legacy, e.g. "2026-12-31"
+	DoorsOpen string `json:"doors_open"` // legacy, e.g. "19:30"
+}
+
+func (in ScheduleInput) Empty() bool {
+	return in == ScheduleInput{}
+}
+
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+var legacyDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "2 Jan 2006", "02 Jan 2006", "2 January 2006", "January 2, 2006", "Jan 2, 2006"}
+var legacyClockLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3 PM", "3PM"}
+
+// ParseEventTime reads an absolute (RFC3339) or local (in loc) timestamp
+func ParseEventTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a valid time, use RFC3339 or YYYY-MM-DDTHH:MM", value)
+}
+
+func parseLegacyDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	for _, layout := range legacyDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not read date '%s'", value)
+}
+
+func parseLegacyClock(value string) (hour, minute int, err error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range legacyClockLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(value)); err == nil {
+			return t.Hour(), t.Minute(), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("could not read doors open time '%s'", value)
+}
+
+// Location is the event's IANA zone, falling back to the default for bad/empty values
+func (e *Event) Location() *time.Location {
+	if loc, err := time.LoadLocation(e.TimeZone); err == nil && e.TimeZone != "" {
+		return loc
+	}
+	loc, err := time.LoadLocation(DefaultTimeZone)
+	if err != nil {
+		return time.FixedZone("MYT", 8*60*60)
+	}
+	return loc
+}
+
+// ApplySchedule merges whatever scheduling fields are set in `in` into the event and
+// validates the result. Fields left empty keep their current values.
+func (e *Event) ApplySchedule(in ScheduleInput) error {
+	// 1. Time zone first: local inputs below are read in it
+	if in.TimeZone != "" {
+		if _, err := time.LoadLocation(in.TimeZone); err != nil {
+			return fmt.Errorf("unknown time zone '%s'", in.TimeZone)
+		}
+		e.TimeZone = in.TimeZone
+	}
+	if e.TimeZone == "" {
+		e.TimeZone = DefaultTimeZone
+	}
+	loc := e.Location()
+
+	// 2. Structured fields
+	for _, f := range []struct {
+		value  string
+		target **time.Time
+	}{
+		{in.StartsAt, &e.StartsAt},
+		{in.EndsAt, &e.EndsAt},
+		{in.DoorsOpenAt, &e.DoorsOpenAt},
+	} {
+		if f.value == "" {
+			continue
+		}
+		t, err := ParseEventTime(f.value, loc)
+		if err != nil {
+			return err
+		}
+		*f.target = &t
+	}
+
+	// 3. Legacy strings, only for whatever the structured fields didn't cover. Older
+	// app builds send back the strings they were shown (see 5): those change nothing.
+	date, doorsOpen := in.Date, in.DoorsOpen
+	if e.StartsAt != nil && date == e.Date {
+		date = ""
+	}
+	if e.StartsAt != nil && doorsOpen == e.DoorsOpen {
+		doorsOpen = ""
+	}
+	if in.StartsAt == "" && (date != "" || doorsOpen != "") {
+		if err := e.applyLegacySchedule(date, doorsOpen, in.EndsAt == "", in.DoorsOpenAt == "", loc); err != nil {
+			return err
+		}
+	}
+
+	// 4. Validate
+	if e.StartsAt == nil {
+		return fmt.Errorf("starts_at is required")
+	}
+	if e.EndsAt != nil && !e.EndsAt.After(*e.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if e.DoorsOpenAt != nil && e.DoorsOpenAt.After(*e.StartsAt) {
+		return fmt.Errorf("doors_open_at cannot be after starts_at")
+	}
+
+	// 5. Keep the legacy strings in step for older app builds
+	e.Date = e.StartsAt.In(loc).Format("2006-01-02")
+	if e.DoorsOpenAt != nil {
+		e.DoorsOpen = e.DoorsOpenAt.In(loc).Format("15:04")
+	}
+	return nil
+}
+
+// applyLegacySchedule turns "date" + "doors_open" into timestamps. The old model had
+// no show time, so doors open doubles as the start, and the event is treated as
+// running until the end of that day so it isn't hidden from sale on the day itself
+// (an end it already has is kept while it is still after the start). A new date for
+// an event that is already scheduled moves it by whole days, times and all.
+func (e *Event) applyLegacySchedule(date, doorsOpen string, setEnd, setDoors bool, loc *time.Location) error {
+	var day time.Time
+	switch {
+	case date != "":
+		d, err := parseLegacyDate(date, loc)
+		if err != nil {
+			return err
+		}
+		day = d
+	case e.StartsAt != nil:
+		day = e.StartsAt.In(loc)
+	default:
+		return fmt.Errorf("date is required")
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	starts := day
+	if e.StartsAt != nil {
+		was := e.StartsAt.In(loc)
+		wasDay := time.Date(was.Year(), was.Month(), was.Day(), 0, 0, 0, 0, loc)
+		days := int(math.Round(day.Sub(wasDay).Hours() / 24)) // a DST change makes a day 23 or 25 hours
+		starts = was.AddDate(0, 0, days)
+		if setEnd && e.EndsAt != nil {
+			ends := e.EndsAt.In(loc).AddDate(0, 0, days)
+			e.EndsAt = &ends
+		}
+		if setDoors && e.DoorsOpenAt != nil {
+			doors := e.DoorsOpenAt.In(loc).AddDate(0, 0, days)
+			e.DoorsOpenAt = &doors
+		}
+	}
+	if doorsOpen != "" {
+		h, m, err := parseLegacyClock(doorsOpen)
+		if err != nil {
+			return err
+		}
+		starts = day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+		if setDoors {
+			doors := starts
+			e.DoorsOpenAt = &doors
+		}
+	}
+	e.StartsAt = &starts
+
+	if setEnd && (e.EndsAt == nil || !e.EndsAt.After(starts)) {
+		ends := day.AddDate(0, 0, 1).Add(-time.Second)
+		e.EndsAt = &ends
+	}
+	return nil
+}
+
+// IsPast reports whether the event is over (by EndsAt, or StartsAt when no end is set)
+func (e *Event) IsPast(now time.Time) bool {
+	switch {
+	case e.EndsAt != nil:
+		return now.After(*e.EndsAt)
+	case e.StartsAt != nil:
+		return now.After(*e.StartsAt)
+	}
+	return false
+}
+
+// DoorsOpenIn is how long until doors open; negative once they have. ok is false when unknown.
+func (e *Event) DoorsOpenIn(now time.Time) (d time.Duration, ok bool) {
+	at := e.DoorsOpenAt
+	if at == nil {
+		at = e.StartsAt
+	}
+	if at == nil {
+		return 0, false
+	}
+	return at.Sub(now), true
+}
@@ -0,0 +1,314 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseEventTime(t *testing.T) {
+	kl := mustLoadLocation(t, DefaultTimeZone)
+	want := time.Date(2026, 12, 31, 20, 0, 0, 0, kl)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2026-12-31T20:00:00+08:00", want: want},
+		{value: "2026-12-31T12:00:00Z", want: want}, // same instant, other offset
+		{value: "2026-12-31T20:00:00", want: want},
+		{value: "2026-12-31T20:00", want: want},
+		{value: "2026-12-31 20:00:00", want: want},
+		{value: " 2026-12-31 20:00 ", want: want},
+		{value: "2026-12-31T20:00:30", want: want.Add(30 * time.Second)},
+		{value: "31/12/2026 20:00", wantErr: true},
+		{value: "2026-12-31", wantErr: true}, // a day is not a time
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseEventTime(tt.value, kl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEventTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("ParseEventTime(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLegacyDate(t *testing.T) {
+	kl := mustLoadLocation(t, DefaultTimeZone)
+	newYearsEve := time.Date(2026, 12, 31, 0, 0, 0, 0, kl)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2026-12-31", want: newYearsEve},
+		{value: "31/12/2026", want: newYearsEve},
+		{value: "05/03/2026", want: time.Date(2026, 3, 5, 0, 0, 0, 0, kl)}, // day first
+		{value: "5/3/2026", want: time.Date(2026, 3, 5, 0, 0, 0, 0, kl)},
+		{value: "31 Dec 2026", want: newYearsEve},
+		{value: "05 Mar 2026", want: time.Date(2026, 3, 5, 0, 0, 0, 0, kl)},
+		{value: "31 December 2026", want: newYearsEve},
+		{value: "December 31, 2026", want: newYearsEve},
+		{value: "Dec 31, 2026", want: newYearsEve},
+		{value: "2026-12-31T12:00:00Z", want: time.Date(2026, 12, 31, 20, 0, 0, 0, kl)},
+		{value: "12/31/2026", wantErr: true}, // month first is not a layout we ever stored
+		{value: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLegacyDate(tt.value, kl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLegacyDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("parseLegacyDate(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLegacyClock(t *testing.T) {
+	tests := []struct {
+		value     string
+		hour, min int
+		wantErr   bool
+	}{
+		{value: "19:30", hour: 19, min: 30},
+		{value: "19:30:00", hour: 19, min: 30},
+		{value: "7:30 PM", hour: 19, min: 30},
+		{value: "7:30 pm", hour: 19, min: 30},
+		{value: "7:30PM", hour: 19, min: 30},
+		{value: "12:15 AM", hour: 0, min: 15},
+		{value: "8 PM", hour: 20},
+		{value: "8pm", hour: 20},
+		{value: " 09:00 ", hour: 9},
+		{value: "25:00", wantErr: true},
+		{value: "half seven", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			hour, min, err := parseLegacyClock(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLegacyClock(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && (hour != tt.hour || min != tt.min) {
+				t.Errorf("parseLegacyClock(%q) = %02d:%02d, want %02d:%02d", tt.value, hour, min, tt.hour, tt.min)
+			}
+		})
+	}
+}
+
+func TestEventApplySchedule(t *testing.T) {
+	kl := mustLoadLocation(t, DefaultTimeZone)
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+	at := func(loc *time.Location, value string) *time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+	// An event already scheduled: doors 19:00, show 20:00 to 23:00 on 31 Dec
+	scheduled := func() Event {
+		return Event{
+			TimeZone:    DefaultTimeZone,
+			StartsAt:    at(kl, "2026-12-31 20:00:00"),
+			EndsAt:      at(kl, "2026-12-31 23:00:00"),
+			DoorsOpenAt: at(kl, "2026-12-31 19:00:00"),
+			Date:        "2026-12-31",
+			DoorsOpen:   "19:00",
+		}
+	}
+
+	tests := []struct {
+		name          string
+		event         Event
+		in            ScheduleInput
+		wantStart     *time.Time
+		wantEnd       *time.Time
+		wantDoors     *time.Time
+		wantDate      string
+		wantDoorsOpen string
+		wantErr       string
+	}{
+		{
+			name:      "structured, local times read in the default zone",
+			in:        ScheduleInput{StartsAt: "2026-12-31T20:00", EndsAt: "2026-12-31T23:00", DoorsOpenAt: "2026-12-31T19:00"},
+			wantStart: at(kl, "2026-12-31 20:00:00"), wantEnd: at(kl, "2026-12-31 23:00:00"), wantDoors: at(kl, "2026-12-31 19:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "19:00",
+		},
+		{
+			name:      "structured, local times read in the event's zone",
+			in:        ScheduleInput{TimeZone: "Asia/Tokyo", StartsAt: "2026-12-31T20:00"},
+			wantStart: at(tokyo, "2026-12-31 20:00:00"),
+			wantDate:  "2026-12-31",
+		},
+		{
+			name:    "unknown time zone",
+			in:      ScheduleInput{TimeZone: "Mars/Olympus_Mons", StartsAt: "2026-12-31T20:00"},
+			wantErr: "unknown time zone 'Mars/Olympus_Mons'",
+		},
+		{
+			name:    "no start at all",
+			in:      ScheduleInput{EndsAt: "2026-12-31T23:00"},
+			wantErr: "starts_at is required",
+		},
+		{
+			name:    "ends when it starts",
+			in:      ScheduleInput{StartsAt: "2026-12-31T20:00", EndsAt: "2026-12-31T20:00"},
+			wantErr: "ends_at must be after starts_at",
+		},
+		{
+			name:    "ends before it starts",
+			in:      ScheduleInput{StartsAt: "2026-12-31T20:00", EndsAt: "2026-12-31T19:59"},
+			wantErr: "ends_at must be after starts_at",
+		},
+		{
+			name:    "doors after the start",
+			in:      ScheduleInput{StartsAt: "2026-12-31T20:00", DoorsOpenAt: "2026-12-31T20:01"},
+			wantErr: "doors_open_at cannot be after starts_at",
+		},
+		{
+			name:      "doors at the start",
+			in:        ScheduleInput{StartsAt: "2026-12-31T20:00", DoorsOpenAt: "2026-12-31T20:00"},
+			wantStart: at(kl, "2026-12-31 20:00:00"), wantDoors: at(kl, "2026-12-31 20:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "20:00",
+		},
+		{
+			name:      "legacy date and 12-hour doors: doors double as the start, runs to the end of the day",
+			in:        ScheduleInput{Date: "31/12/2026", DoorsOpen: "7:30 PM"},
+			wantStart: at(kl, "2026-12-31 19:30:00"), wantEnd: at(kl, "2026-12-31 23:59:59"), wantDoors: at(kl, "2026-12-31 19:30:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "19:30",
+		},
+		{
+			name:      "legacy date only starts at midnight",
+			in:        ScheduleInput{Date: "December 31, 2026"},
+			wantStart: at(kl, "2026-12-31 00:00:00"), wantEnd: at(kl, "2026-12-31 23:59:59"),
+			wantDate: "2026-12-31",
+		},
+		{
+			name:      "legacy date in the event's zone",
+			in:        ScheduleInput{TimeZone: "Asia/Tokyo", Date: "2026-12-31", DoorsOpen: "18:00"},
+			wantStart: at(tokyo, "2026-12-31 18:00:00"), wantEnd: at(tokyo, "2026-12-31 23:59:59"), wantDoors: at(tokyo, "2026-12-31 18:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "18:00",
+		},
+		{
+			name:    "unreadable legacy date",
+			in:      ScheduleInput{Date: "New Year's Eve", DoorsOpen: "19:30"},
+			wantErr: "could not read date 'New Year's Eve'",
+		},
+		{
+			name:    "unreadable legacy doors",
+			in:      ScheduleInput{Date: "2026-12-31", DoorsOpen: "after dinner"},
+			wantErr: "could not read doors open time 'after dinner'",
+		},
+		{
+			name:    "legacy doors without any date",
+			in:      ScheduleInput{DoorsOpen: "19:30"},
+			wantErr: "date is required",
+		},
+		{
+			name:      "structured start wins over the legacy strings",
+			in:        ScheduleInput{StartsAt: "2026-12-30T21:00", Date: "2026-12-31", DoorsOpen: "19:30"},
+			wantStart: at(kl, "2026-12-30 21:00:00"),
+			wantDate:  "2026-12-30",
+		},
+		{
+			name:      "partial update: a new end keeps the start and doors",
+			event:     scheduled(),
+			in:        ScheduleInput{EndsAt: "2027-01-01T01:00"},
+			wantStart: at(kl, "2026-12-31 20:00:00"), wantEnd: at(kl, "2027-01-01 01:00:00"), wantDoors: at(kl, "2026-12-31 19:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "19:00",
+		},
+		{
+			name:      "partial update: a new time zone keeps the instants",
+			event:     scheduled(),
+			in:        ScheduleInput{TimeZone: "Asia/Tokyo"},
+			wantStart: at(kl, "2026-12-31 20:00:00"), wantEnd: at(kl, "2026-12-31 23:00:00"), wantDoors: at(kl, "2026-12-31 19:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "20:00", // 19:00 in Kuala Lumpur
+		},
+		{
+			name:      "partial update: an old app sending back what it was shown changes nothing",
+			event:     scheduled(),
+			in:        ScheduleInput{Date: "2026-12-31", DoorsOpen: "19:00"},
+			wantStart: at(kl, "2026-12-31 20:00:00"), wantEnd: at(kl, "2026-12-31 23:00:00"), wantDoors: at(kl, "2026-12-31 19:00:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "19:00",
+		},
+		{
+			name:      "partial update: legacy doors move the start and doors on the same day, the end stays",
+			event:     scheduled(),
+			in:        ScheduleInput{Date: "2026-12-31", DoorsOpen: "6:30 PM"},
+			wantStart: at(kl, "2026-12-31 18:30:00"), wantEnd: at(kl, "2026-12-31 23:00:00"), wantDoors: at(kl, "2026-12-31 18:30:00"),
+			wantDate: "2026-12-31", wantDoorsOpen: "18:30",
+		},
+		{
+			name:      "partial update: a new legacy date moves the whole schedule by days",
+			event:     scheduled(),
+			in:        ScheduleInput{Date: "2027-01-02", DoorsOpen: "19:00"},
+			wantStart: at(kl, "2027-01-02 20:00:00"), wantEnd: at(kl, "2027-01-02 23:00:00"), wantDoors: at(kl, "2027-01-02 19:00:00"),
+			wantDate: "2027-01-02", wantDoorsOpen: "19:00",
+		},
+		{
+			name:      "partial update: a new legacy day and doors, the end moves with the day",
+			event:     scheduled(),
+			in:        ScheduleInput{Date: "2027-01-02", DoorsOpen: "18:00"},
+			wantStart: at(kl, "2027-01-02 18:00:00"), wantEnd: at(kl, "2027-01-02 23:00:00"), wantDoors: at(kl, "2027-01-02 18:00:00"),
+			wantDate: "2027-01-02", wantDoorsOpen: "18:00",
+		},
+		{
+			name:    "partial update that breaks the order is refused",
+			event:   scheduled(),
+			in:      ScheduleInput{StartsAt: "2026-12-31T23:30"},
+			wantErr: "ends_at must be after starts_at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+			err := event.ApplySchedule(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ApplySchedule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplySchedule() error = %v", err)
+			}
+			for _, f := range []struct {
+				name      string
+				got, want *time.Time
+			}{
+				{"starts_at", event.StartsAt, tt.wantStart},
+				{"ends_at", event.EndsAt, tt.wantEnd},
+				{"doors_open_at", event.DoorsOpenAt, tt.wantDoors},
+			} {
+				if (f.got == nil) != (f.want == nil) || (f.got != nil && !f.got.Equal(*f.want)) {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+			if event.Date != tt.wantDate || event.DoorsOpen != tt.wantDoorsOpen {
+				t.Errorf("legacy strings = %q %q, want %q %q", event.Date, event.DoorsOpen, tt.wantDate, tt.wantDoorsOpen)
+			}
+		})
+	}
+}
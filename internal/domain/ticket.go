@@ -14,15 +14,25 @@ type Ticket struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	EventID     uint       `json:"event_id"`
-	Event       Event      `json:"event" gorm:"foreignKey:EventID"`
-	Category    string     `json:"category"`
-	Price       float64    `json:"price"`
-	IsSold      bool       `json:"is_sold" gorm:"default:false"`
-	CheckedInAt *time.Time `json:"checked_in_at"`
-
-	OrderID *uint `json:"order_id"`
-	Stock   int   `json:"stock,omitempty" gorm:"-"`
+	EventID         uint       `json:"event_id"`
+	Event           Event      `json:"event" gorm:"foreignKey:EventID"`
+	Category        string     `json:"category"`
+	Price           Money      `json:"price" gorm:"column:price_sen"`
+	SeatID          *uint      `json:"seat_id,omitempty"`    // reserved seating only
+	SeatLabel       string     `json:"seat_label,omitempty"` // "Stalls, Row B, Seat 12"
+	IsSold          bool       `json:"is_sold" gorm:"default:false"`
+	Status          string     `json:"status" gorm:"index;default:'sold'"` // See reservation.go
+	CheckedInAt     *time.Time `json:"checked_in_at"`
+	CheckedInBy     *uint      `json:"checked_in_by"`     // Operator whose scan won
+	CheckedInGate   string     `json:"checked_in_gate"`   // Gate/scanner that admitted the ticket
+	CheckedInDevice string     `json:"checked_in_device"` // Set when the admission came from an offline sync
+	Inside          bool       `json:"inside"`            // Only meaningful for in_out events
+
+	OrderID           *uint      `json:"order_id"`                            // the purchase; refunds go to its buyer
+	HolderUserID      *uint      `json:"holder_user_id" gorm:"index"`         // who owns it now; the buyer unless transferred
+	CredentialVersion int        `json:"credential_version" gorm:"default:0"` // bumped on transfer, older QR codes stop working
+	Stock             int        `json:"stock,omitempty" gorm:"-"`
+	OpensAt           *time.Time `json:"opens_at,omitempty" gorm:"-"` // Marketplace: tier not on sale yet
 }
 
 func (t *Ticket) BeforeCreate(tx *gorm.DB) (err error) {
@@ -38,6 +48,7 @@ type TicketRepository interface {
 	CreateUser(user *User) error
 	UpdateUser(user *User) error
 	GetUserByID(id string) (*User, error)
+	GetUserForUpdate(id uint) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUserWithTickets(id string) (*User, error)
 	SearchCustomerByName(name string) ([]User, error)
@@ -50,38 +61,180 @@ type TicketRepository interface {
 	GetAll(limit int, offset int, category string, available bool, search string) ([]Ticket, int64, error)
 	UpdateTicketBatch(tickets []Ticket) error
 	GetUserTickets(userID uint) ([]Ticket, error)
-	ScanTicket(ticketID string) (*Ticket, error)
+	GetUserTicket(ticketID string, userID uint) (*Ticket, error)
 	GetGateStats() (int64, int64, error)
 	GetUnscannedByEmail(email string) ([]Ticket, error)
-	BulkCheckIn(ticketIDs []string) error
-	CountSoldTickets(eventID uint, category string) (int64, error)
-	DeleteTicketsByCategory(eventID uint, category string) error
-	CreateTicketBatch(tickets []Ticket) error
+	ClaimCheckIn(ticketID string, eventID uint, version int, operatorID uint, gate string, at time.Time) (bool, error)
+	GetTicketForUpdate(id string) (*Ticket, error)
+	RecordCheckIn(ticketID string, at time.Time, operatorID uint, deviceID, gate string) error
+	GetScannerManifest(eventID uint, since time.Time) ([]Ticket, error)
+	SetTicketInside(ticketID string, inside bool) error
+	RecordScanEvent(scan *ScanEvent)
+	GetTicketScans(ticketID string) ([]ScanEvent, error)
 	UpdateEvent(event *Event) error
 
 	// --- MARKETPLACE & BOOKING ---
-	GetMarketplace(search string) ([]Ticket, error)
-	GetAvailableSequential(eventID uint, category string, limit int) ([]Ticket, error)
-	CreateBulkBooking(userID uint, eventID uint, category string, quantity int) error
-	GetTicketTier(eventID uint, category string) (struct {
-		Price float64
-		Stock int
-	}, error)
-	CreateOrderItem(orderID uint, category string, quantity int) error
+	GetMarketplace(search string, viewerID uint) ([]Ticket, error)
+
+	// --- TIER INVENTORY ---
+	CreateTiers(eventID uint, tiers []TicketTier) error
+	GetEventTiers(eventID uint) ([]TicketTier, error)
+	GetTicketTier(eventID uint, category string) (*TicketTier, error)
+	AddTierCapacity(eventID uint, category string, quantity int) error
+	DeleteTier(eventID uint, category string) (bool, error)
+	UpdateTierRules(eventID uint, rules TicketTier) error
+	CountUserTierQuantity(userID, tierID, excludeOrderID uint) (int, error)
+
+	// --- RESERVED SEATING ---
+	CreateVenue(venue *Venue) error
+	GetVenue(id uint) (*Venue, error)
+	GetSeatMap(id uint) (*SeatMap, error)
+	CreateEventSeats(eventID, seatMapID uint) (map[string]int, error)
+	HoldEventSeats(orderID, eventID uint, seatIDs []uint) ([]OrderItem, error)
+	GetEventSeat(eventID, seatID uint) (*EventSeat, error)
+	GetEventSeatAvailability(eventID uint) ([]SeatAvailability, error)
+	HoldTierStock(orderID, eventID uint, category string, quantity int) (*OrderItem, error)
+	GetOrderItems(orderID uint) ([]OrderItem, error)
+	IssueOrderTickets(orderID uint) ([]Ticket, error)
+	ReleaseOrderHolds(orderID uint) (int64, error)
+	RefundTickets(orderID uint, ticketIDs []string, restock bool) (int64, error)
 
 	// --- EVENT & GENERATION ---
 	CreateEventStock(req CreateEventRequest) error
 	GetEventByID(id uint) (*Event, error)
 	GetAllEvents() ([]Event, error)
+	GetEventForCheckout(id uint) (*Event, error)
+	TransitionEventStatus(eventID uint, from, to string) (bool, error)
+	CountAvailableTickets(eventID uint) (int64, error)
 
 	// --- ORDER HELPERS ---
 	CreateOrder(order *Order) error
 	GetUserOrders(userID uint) ([]Order, error)
 	GetOrderWithTickets(orderID string, userID uint) (Order, error)
 	GetOrderById(id string) (*Order, error)
+	GetOrderByBillID(billID string) (*Order, error)
+	GetOrderEvents(orderID uint) ([]Event, error)
 	UpdateOrder(order *Order) error
 	UpdateOrderFields(orderID uint, fields map[string]interface{}) error
 	CleanupExpiredOrders(timeout time.Duration) (int64, error)
+	TransitionOrderStatus(orderID uint, from []string, to string) (bool, error)
+
+	// --- SHOPPING CART ---
+	GetOrCreateCart(userID uint) (*Cart, error)
+	GetCartForUpdate(userID uint) (*Cart, error)
+	AddCartItem(item *CartItem) error
+	UpdateCartItemQuantity(cartID, itemID uint, quantity int) (bool, error)
+	DeleteCartItem(cartID, itemID uint) (bool, error)
+	ClearCart(cartID uint) error
+	TouchCart(cartID uint, expiresAt time.Time) error
+	CleanupExpiredCarts() (int64, error)
+	TakeCartHolds(cartID, eventID, orderID uint) ([]OrderItem, error)
+
+	// --- REFUND REQUESTS ---
+	CreateRefundRequest(req *RefundRequest) error
+	GetRefundRequest(id uint) (*RefundRequest, error)
+	ListRefundRequests(status string) ([]RefundRequest, error)
+	GetUserRefundRequests(userID uint) ([]RefundRequest, error)
+	GetOpenRefundRequest(orderID uint) (*RefundRequest, error)
+	UpdateRefundRequest(req *RefundRequest) error
+	CreateRefund(refund *Refund) error
+	GetProcessingRefund(orderID uint) (*Refund, error)
+	UpdateRefund(refund *Refund) error
+	AddOrderRefund(orderID uint, amount Money, pointsReversed, pointsRestored int) error
+
+	// --- TICKET TRANSFERS ---
+	CreateTicketTransfer(transfer *TicketTransfer) error
+	GetTicketTransfer(id uint) (*TicketTransfer, error)
+	GetPendingTransfer(ticketID string) (*TicketTransfer, error)
+	UpdateTicketTransfer(transfer *TicketTransfer) error
+	GetUserTransfers(userID uint, email string) ([]TicketTransfer, error)
+	GetTicketTransfers(ticketID string) ([]TicketTransfer, error)
+	MoveTicketHolder(ticketID string, fromUserID, toUserID uint) (bool, error)
+
+	// --- RESALE ---
+	CreateResaleListing(listing *ResaleListing) error
+	GetResaleListing(id uint) (*ResaleListing, error)
+	GetLiveListing(ticketID string) (*ResaleListing, error)
+	ListResaleListings(eventID uint) ([]ResaleListing, error)
+	GetUserListings(sellerID uint) ([]ResaleListing, error)
+	TransitionListing(listingID uint, from, to string) (bool, error)
+	ReserveResaleListing(listingID, orderID, buyerID uint) (*OrderItem, bool, error)
+	SellResaleListing(listing *ResaleListing, orderID, buyerID uint) (bool, error)
+	CancelTicketListings(ticketIDs []string) ([]ResaleListing, error)
+	CancelEventListings(eventID uint) (int64, error)
+	AddOrderResold(orderID uint, amount Money) error
+	CreateResalePayout(payout *ResalePayout) error
+	GetResalePayout(id uint) (*ResalePayout, error)
+	ListResalePayouts(status string) ([]ResalePayout, error)
+	GetUserPayouts(sellerID uint) ([]ResalePayout, error)
+	UpdateResalePayout(payout *ResalePayout) error
+
+	// --- ACCESS CODES ---
+	CreateAccessCodes(codes []AccessCode) error
+	GetAccessCode(id uint) (*AccessCode, error)
+	ListAccessCodes(eventID uint) ([]AccessCode, error)
+	SetAccessCodeActive(id uint, active bool) error
+	GetAccessCodeForRedeem(code string) (*AccessCode, error)
+	RedeemAccessCode(code *AccessCode, userID uint, tierIDs []uint) error
+	GetUnlockedTierIDs(userID uint, tierIDs []uint) ([]uint, error)
+	HasTierUnlock(userID, tierID uint) (bool, error)
+
+	// --- WAITLISTS ---
+	CreateWaitlistEntry(entry *WaitlistEntry) error
+	GetLiveWaitlistEntry(tierID, userID uint) (*WaitlistEntry, error)
+	GetWaitlistPosition(entry *WaitlistEntry) (int, error)
+	GetUserWaitlists(userID uint) ([]WaitlistEntry, error)
+	CountWaiting(tierID uint) (int64, error)
+	GetWaitlistedTiers() ([]TicketTier, error)
+	GetWaitingEntries(tierID uint, limit int) ([]WaitlistEntry, error)
+	OfferWaitlistStock(entry *WaitlistEntry, quantity int, expiresAt time.Time) (bool, error)
+	CloseWaitlistEntry(entry *WaitlistEntry, status string) (bool, error)
+	GetExpiredWaitlistOffers(now time.Time) ([]WaitlistEntry, error)
+	GetActiveOffer(userID, tierID uint, now time.Time) (*WaitlistEntry, error)
+	TakeWaitlistOffer(entry *WaitlistEntry, orderID uint, quantity int) (*OrderItem, bool, error)
+
+	// --- PROMO CODES ---
+	CreatePromoCode(promo *PromoCode) error
+	UpdatePromoCode(promo *PromoCode) error
+	GetPromoCode(id uint) (*PromoCode, error)
+	ListPromoCodes() ([]PromoCode, error)
+	DeletePromoCode(id uint) error
+	GetPromoCodeForCheckout(code string) (*PromoCode, error)
+	CountPromoRedemptions(promoID, userID uint) (int64, error)
+	GetPromoCodeStats(promoID uint) (*PromoCodeStats, error)
+	GetTiersByIDs(ids []uint) ([]TicketTier, error)
+	CreateOrderLines(lines []OrderLine) error
+
+	// --- FEES & TAX ---
+	GetChargePolicies(eventID uint) ([]ChargePolicy, error)
+	GetChargePolicy(eventID, tierID *uint) (*ChargePolicy, error)
+	SaveChargePolicy(policy *ChargePolicy) error
+
+	// --- PAYMENT EVENTS ---
+	CreatePaymentEvent(event *PaymentEvent) (bool, error)
+	GetPaymentEvent(transactionID string) (*PaymentEvent, error)
+	UpdatePaymentEvent(event *PaymentEvent) error
+	IncrementPaymentEventDeliveries(id uint) error
+
+	// --- IDEMPOTENCY KEYS ---
+	ClaimIdempotencyKey(key *IdempotencyKey) (bool, error)
+	GetIdempotencyKey(userID uint, scope, key string) (*IdempotencyKey, error)
+	CompleteIdempotencyKey(id uint, statusCode int, body []byte, orderID *uint) error
+	ReleaseIdempotencyKey(id uint) error
+	CleanupIdempotencyKeys(olderThan time.Duration) (int64, error)
+
+	// --- BACKGROUND JOBS & NOTIFICATIONS ---
+	CreateJob(job *Job) error
+	GetJob(id uint) (*Job, error)
+	FindJob(jobType, targetID string) (*Job, error)
+	UpdateJob(job *Job) error
+	GetUnfinishedJobs() ([]Job, error)
+	CreateNotification(n *Notification) error
+	GetUserNotifications(userID uint) ([]Notification, error)
+	MarkNotificationsRead(userID uint) error
+	GetEventOrders(eventID uint, afterID uint, statuses []string, limit int) ([]Order, error)
+	CountEventOrders(eventID uint, statuses []string) (int64, error)
+	VoidUnscannedTickets(eventID uint) (int64, error)
 
 	// --- ADMIN STATS ---
 	GetAdminStats() (map[string]interface{}, error)
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Checkout error codes for a tier's sales rules, returned to clients as "code"
+const (
+	CodeTierNotOnSale   = "TIER_NOT_ON_SALE"
+	CodeTierSalesClosed = "TIER_SALES_CLOSED"
+	CodeBelowMinOrder   = "BELOW_MIN_PER_ORDER"
+	CodeAboveMaxOrder   = "ABOVE_MAX_PER_ORDER"
+	CodeAboveMaxUser    = "ABOVE_MAX_PER_USER"
+)
+
+// CheckoutError is a checkout refusal the buyer can act on (wrong quantity, too
+// early, ...), as opposed to something breaking on our side.
+type CheckoutError struct {
+	Code    string
+	Message string
+}
+
+func (e *CheckoutError) Error() string {
+	return e.Message
+}
+
+func (t *TicketTier) ValidateRules() error {
+	if t.SalesStartAt != nil && t.SalesEndAt != nil && !t.SalesEndAt.After(*t.SalesStartAt) {
+		return fmt.Errorf("tier '%s': sales must end after they start", t.Category)
+	}
+	if t.MinPerOrder < 0 || t.MaxPerOrder < 0 || t.MaxPerUser < 0 {
+		return fmt.Errorf("tier '%s': purchase limits cannot be negative", t.Category)
+	}
+	if t.MaxPerOrder > 0 && t.MinPerOrder > t.MaxPerOrder {
+		return fmt.Errorf("tier '%s': min_per_order is above max_per_order", t.Category)
+	}
+	if t.MaxPerUser > 0 && t.MinPerOrder > t.MaxPerUser {
+		return fmt.Errorf("tier '%s': min_per_order is above max_per_user", t.Category)
+	}
+	return nil
+}
+
+// OnSale reports whether the tier's sales window is open at `now`
+func (t *TicketTier) OnSale(now time.Time) bool {
+	return (t.SalesStartAt == nil || !now.Before(*t.SalesStartAt)) &&
+		(t.SalesEndAt == nil || now.Before(*t.SalesEndAt))
+}
+
+// CheckPurchase applies the tier's rules to one order taking `quantity` of it,
+// by a user who already holds or owns `owned` from earlier orders.
+func (t *TicketTier) CheckPurchase(quantity, owned int, now time.Time) error {
+	if t.SalesStartAt != nil && now.Before(*t.SalesStartAt) {
+		return &CheckoutError{CodeTierNotOnSale, fmt.Sprintf("%s goes on sale at %s", t.Category, t.SalesStartAt.Format(time.RFC3339))}
+	}
+	if t.SalesEndAt != nil && !now.Before(*t.SalesEndAt) {
+		return &CheckoutError{CodeTierSalesClosed, fmt.Sprintf("sales for %s closed at %s", t.Category, t.SalesEndAt.Format(time.RFC3339))}
+	}
+	if t.MinPerOrder > 0 && quantity < t.MinPerOrder {
+		return &CheckoutError{CodeBelowMinOrder, fmt.Sprintf("%s must be bought at least %d at a time", t.Category, t.MinPerOrder)}
+	}
+	if t.MaxPerOrder > 0 && quantity > t.MaxPerOrder {
+		return &CheckoutError{CodeAboveMaxOrder, fmt.Sprintf("%s is limited to %d per order", t.Category, t.MaxPerOrder)}
+	}
+	if t.MaxPerUser > 0 && owned+quantity > t.MaxPerUser {
+		return &CheckoutError{CodeAboveMaxUser, fmt.Sprintf("%s is limited to %d per customer, you already have %d", t.Category, t.MaxPerUser, owned)}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTicketTierCheckPurchase(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		tier     TicketTier
+		quantity int
+		owned    int
+		wantCode string // "" when the purchase is allowed
+	}{
+		{name: "no rules", tier: TicketTier{}, quantity: 10},
+		{name: "inside the sales window", tier: TicketTier{SalesStartAt: &before, SalesEndAt: &after}, quantity: 1},
+
+		{name: "before sales start", tier: TicketTier{SalesStartAt: &after}, quantity: 1, wantCode: CodeTierNotOnSale},
+		{name: "sales start now", tier: TicketTier{SalesStartAt: &now}, quantity: 1},
+		{name: "after sales end", tier: TicketTier{SalesEndAt: &before}, quantity: 1, wantCode: CodeTierSalesClosed},
+		{name: "sales end now", tier: TicketTier{SalesEndAt: &now}, quantity: 1, wantCode: CodeTierSalesClosed},
+
+		{name: "below min per order", tier: TicketTier{MinPerOrder: 2}, quantity: 1, wantCode: CodeBelowMinOrder},
+		{name: "exactly min per order", tier: TicketTier{MinPerOrder: 2}, quantity: 2},
+		{name: "above max per order", tier: TicketTier{MaxPerOrder: 4}, quantity: 5, wantCode: CodeAboveMaxOrder},
+		{name: "exactly max per order", tier: TicketTier{MaxPerOrder: 4}, quantity: 4},
+
+		{name: "above max per user", tier: TicketTier{MaxPerUser: 4}, quantity: 2, owned: 3, wantCode: CodeAboveMaxUser},
+		{name: "owned plus quantity is max per user", tier: TicketTier{MaxPerUser: 4}, quantity: 1, owned: 3},
+		{name: "max per user counts this order alone too", tier: TicketTier{MaxPerUser: 4}, quantity: 5, wantCode: CodeAboveMaxUser},
+
+		{name: "the window is checked before quantities", tier: TicketTier{SalesStartAt: &after, MinPerOrder: 2}, quantity: 1, wantCode: CodeTierNotOnSale},
+		{name: "per order limits before the per user one", tier: TicketTier{MaxPerOrder: 2, MaxPerUser: 2}, quantity: 3, wantCode: CodeAboveMaxOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tier.Category = "GA"
+			err := tt.tier.CheckPurchase(tt.quantity, tt.owned, now)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("CheckPurchase() error = %v, want none", err)
+				}
+				return
+			}
+			var checkoutErr *CheckoutError
+			if !errors.As(err, &checkoutErr) || checkoutErr.Code != tt.wantCode {
+				t.Fatalf("CheckPurchase() error = %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
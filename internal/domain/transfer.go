@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ApplyTransferCutoff sets how many hours before the start transfers stop; nil leaves it
+func (e *Event) ApplyTransferCutoff(cutoffHours *int) error {
+	if cutoffHours == nil {
+		return nil
+	}
+	if *cutoffHours < 0 {
+		return fmt.Errorf("transfer_cutoff_hours cannot be negative")
+	}
+	e.TransferCutoffHours = *cutoffHours
+	return nil
+}
+
+// CheckTransferable says whether tickets for the event may still change hands
+func (e *Event) CheckTransferable(now time.Time) error {
+	if e.Status == EventCancelled {
+		return fmt.Errorf("%s has been cancelled", e.Name)
+	}
+	if e.StartsAt == nil {
+		return nil
+	}
+	cutoff := e.StartsAt.Add(-time.Duration(e.TransferCutoffHours) * time.Hour)
+	if !now.Before(cutoff) {
+		if e.TransferCutoffHours == 0 {
+			return fmt.Errorf("%s has already started", e.Name)
+		}
+		return fmt.Errorf("transfers for %s closed %dh before the show", e.Name, e.TransferCutoffHours)
+	}
+	return nil
+}
+
+// Ticket transfer states
+const (
+	TransferPending   = "pending"   // waiting for the recipient
+	TransferAccepted  = "accepted"  // the ticket is the recipient's now
+	TransferDeclined  = "declined"  // by the recipient
+	TransferCancelled = "cancelled" // by the sender, or the ticket stopped being transferable
+)
+
+// TicketTransfer is a holder giving a ticket to someone else's account. The recipient
+// is named by email and doesn't need an account yet: the transfer waits for them to
+// register and accept. Accepted transfers are the ticket's ownership history.
+type TicketTransfer struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TicketID   string     `gorm:"type:uuid;index" json:"ticket_id"`
+	Ticket     *Ticket    `json:"ticket,omitempty" gorm:"foreignKey:TicketID"`
+	FromUserID uint       `gorm:"index" json:"from_user_id"`
+	ToEmail    string     `gorm:"index" json:"to_email"`
+	ToUserID   *uint      `json:"to_user_id,omitempty"` // set once accepted
+	Status     string     `gorm:"index" json:"status"`
+	Note       string     `json:"note,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
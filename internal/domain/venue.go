@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Venue is a physical place with one or more seating layouts (a concert hall
+// may have an "end stage" and an "in the round" map).
+type Venue struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	SeatMaps  []SeatMap `json:"seat_maps,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SeatMap is a layout of sections → rows → seats. Every seat belongs to a price
+// zone; a seated event prices each zone with a TicketTier of the same Category.
+type SeatMap struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	VenueID   uint          `gorm:"index" json:"venue_id"`
+	Name      string        `json:"name"`
+	Sections  []SeatSection `json:"sections,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type SeatSection struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SeatMapID uint      `gorm:"index" json:"seat_map_id"`
+	Name      string    `json:"name"`
+	Rows      []SeatRow `gorm:"foreignKey:SectionID" json:"rows,omitempty"`
+}
+
+type SeatRow struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	SectionID uint   `gorm:"index" json:"section_id"`
+	Label     string `json:"label"`
+	Seats     []Seat `gorm:"foreignKey:RowID" json:"seats,omitempty"`
+}
+
+type Seat struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	RowID  uint   `gorm:"index" json:"row_id"`
+	Number string `json:"number"`
+	Zone   string `json:"zone"` // price zone, matches a TicketTier.Category
+}
+
+// Seat states for one event
+const (
+	SeatAvailable = "available"
+	SeatHeld      = "held"
+	SeatSold      = "sold"
+)
+
+// EventSeat is a seat's state for one event. The unique index is what makes a seat
+// sellable once per event; holds are conditional UPDATEs on Status (see HoldEventSeats).
+type EventSeat struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	EventID     uint   `gorm:"uniqueIndex:idx_event_seat" json:"event_id"`
+	SeatID      uint   `gorm:"uniqueIndex:idx_event_seat" json:"seat_id"`
+	Zone        string `json:"zone"`
+	Label       string `json:"label"` // "Stalls, Row B, Seat 12"
+	Status      string `gorm:"index" json:"status"`
+	OrderID     *uint  `gorm:"index" json:"-"`
+	OrderItemID *uint  `gorm:"index" json:"-"`
+	CartItemID  *uint  `gorm:"index" json:"-"` // held in a cart, not yet in an order
+}
+
+// SeatAvailability is one seat of the live seat map for an event
+type SeatAvailability struct {
+	SeatID  uint   `json:"seat_id"`
+	Section string `json:"section"`
+	Row     string `json:"row" gorm:"column:row_label"`
+	Number  string `json:"number"`
+	Zone    string `json:"zone"`
+	Price   Money  `json:"price"`
+	Status  string `json:"status"`
+}
+
+// --- SEAT MAP REQUESTS ---
+
+type CreateVenueRequest struct {
+	Name     string                 `json:"name" binding:"required"`
+	Address  string                 `json:"address"`
+	SeatMaps []CreateSeatMapRequest `json:"seat_maps"`
+}
+
+type CreateSeatMapRequest struct {
+	Name     string               `json:"name" binding:"required"`
+	Sections []SeatSectionRequest `json:"sections" binding:"required"`
+}
+
+type SeatSectionRequest struct {
+	Name string           `json:"name"`
+	Zone string           `json:"zone"` // default zone for the section's rows
+	Rows []SeatRowRequest `json:"rows"`
+}
+
+// SeatRowRequest numbers its seats 1..Seats
+type SeatRowRequest struct {
+	Label string `json:"label"`
+	Seats int    `json:"seats"`
+	Zone  string `json:"zone"` // overrides the section's zone
+}
+
+// NewSeatMap builds the full layout a request describes, ready to be saved in one go
+func (r CreateSeatMapRequest) NewSeatMap(venueID uint) (*SeatMap, error) {
+	if len(r.Sections) == 0 {
+		return nil, fmt.Errorf("a seat map needs at least one section")
+	}
+	seatMap := &SeatMap{VenueID: venueID, Name: r.Name}
+	sectionNames := map[string]bool{}
+	for _, s := range r.Sections {
+		if s.Name == "" || sectionNames[s.Name] {
+			return nil, fmt.Errorf("every section needs a unique name")
+		}
+		sectionNames[s.Name] = true
+
+		section := SeatSection{Name: s.Name}
+		rowLabels := map[string]bool{}
+		for _, row := range s.Rows {
+			if row.Label == "" || rowLabels[row.Label] {
+				return nil, fmt.Errorf("section '%s': every row needs a unique label", s.Name)
+			}
+			rowLabels[row.Label] = true
+			if row.Seats <= 0 {
+				return nil, fmt.Errorf("section '%s' row '%s' needs at least one seat", s.Name, row.Label)
+			}
+			zone := row.Zone
+			if zone == "" {
+				zone = s.Zone
+			}
+			if zone == "" {
+				return nil, fmt.Errorf("section '%s' row '%s' has no price zone", s.Name, row.Label)
+			}
+
+			seatRow := SeatRow{Label: row.Label}
+			for n := 1; n <= row.Seats; n++ {
+				seatRow.Seats = append(seatRow.Seats, Seat{Number: fmt.Sprint(n), Zone: zone})
+			}
+			section.Rows = append(section.Rows, seatRow)
+		}
+		if len(section.Rows) == 0 {
+			return nil, fmt.Errorf("section '%s' has no rows", s.Name)
+		}
+		seatMap.Sections = append(seatMap.Sections, section)
+	}
+	return seatMap, nil
+}
+
+func SeatLabel(section, row, number string) string {
+	return fmt.Sprintf("%s, Row %s, Seat %s", section, row, number)
+}
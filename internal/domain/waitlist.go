@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// WaitlistOfferWindow is how long someone at the front of a waitlist has the
+// released tickets to themselves
+const WaitlistOfferWindow = 15 * time.Minute
+
+// CodeWaitlistActive: a tier's released stock belongs to its waitlist, so buyers
+// without an offer are sent there instead
+const CodeWaitlistActive = "WAITLIST_ACTIVE"
+
+// Waitlist entry states
+//
+//	waiting   → offered    (stock came back: it is held for them for WaitlistOfferWindow)
+//	offered   → fulfilled  (they checked out within the window)
+//	offered   → expired    (they didn't; the stock goes to the next in line)
+//	waiting/offered → left (they gave up their place)
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistOffered   = "offered"
+	WaitlistFulfilled = "fulfilled"
+	WaitlistExpired   = "expired"
+	WaitlistLeft      = "left"
+)
+
+// WaitlistEntry is one user queuing for a sold-out general admission tier, served
+// first come first served (by ID). An offer is a hold like an order's: it counts in
+// the tier's `held`, so nobody else can take those tickets while it lasts.
+type WaitlistEntry struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TierID         uint       `gorm:"index" json:"tier_id"`
+	EventID        uint       `gorm:"index" json:"event_id"`
+	Category       string     `json:"category"`
+	UserID         uint       `gorm:"index" json:"user_id"`
+	Quantity       int        `json:"quantity"` // how many they want
+	Status         string     `gorm:"index" json:"status"`
+	OfferQuantity  int        `json:"offer_quantity,omitempty"` // held for them, may be fewer than Quantity
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+	OrderID        *uint      `json:"order_id,omitempty"` // the checkout that used the offer
+	Position       int        `json:"position,omitempty" gorm:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Live is whether the entry still holds a place in the queue
+func (w *WaitlistEntry) Live() bool {
+	return w.Status == WaitlistWaiting || w.Status == WaitlistOffered
+}
@@ -19,20 +19,8 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		// 2. Format usually is "Bearer <token>"
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		// 3. Parse and Validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the algorithm is what we expect
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-
-			// Return the secret key from your .env
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
+		// 2. Format usually is "Bearer <token>", 3. parse and validate it
+		token, err := parseToken(authHeader)
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
@@ -40,17 +28,48 @@ func AuthRequired() gin.HandlerFunc {
 		}
 
 		// 4. Extract the User ID and save it in the Context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("userID", uint(claims["user_id"].(float64)))
-			c.Set("userRole", claims["user_role"].(string)) // Store role in context for later use
-			c.Set("userName", claims["user_name"].(string))
-			c.Set("userEmail", claims["user_email"].(string))
-		}
+		setClaims(c, token)
 
 		c.Next()
 	}
 }
 
+// OptionalAuth identifies the caller when they send a valid token and lets everyone
+// else through as a guest (no userID in the context), for public pages that show
+// logged-in users a little more
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if token, err := parseToken(authHeader); err == nil && token.Valid {
+				setClaims(c, token)
+			}
+		}
+		c.Next()
+	}
+}
+
+func parseToken(authHeader string) (*jwt.Token, error) {
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validate the algorithm is what we expect
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		// Return the secret key from your .env
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+}
+
+func setClaims(c *gin.Context, token *jwt.Token) {
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		c.Set("userID", uint(claims["user_id"].(float64)))
+		c.Set("userRole", claims["user_role"].(string)) // Store role in context for later use
+		c.Set("userName", claims["user_name"].(string))
+		c.Set("userEmail", claims["user_email"].(string))
+	}
+}
+
 func RolesRequired(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Get role from JWT (stored in context by AuthRequired)
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"neptunes-tix/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyOrderKey is where a handler puts the ID of the order it created, so the
+// key's record points at it
+const IdempotencyOrderKey = "idempotencyOrderID"
+
+// Idempotent makes a mutating endpoint safe to retry. When the request carries an
+// Idempotency-Key header:
+//
+//  1. first time: the key is stored with a hash of the request, the handler runs and
+//     its response is saved with the key
+//  2. same key, same body: the saved response is sent again, the handler doesn't run
+//  3. same key, different body: 409, nothing runs
+//  4. same key while the first request is still running: 409, try again shortly
+//
+// Responses of 5xx aren't saved (the key is released) so a retry gets another go.
+// Requests without the header behave as before. Mount it after AuthRequired: keys
+// are per user.
+func Idempotent(repo domain.TicketRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := c.Request.Method + " " + c.FullPath()
+		sum := sha256.Sum256(append([]byte(scope+"\n"), body...))
+		record := &domain.IdempotencyKey{
+			UserID:      c.GetUint("userID"),
+			Scope:       scope,
+			Key:         key,
+			RequestHash: hex.EncodeToString(sum[:]),
+		}
+
+		// 1. Claim the key; the unique index decides who is first
+		claimed, err := repo.ClaimIdempotencyKey(record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not record Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !claimed {
+			replayIdempotent(c, repo, record)
+			return
+		}
+
+		// 2. Run the handler, keeping a copy of what it sends
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			if p := recover(); p != nil {
+				repo.ReleaseIdempotencyKey(record.ID)
+				panic(p)
+			}
+		}()
+		c.Next()
+
+		// 3. Keep the outcome, unless it was our fault
+		status := c.Writer.Status()
+		if status >= 500 {
+			repo.ReleaseIdempotencyKey(record.ID)
+			return
+		}
+		var orderID *uint
+		if id, ok := c.Get(IdempotencyOrderKey); ok {
+			if id, ok := id.(uint); ok {
+				orderID = &id
+			}
+		}
+		repo.CompleteIdempotencyKey(record.ID, status, recorder.body.Bytes(), orderID)
+	}
+}
+
+func replayIdempotent(c *gin.Context, repo domain.TicketRepository, attempt *domain.IdempotencyKey) {
+	defer c.Abort()
+
+	existing, err := repo.GetIdempotencyKey(attempt.UserID, attempt.Scope, attempt.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not look up Idempotency-Key"})
+		return
+	}
+	if existing.RequestHash != attempt.RequestHash {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "This Idempotency-Key was already used for a different request",
+			"code":  "IDEMPOTENCY_KEY_REUSED",
+		})
+		return
+	}
+	if !existing.Completed {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A request with this Idempotency-Key is still in progress",
+			"code":  "IDEMPOTENCY_KEY_IN_PROGRESS",
+		})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(existing.StatusCode, "application/json; charset=utf-8", existing.ResponseBody)
+}
+
+// responseRecorder tees the response body so it can be stored with the key
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- ACCESS CODES ---
+
+// CreateAccessCodes saves a batch of codes with their tiers; a duplicate code fails the batch
+func (d *dbRepo) CreateAccessCodes(codes []domain.AccessCode) error {
+	return d.db.Create(&codes).Error
+}
+
+func (d *dbRepo) GetAccessCode(id uint) (*domain.AccessCode, error) {
+	var code domain.AccessCode
+	err := d.db.Preload("Tiers").First(&code, id).Error
+	return &code, err
+}
+
+// ListAccessCodes lists an event's codes (every event's when eventID is 0)
+func (d *dbRepo) ListAccessCodes(eventID uint) ([]domain.AccessCode, error) {
+	var codes []domain.AccessCode
+	query := d.db.Preload("Tiers").Order("created_at desc, id desc")
+	if eventID != 0 {
+		query = query.Where("event_id = ?", eventID)
+	}
+	err := query.Find(&codes).Error
+	return codes, err
+}
+
+func (d *dbRepo) SetAccessCodeActive(id uint, active bool) error {
+	return d.db.Model(&domain.AccessCode{}).Where("id = ?", id).Update("active", active).Error
+}
+
+// FOR UPDATE: people redeeming the same code queue up, so a single-use code is used once
+func (d *dbRepo) GetAccessCodeForRedeem(code string) (*domain.AccessCode, error) {
+	var access domain.AccessCode
+	err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", domain.NormalizeAccessCode(code)).
+		First(&access).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := d.db.Model(&access).Association("Tiers").Find(&access.Tiers); err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// RedeemAccessCode unlocks the tiers for the user and counts one use of the code
+func (d *dbRepo) RedeemAccessCode(code *domain.AccessCode, userID uint, tierIDs []uint) error {
+	unlocks := make([]domain.TierUnlock, len(tierIDs))
+	for i, tierID := range tierIDs {
+		unlocks[i] = domain.TierUnlock{UserID: userID, TierID: tierID, AccessCodeID: code.ID}
+	}
+	if err := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&unlocks).Error; err != nil {
+		return err
+	}
+	code.Uses++
+	return d.db.Model(code).Update("uses", code.Uses).Error
+}
+
+// GetUnlockedTierIDs lists which of the given tiers the user has unlocked
+func (d *dbRepo) GetUnlockedTierIDs(userID uint, tierIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(tierIDs) == 0 {
+		return ids, nil
+	}
+	err := d.db.Model(&domain.TierUnlock{}).
+		Where("user_id = ? AND tier_id IN ?", userID, tierIDs).
+		Pluck("tier_id", &ids).Error
+	return ids, err
+}
+
+func (d *dbRepo) HasTierUnlock(userID, tierID uint) (bool, error) {
+	var count int64
+	err := d.db.Model(&domain.TierUnlock{}).
+		Where("user_id = ? AND tier_id = ?", userID, tierID).
+		Count(&count).Error
+	return count > 0, err
+}
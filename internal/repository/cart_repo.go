@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- SHOPPING CART ---
+
+// GetOrCreateCart returns the user's cart, starting an empty one if they have none.
+// The cart row is locked until the transaction ends, so two tabs editing the same
+// cart take turns.
+func (d *dbRepo) GetOrCreateCart(userID uint) (*domain.Cart, error) {
+	cart := domain.Cart{UserID: userID, ExpiresAt: time.Now().Add(domain.CartHoldTime)}
+	if err := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&cart).Error; err != nil {
+		return nil, err
+	}
+	return d.GetCartForUpdate(userID)
+}
+
+func (d *dbRepo) GetCartForUpdate(userID uint) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Where("user_id = ?", userID).
+		First(&cart).Error
+	return &cart, err
+}
+
+// AddCartItem holds the item's stock for the cart (and its seat, for reserved
+// seating), then saves it. Like HoldTierStock the hold is one conditional UPDATE.
+func (d *dbRepo) AddCartItem(item *domain.CartItem) error {
+	if err := d.holdCartStock(item.EventID, item.Category, item.Quantity); err != nil {
+		return err
+	}
+	if err := d.db.Create(item).Error; err != nil {
+		return err
+	}
+	if item.SeatID == nil {
+		return nil
+	}
+	res := d.db.Model(&domain.EventSeat{}).
+		Where("event_id = ? AND seat_id = ? AND status = ?", item.EventID, *item.SeatID, domain.SeatAvailable).
+		Updates(map[string]interface{}{"status": domain.SeatHeld, "cart_item_id": item.ID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("seat %s is no longer available", item.SeatLabel)
+	}
+	return nil
+}
+
+// UpdateCartItemQuantity holds the extra stock, or gives back what is no longer wanted
+func (d *dbRepo) UpdateCartItemQuantity(cartID, itemID uint, quantity int) (bool, error) {
+	var item domain.CartItem
+	if err := d.db.Where("id = ? AND cart_id = ?", itemID, cartID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if delta := quantity - item.Quantity; delta > 0 {
+		if err := d.holdCartStock(item.EventID, item.Category, delta); err != nil {
+			return false, err
+		}
+	} else if delta < 0 {
+		if err := d.releaseCartStock(item.EventID, item.Category, -delta); err != nil {
+			return false, err
+		}
+	}
+	res := d.db.Model(&item).Update("quantity", quantity)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *dbRepo) DeleteCartItem(cartID, itemID uint) (bool, error) {
+	var item domain.CartItem
+	if err := d.db.Where("id = ? AND cart_id = ?", itemID, cartID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := d.releaseCartItem(item); err != nil {
+		return false, err
+	}
+	res := d.db.Delete(&item)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ClearCart empties a cart, giving its stock back, and restarts its hold timer
+func (d *dbRepo) ClearCart(cartID uint) error {
+	if _, err := d.emptyCart(cartID); err != nil {
+		return err
+	}
+	return d.TouchCart(cartID, time.Now().Add(domain.CartHoldTime))
+}
+
+func (d *dbRepo) TouchCart(cartID uint, expiresAt time.Time) error {
+	return d.db.Model(&domain.Cart{}).Where("id = ?", cartID).Update("expires_at", expiresAt).Error
+}
+
+// CleanupExpiredCarts empties carts nobody has touched for CartHoldTime and gives
+// their stock back. A cart being edited right now is locked and skipped: the edit
+// restarts its timer anyway. Returns how many items were dropped.
+func (d *dbRepo) CleanupExpiredCarts() (int64, error) {
+	var cartIDs []uint
+	err := d.db.Model(&domain.Cart{}).
+		Where("expires_at < ? AND id IN (SELECT cart_id FROM cart_items)", time.Now()).
+		Pluck("id", &cartIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var dropped int64
+	for _, cartID := range cartIDs {
+		err := d.db.Transaction(func(tx *gorm.DB) error {
+			var cart domain.Cart
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("id = ? AND expires_at < ?", cartID, time.Now()).
+				Take(&cart).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // being edited, or touched since
+			}
+			if err != nil {
+				return err
+			}
+			n, err := NewDBRepo(tx).emptyCart(cart.ID)
+			dropped += n
+			return err
+		})
+		if err != nil {
+			return dropped, err
+		}
+	}
+	return dropped, nil
+}
+
+// TakeCartHolds turns the cart's items for one event into the order's holds. The
+// stock already counts in the tiers' `held` and the seats are already held, so
+// nothing is re-checked: it only changes hands, like TakeWaitlistOffer. Seats are
+// grouped into one item per zone, as HoldEventSeats does.
+func (d *dbRepo) TakeCartHolds(cartID, eventID, orderID uint) ([]domain.OrderItem, error) {
+	var cartItems []domain.CartItem
+	if err := d.db.Where("cart_id = ? AND event_id = ?", cartID, eventID).Order("id asc").Find(&cartItems).Error; err != nil {
+		return nil, err
+	}
+
+	var items []domain.OrderItem
+	zoneItem := map[string]int{} // zone → index in items
+	for _, ci := range cartItems {
+		tier, err := d.GetTicketTier(eventID, ci.Category)
+		if err != nil {
+			return nil, fmt.Errorf("category '%s' does not exist", ci.Category)
+		}
+		i, seen := zoneItem[ci.Category]
+		if ci.SeatID == nil || !seen {
+			item := domain.OrderItem{
+				OrderID:   orderID,
+				TierID:    tier.ID,
+				EventID:   eventID,
+				Category:  ci.Category,
+				UnitPrice: tier.Price,
+				Quantity:  ci.Quantity,
+				Status:    domain.HoldHeld,
+			}
+			if err := d.db.Create(&item).Error; err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			i = len(items) - 1
+			if ci.SeatID != nil {
+				zoneItem[ci.Category] = i
+			}
+		} else {
+			items[i].Quantity += ci.Quantity
+			if err := d.db.Model(&items[i]).Update("quantity", items[i].Quantity).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		if ci.SeatID != nil {
+			if err := d.db.Model(&domain.EventSeat{}).Where("cart_item_id = ?", ci.ID).
+				Updates(map[string]interface{}{"cart_item_id": nil, "order_id": orderID, "order_item_id": items[i].ID}).Error; err != nil {
+				return nil, err
+			}
+		}
+		if err := d.db.Delete(&ci).Error; err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// emptyCart gives back the stock of every item in the cart and deletes them
+func (d *dbRepo) emptyCart(cartID uint) (int64, error) {
+	var items []domain.CartItem
+	if err := d.db.Where("cart_id = ?", cartID).Find(&items).Error; err != nil {
+		return 0, err
+	}
+	for _, item := range items {
+		if err := d.releaseCartItem(item); err != nil {
+			return 0, err
+		}
+	}
+	res := d.db.Where("cart_id = ?", cartID).Delete(&domain.CartItem{})
+	return res.RowsAffected, res.Error
+}
+
+func (d *dbRepo) holdCartStock(eventID uint, category string, quantity int) error {
+	res := d.db.Model(&domain.TicketTier{}).
+		Where("event_id = ? AND category = ? AND capacity - sold - held >= ?", eventID, category, quantity).
+		Update("held", gorm.Expr("held + ?", quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		tier, err := d.GetTicketTier(eventID, category)
+		if err != nil {
+			return fmt.Errorf("category '%s' does not exist", category)
+		}
+		return fmt.Errorf("only %d tickets left for %s", tier.Available(), category)
+	}
+	return nil
+}
+
+func (d *dbRepo) releaseCartStock(eventID uint, category string, quantity int) error {
+	return d.db.Model(&domain.TicketTier{}).
+		Where("event_id = ? AND category = ?", eventID, category).
+		Update("held", gorm.Expr("held - ?", quantity)).Error
+}
+
+// releaseCartItem gives one item's stock, and its seat, back
+func (d *dbRepo) releaseCartItem(item domain.CartItem) error {
+	if err := d.releaseCartStock(item.EventID, item.Category, item.Quantity); err != nil {
+		return err
+	}
+	if item.SeatID == nil {
+		return nil
+	}
+	return d.db.Model(&domain.EventSeat{}).
+		Where("cart_item_id = ? AND status = ?", item.ID, domain.SeatHeld).
+		Updates(map[string]interface{}{"status": domain.SeatAvailable, "cart_item_id": nil}).Error
+}
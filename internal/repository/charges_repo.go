@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// --- FEES & TAX ---
+
+// GetChargePolicies returns every policy that can apply to the event's orders:
+// its tier and event policies, plus the global one
+func (d *dbRepo) GetChargePolicies(eventID uint) ([]domain.ChargePolicy, error) {
+	var policies []domain.ChargePolicy
+	err := d.db.
+		Where("event_id = ? OR (event_id IS NULL AND tier_id IS NULL)", eventID).
+		Find(&policies).Error
+	return policies, err
+}
+
+// GetChargePolicy finds the policy for exactly this scope (nil, nil is the global one)
+func (d *dbRepo) GetChargePolicy(eventID, tierID *uint) (*domain.ChargePolicy, error) {
+	query := d.db.Model(&domain.ChargePolicy{})
+	if eventID == nil {
+		query = query.Where("event_id IS NULL")
+	} else {
+		query = query.Where("event_id = ?", *eventID)
+	}
+	if tierID == nil {
+		query = query.Where("tier_id IS NULL")
+	} else {
+		query = query.Where("tier_id = ?", *tierID)
+	}
+
+	var policy domain.ChargePolicy
+	err := query.First(&policy).Error
+	return &policy, err
+}
+
+func (d *dbRepo) SaveChargePolicy(policy *domain.ChargePolicy) error {
+	return d.db.Save(policy).Error
+}
+
+// MigrateOrderDiscounts moves the discount rows of the first promo code release
+// into order_lines and drops their old table. Runs once; a no-op afterwards.
+func (d *dbRepo) MigrateOrderDiscounts() error {
+	if !d.db.Migrator().HasTable("order_discounts") {
+		return nil
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO order_lines (order_id, kind, description, amount_sen, source, promo_code_id, code, created_at)
+			SELECT order_id, ?, description, -ROUND(amount * 100), kind, promo_code_id, code, created_at FROM order_discounts`, domain.LineDiscount).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("order_discounts")
+	})
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- IDEMPOTENCY KEYS ---
+
+// ClaimIdempotencyKey stores a new key. Returns false (and no error) when the user
+// already sent this key to the same endpoint.
+func (d *dbRepo) ClaimIdempotencyKey(key *domain.IdempotencyKey) (bool, error) {
+	res := d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "scope"}, {Name: "key"}},
+		DoNothing: true,
+	}).Create(key)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *dbRepo) GetIdempotencyKey(userID uint, scope, key string) (*domain.IdempotencyKey, error) {
+	var record domain.IdempotencyKey
+	err := d.db.Where("user_id = ? AND scope = ? AND key = ?", userID, scope, key).First(&record).Error
+	return &record, err
+}
+
+func (d *dbRepo) CompleteIdempotencyKey(id uint, statusCode int, body []byte, orderID *uint) error {
+	return d.db.Model(&domain.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"completed":     true,
+		"status_code":   statusCode,
+		"response_body": body,
+		"order_id":      orderID,
+	}).Error
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed on our side, so the
+// client's retry runs for real
+func (d *dbRepo) ReleaseIdempotencyKey(id uint) error {
+	return d.db.Delete(&domain.IdempotencyKey{}, id).Error
+}
+
+func (d *dbRepo) CleanupIdempotencyKeys(olderThan time.Duration) (int64, error) {
+	res := d.db.Where("created_at < ?", time.Now().Add(-olderThan)).Delete(&domain.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// --- BACKGROUND JOBS ---
+
+func (d *dbRepo) CreateJob(job *domain.Job) error {
+	return d.db.Create(job).Error
+}
+
+func (d *dbRepo) GetJob(id uint) (*domain.Job, error) {
+	var job domain.Job
+	err := d.db.First(&job, id).Error
+	return &job, err
+}
+
+func (d *dbRepo) FindJob(jobType, targetID string) (*domain.Job, error) {
+	var job domain.Job
+	err := d.db.Where("type = ? AND target_id = ?", jobType, targetID).Order("id desc").First(&job).Error
+	return &job, err
+}
+
+func (d *dbRepo) UpdateJob(job *domain.Job) error {
+	return d.db.Save(job).Error
+}
+
+func (d *dbRepo) GetUnfinishedJobs() ([]domain.Job, error) {
+	var jobs []domain.Job
+	err := d.db.Where("status IN ?", []string{domain.JobQueued, domain.JobRunning}).Order("id asc").Find(&jobs).Error
+	return jobs, err
+}
+
+// --- NOTIFICATIONS ---
+
+func (d *dbRepo) CreateNotification(n *domain.Notification) error {
+	return d.db.Create(n).Error
+}
+
+func (d *dbRepo) GetUserNotifications(userID uint) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	err := d.db.Where("user_id = ?", userID).Order("created_at desc").Limit(50).Find(&notifications).Error
+	return notifications, err
+}
+
+func (d *dbRepo) MarkNotificationsRead(userID uint) error {
+	return d.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+// --- EVENT-WIDE ORDER SWEEPS ---
+
+// Orders with at least one ticket for the event, in ID order after `afterID`
+func (d *dbRepo) GetEventOrders(eventID uint, afterID uint, statuses []string, limit int) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := d.db.
+		Where("id > ? AND status IN ?", afterID, statuses).
+		Where("id IN (SELECT DISTINCT order_id FROM order_items WHERE event_id = ?)", eventID).
+		Order("id asc").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+func (d *dbRepo) CountEventOrders(eventID uint, statuses []string) (int64, error) {
+	var count int64
+	err := d.db.Model(&domain.Order{}).
+		Where("status IN ?", statuses).
+		Where("id IN (SELECT DISTINCT order_id FROM order_items WHERE event_id = ?)", eventID).
+		Count(&count).Error
+	return count, err
+}
+
+// Sold and never scanned → voided. Scanned tickets are history and stay as they are.
+func (d *dbRepo) VoidUnscannedTickets(eventID uint) (int64, error) {
+	res := d.db.Model(&domain.Ticket{}).
+		Where("event_id = ? AND checked_in_at IS NULL AND status = ?", eventID, domain.TicketSold).
+		Update("status", domain.TicketVoided)
+	return res.RowsAffected, res.Error
+}
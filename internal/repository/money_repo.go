@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns maps each legacy ringgit float column to the sen column that replaced it
+// (only on rows matching `where`, when set)
+var moneyColumns = []struct {
+	table, from, to, where string
+}{
+	{"tickets", "price", "price_sen", ""},
+	{"ticket_tiers", "price", "price_sen", ""},
+	{"orders", "total_amount", "total_amount_sen", ""},
+	{"order_items", "unit_price", "unit_price_sen", ""},
+	{"order_lines", "unit_amount", "unit_amount_sen", ""},
+	{"order_lines", "amount", "amount_sen", ""},
+	{"charge_policies", "fee_fixed", "fee_fixed_sen", ""},
+	{"promo_codes", "value", "amount_sen", "kind = 'fixed'"},
+}
+
+// MigrateMoneyColumns fills the new *_sen columns from the old float ones, rounding
+// to the nearest sen. Only rows that haven't been converted are touched, so it is
+// safe to run on every boot. The float columns are left in place (and no longer
+// written) so a rollback still has its data; drop them once nobody needs that.
+func (d *dbRepo) MigrateMoneyColumns() error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range moneyColumns {
+			if !tx.Migrator().HasTable(c.table) || !tx.Migrator().HasColumn(c.table, c.from) {
+				continue
+			}
+			sql := fmt.Sprintf("UPDATE %s SET %s = ROUND(%s * 100) WHERE %s IS NULL AND %s IS NOT NULL",
+				c.table, c.to, c.from, c.to, c.from)
+			if c.where != "" {
+				sql += " AND " + c.where
+			}
+			if err := tx.Exec(sql).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", c.table, c.from, err)
+			}
+			// New rows only write the sen column; the float one mustn't block them
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", c.table, c.from)).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", c.table, c.from, err)
+			}
+		}
+
+		// promo_codes.value also held percentages, which aren't money: they move as they are
+		if tx.Migrator().HasTable("promo_codes") && tx.Migrator().HasColumn("promo_codes", "value") {
+			if err := tx.Exec("UPDATE promo_codes SET percent = value WHERE kind = 'percent' AND percent IS NULL AND value IS NOT NULL").Error; err != nil {
+				return fmt.Errorf("promo_codes.value: %w", err)
+			}
+		}
+		return nil
+	})
+}
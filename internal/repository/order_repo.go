@@ -14,15 +14,22 @@ func (d *dbRepo) CreateOrder(order *domain.Order) error {
 func (d *dbRepo) GetUserTickets(userID uint) ([]domain.Ticket, error) {
 	var tickets []domain.Ticket
 	err := d.db.Preload("Event").
-		Joins("JOIN orders ON orders.id = tickets.order_id").
-		Where("orders.user_id = ?", userID).
+		Where("holder_user_id = ?", userID).
 		Find(&tickets).Error
 	return tickets, err
 }
 
+func (d *dbRepo) GetUserTicket(ticketID string, userID uint) (*domain.Ticket, error) {
+	var ticket domain.Ticket
+	err := d.db.Preload("Event").
+		Where("id = ? AND holder_user_id = ?", ticketID, userID).
+		First(&ticket).Error
+	return &ticket, err
+}
+
 func (d *dbRepo) GetUserOrders(userID uint) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := d.db.Preload("Tickets.Event").
+	err := d.db.Preload("Tickets.Event").Preload("Items").Preload("Lines").Preload("Refunds.Tickets").
 		Where("user_id = ?", userID).
 		Order("created_at desc").
 		Find(&orders).Error
@@ -31,7 +38,7 @@ func (d *dbRepo) GetUserOrders(userID uint) ([]domain.Order, error) {
 
 func (d *dbRepo) GetOrderWithTickets(orderID string, userID uint) (domain.Order, error) {
 	var order domain.Order
-	err := d.db.Preload("Tickets.Event").
+	err := d.db.Preload("Tickets.Event").Preload("Items").Preload("Lines").Preload("Refunds.Tickets").
 		Where("id = ? AND user_id = ?", orderID, userID).
 		First(&order).Error
 	return order, err
@@ -51,8 +58,22 @@ func (d *dbRepo) Transaction(fn func(domain.TicketRepository) error) error {
 
 func (d *dbRepo) GetOrderById(id string) (*domain.Order, error) {
 	var order domain.Order
-	// 🚀 CRITICAL: We MUST Preload Tickets so FinalizePayment can mark them as sold
-	err := d.db.Preload("Tickets").First(&order, "id = ?", id).Error
+	err := d.db.Preload("Tickets").Preload("Items").Preload("Lines").Preload("Refunds.Tickets").First(&order, "id = ?", id).Error
+	return &order, err
+}
+
+// GetOrderEvents returns every event an order has items for
+func (d *dbRepo) GetOrderEvents(orderID uint) ([]domain.Event, error) {
+	var events []domain.Event
+	err := d.db.Where("id IN (SELECT DISTINCT event_id FROM order_items WHERE order_id = ?)", orderID).
+		Order("id asc").
+		Find(&events).Error
+	return events, err
+}
+
+func (d *dbRepo) GetOrderByBillID(billID string) (*domain.Order, error) {
+	var order domain.Order
+	err := d.db.Preload("Tickets").First(&order, "billplz_id = ?", billID).Error
 	return &order, err
 }
 
@@ -78,13 +99,21 @@ func (d *dbRepo) CleanupExpiredOrders(timeout time.Duration) (int64, error) {
 
 	var totalReleased int64
 	for _, order := range expiredOrders {
-		// 1. Unlink tickets from this order
-		// We set order_id to NULL so they show up in Marketplace again
-		res := d.db.Model(&domain.Ticket{}).Where("order_id = ?", order.ID).Update("order_id", nil)
-		totalReleased += res.RowsAffected
-
-		// 2. Mark order as expired so it doesn't get picked up again
-		d.db.Model(&order).Update("status", "expired")
+		err := d.Transaction(func(txRepo domain.TicketRepository) error {
+			// 1. Mark order as expired, unless a payment finalized it in the meantime
+			won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "expired")
+			if err != nil || !won {
+				return err
+			}
+
+			// 2. held → released, so the stock shows up in Marketplace again
+			released, err := txRepo.ReleaseOrderHolds(order.ID)
+			totalReleased += released
+			return err
+		})
+		if err != nil {
+			return totalReleased, err
+		}
 	}
 
 	return totalReleased, nil
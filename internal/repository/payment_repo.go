@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Returns false (and no error) when an event with the same TransactionID already exists
+func (d *dbRepo) CreatePaymentEvent(event *domain.PaymentEvent) (bool, error) {
+	res := d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "transaction_id"}},
+		DoNothing: true,
+	}).Create(event)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *dbRepo) GetPaymentEvent(transactionID string) (*domain.PaymentEvent, error) {
+	var event domain.PaymentEvent
+	err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&event, "transaction_id = ?", transactionID).Error
+	return &event, err
+}
+
+func (d *dbRepo) UpdatePaymentEvent(event *domain.PaymentEvent) error {
+	return d.db.Save(event).Error
+}
+
+func (d *dbRepo) IncrementPaymentEventDeliveries(id uint) error {
+	return d.db.Model(&domain.PaymentEvent{}).Where("id = ?", id).
+		Update("deliveries", gorm.Expr("deliveries + 1")).Error
+}
+
+// Conditional status change: only succeeds if the order is currently in one of `from`.
+// The returned bool tells the caller whether it won.
+func (d *dbRepo) TransitionOrderStatus(orderID uint, from []string, to string) (bool, error) {
+	res := d.db.Model(&domain.Order{}).
+		Where("id = ? AND status IN ?", orderID, from).
+		Update("status", to)
+	return res.RowsAffected == 1, res.Error
+}
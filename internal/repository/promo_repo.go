@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- PROMO CODES ---
+
+func (d *dbRepo) CreatePromoCode(promo *domain.PromoCode) error {
+	return d.db.Create(promo).Error
+}
+
+// UpdatePromoCode saves the code and replaces its tier scope
+func (d *dbRepo) UpdatePromoCode(promo *domain.PromoCode) error {
+	if err := d.db.Omit("Tiers").Save(promo).Error; err != nil {
+		return err
+	}
+	return d.db.Model(promo).Association("Tiers").Replace(promo.Tiers)
+}
+
+func (d *dbRepo) GetPromoCode(id uint) (*domain.PromoCode, error) {
+	var promo domain.PromoCode
+	err := d.db.Preload("Tiers").First(&promo, id).Error
+	return &promo, err
+}
+
+func (d *dbRepo) ListPromoCodes() ([]domain.PromoCode, error) {
+	var promos []domain.PromoCode
+	err := d.db.Preload("Tiers").Order("created_at desc").Find(&promos).Error
+	return promos, err
+}
+
+// Soft delete: orders keep pointing at the codes they used
+func (d *dbRepo) DeletePromoCode(id uint) error {
+	return d.db.Delete(&domain.PromoCode{}, id).Error
+}
+
+// FOR UPDATE: checkouts using the same code queue up, so usage caps can't be overshot
+func (d *dbRepo) GetPromoCodeForCheckout(code string) (*domain.PromoCode, error) {
+	var promo domain.PromoCode
+	err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", domain.NormalizePromoCode(code)).
+		First(&promo).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := d.db.Model(&promo).Association("Tiers").Find(&promo.Tiers); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// An order redeems its promo code from checkout on, including through refunds. Only
+// orders that never went through give the use back.
+var unredeemedOrderStatuses = []string{"cancelled", "expired"}
+
+// CountPromoRedemptions counts orders that used the code, by everyone when userID is 0
+func (d *dbRepo) CountPromoRedemptions(promoID, userID uint) (int64, error) {
+	var count int64
+	query := d.db.Model(&domain.OrderLine{}).
+		Joins("JOIN orders ON orders.id = order_lines.order_id").
+		Where("order_lines.promo_code_id = ? AND orders.status NOT IN ?", promoID, unredeemedOrderStatuses)
+	if userID != 0 {
+		query = query.Where("orders.user_id = ?", userID)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (d *dbRepo) GetPromoCodeStats(promoID uint) (*domain.PromoCodeStats, error) {
+	var stats domain.PromoCodeStats
+	err := d.db.Model(&domain.OrderLine{}).
+		Select("COUNT(DISTINCT orders.id) AS redemptions, COALESCE(-SUM(order_lines.amount_sen), 0) AS discount_given, "+
+			"COALESCE(SUM(orders.total_amount_sen), 0) AS revenue").
+		Joins("JOIN orders ON orders.id = order_lines.order_id").
+		Where("order_lines.promo_code_id = ? AND orders.status NOT IN ?", promoID, unredeemedOrderStatuses).
+		Scan(&stats).Error
+	return &stats, err
+}
+
+func (d *dbRepo) GetTiersByIDs(ids []uint) ([]domain.TicketTier, error) {
+	var tiers []domain.TicketTier
+	err := d.db.Where("id IN ?", ids).Find(&tiers).Error
+	return tiers, err
+}
+
+func (d *dbRepo) CreateOrderLines(lines []domain.OrderLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	return d.db.Create(&lines).Error
+}
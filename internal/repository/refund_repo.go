@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- REFUND REQUESTS ---
+
+func (d *dbRepo) CreateRefundRequest(req *domain.RefundRequest) error {
+	return d.db.Create(req).Error
+}
+
+func (d *dbRepo) GetRefundRequest(id uint) (*domain.RefundRequest, error) {
+	var req domain.RefundRequest
+	err := d.db.First(&req, id).Error
+	return &req, err
+}
+
+// ListRefundRequests returns the newest first; an empty status returns all of them
+func (d *dbRepo) ListRefundRequests(status string) ([]domain.RefundRequest, error) {
+	var reqs []domain.RefundRequest
+	query := d.db.Order("created_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&reqs).Error
+	return reqs, err
+}
+
+func (d *dbRepo) GetUserRefundRequests(userID uint) ([]domain.RefundRequest, error) {
+	var reqs []domain.RefundRequest
+	err := d.db.Where("user_id = ?", userID).Order("created_at desc").Find(&reqs).Error
+	return reqs, err
+}
+
+// GetOpenRefundRequest finds the request an order in refund_requested is waiting on
+func (d *dbRepo) GetOpenRefundRequest(orderID uint) (*domain.RefundRequest, error) {
+	var req domain.RefundRequest
+	err := d.db.Where("order_id = ? AND status = ?", orderID, domain.RefundRequested).First(&req).Error
+	return &req, err
+}
+
+func (d *dbRepo) UpdateRefundRequest(req *domain.RefundRequest) error {
+	return d.db.Save(req).Error
+}
+
+// --- REFUNDS ---
+
+// CreateRefund saves the refund with its tickets. The unique ticket index makes a
+// second refund of the same ticket fail.
+func (d *dbRepo) CreateRefund(refund *domain.Refund) error {
+	return d.db.Create(refund).Error
+}
+
+// GetProcessingRefund finds the refund an order in refund_processing was left with
+func (d *dbRepo) GetProcessingRefund(orderID uint) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := d.db.Preload("Tickets").
+		Where("order_id = ? AND status = ?", orderID, domain.RefundProcessing).
+		First(&refund).Error
+	return &refund, err
+}
+
+func (d *dbRepo) UpdateRefund(refund *domain.Refund) error {
+	return d.db.Omit(clause.Associations).Save(refund).Error
+}
+
+// AddOrderRefund books a settled refund on the order's running totals
+func (d *dbRepo) AddOrderRefund(orderID uint, amount domain.Money, pointsReversed, pointsRestored int) error {
+	return d.db.Model(&domain.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+		"refunded_amount_sen": gorm.Expr("COALESCE(refunded_amount_sen, 0) + ?", amount),
+		"points_earned":       gorm.Expr("points_earned - ?", pointsReversed),
+		"points_applied":      gorm.Expr("points_applied - ?", pointsRestored),
+	}).Error
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- RESALE LISTINGS ---
+
+// CreateResaleListing saves the listing and retires the seller's QR code for the
+// ticket (see ResaleListing)
+func (d *dbRepo) CreateResaleListing(listing *domain.ResaleListing) error {
+	if err := d.db.Omit("Ticket", "Event").Create(listing).Error; err != nil {
+		return err
+	}
+	return d.db.Model(&domain.Ticket{}).Where("id = ?", listing.TicketID).
+		Update("credential_version", gorm.Expr("credential_version + 1")).Error
+}
+
+func (d *dbRepo) GetResaleListing(id uint) (*domain.ResaleListing, error) {
+	var listing domain.ResaleListing
+	err := d.db.Preload("Event").First(&listing, id).Error
+	return &listing, err
+}
+
+// GetLiveListing finds a ticket's listing that is still for sale or being bought
+func (d *dbRepo) GetLiveListing(ticketID string) (*domain.ResaleListing, error) {
+	var listing domain.ResaleListing
+	err := d.db.Where("ticket_id = ? AND status IN ?", ticketID, []string{domain.ListingActive, domain.ListingReserved}).
+		First(&listing).Error
+	return &listing, err
+}
+
+// ListResaleListings is what fans can buy right now, cheapest first; eventID 0 means every event
+func (d *dbRepo) ListResaleListings(eventID uint) ([]domain.ResaleListing, error) {
+	var listings []domain.ResaleListing
+	query := d.db.Preload("Event").
+		Joins("JOIN events ON events.id = resale_listings.event_id AND events.deleted_at IS NULL").
+		Where("resale_listings.status = ? AND events.resale_enabled = ?", domain.ListingActive, true).
+		Where("events.starts_at IS NULL OR events.starts_at > ?", time.Now()).
+		Order("resale_listings.price_sen asc, resale_listings.id asc")
+	if eventID != 0 {
+		query = query.Where("resale_listings.event_id = ?", eventID)
+	}
+	err := query.Find(&listings).Error
+	return listings, err
+}
+
+func (d *dbRepo) GetUserListings(sellerID uint) ([]domain.ResaleListing, error) {
+	var listings []domain.ResaleListing
+	err := d.db.Preload("Event").Where("seller_id = ?", sellerID).Order("created_at desc").Find(&listings).Error
+	return listings, err
+}
+
+// TransitionListing is the conditional status move every listing change goes through
+func (d *dbRepo) TransitionListing(listingID uint, from, to string) (bool, error) {
+	res := d.db.Model(&domain.ResaleListing{}).
+		Where("id = ? AND status = ?", listingID, from).
+		Update("status", to)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ReserveResaleListing holds an active listing for a buyer's order and adds it to
+// the order as an item. False means someone else got there first.
+func (d *dbRepo) ReserveResaleListing(listingID, orderID, buyerID uint) (*domain.OrderItem, bool, error) {
+	res := d.db.Model(&domain.ResaleListing{}).
+		Where("id = ? AND status = ?", listingID, domain.ListingActive).
+		Updates(map[string]interface{}{"status": domain.ListingReserved, "order_id": orderID, "buyer_id": buyerID})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, false, res.Error
+	}
+
+	var listing domain.ResaleListing
+	if err := d.db.First(&listing, listingID).Error; err != nil {
+		return nil, false, err
+	}
+	tier, err := d.GetTicketTier(listing.EventID, listing.Category)
+	if err != nil {
+		return nil, false, err
+	}
+	item := &domain.OrderItem{
+		OrderID:         orderID,
+		TierID:          tier.ID,
+		EventID:         listing.EventID,
+		Category:        listing.Category,
+		UnitPrice:       listing.Price,
+		Quantity:        1,
+		Status:          domain.HoldHeld,
+		ResaleListingID: &listing.ID,
+	}
+	return item, true, d.db.Create(item).Error
+}
+
+// SellResaleListing completes a paid resale: the ticket moves to the buyer's account
+// and order with a fresh credential version, and the listing is sold. False when the
+// ticket is no longer the seller's to sell (used or refunded meanwhile).
+func (d *dbRepo) SellResaleListing(listing *domain.ResaleListing, orderID, buyerID uint) (bool, error) {
+	res := d.db.Model(&domain.Ticket{}).
+		Where("id = ? AND holder_user_id = ? AND is_sold = ? AND checked_in_at IS NULL", listing.TicketID, listing.SellerID, true).
+		Updates(map[string]interface{}{
+			"holder_user_id":     buyerID,
+			"order_id":           orderID,
+			"credential_version": gorm.Expr("credential_version + 1"),
+		})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	res = d.db.Model(&domain.ResaleListing{}).
+		Where("id = ? AND status = ? AND order_id = ?", listing.ID, domain.ListingReserved, orderID).
+		Updates(map[string]interface{}{"status": domain.ListingSold, "sold_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+// CancelTicketListings takes tickets off resale (e.g. they were refunded). Returns the
+// listings that were live, so reserved ones can have their buyer's order cancelled.
+func (d *dbRepo) CancelTicketListings(ticketIDs []string) ([]domain.ResaleListing, error) {
+	var listings []domain.ResaleListing
+	live := []string{domain.ListingActive, domain.ListingReserved}
+	if err := d.db.Where("ticket_id IN ? AND status IN ?", ticketIDs, live).Find(&listings).Error; err != nil {
+		return nil, err
+	}
+	if len(listings) == 0 {
+		return nil, nil
+	}
+	err := d.db.Model(&domain.ResaleListing{}).
+		Where("ticket_id IN ? AND status IN ?", ticketIDs, live).
+		Update("status", domain.ListingCancelled).Error
+	return listings, err
+}
+
+// CancelEventListings takes every active listing of an event off sale (resale switched off)
+func (d *dbRepo) CancelEventListings(eventID uint) (int64, error) {
+	res := d.db.Model(&domain.ResaleListing{}).
+		Where("event_id = ? AND status = ?", eventID, domain.ListingActive).
+		Update("status", domain.ListingCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// AddOrderResold books a resold ticket's paid share against the seller's original order
+func (d *dbRepo) AddOrderResold(orderID uint, amount domain.Money) error {
+	return d.db.Model(&domain.Order{}).Where("id = ?", orderID).
+		Update("resold_amount_sen", gorm.Expr("COALESCE(resold_amount_sen, 0) + ?", amount)).Error
+}
+
+// --- RESALE PAYOUTS ---
+
+func (d *dbRepo) CreateResalePayout(payout *domain.ResalePayout) error {
+	return d.db.Create(payout).Error
+}
+
+func (d *dbRepo) GetResalePayout(id uint) (*domain.ResalePayout, error) {
+	var payout domain.ResalePayout
+	err := d.db.First(&payout, id).Error
+	return &payout, err
+}
+
+// ListResalePayouts returns the oldest first, so finance works through them in order
+func (d *dbRepo) ListResalePayouts(status string) ([]domain.ResalePayout, error) {
+	var payouts []domain.ResalePayout
+	query := d.db.Order("created_at asc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&payouts).Error
+	return payouts, err
+}
+
+func (d *dbRepo) GetUserPayouts(sellerID uint) ([]domain.ResalePayout, error) {
+	var payouts []domain.ResalePayout
+	err := d.db.Where("seller_id = ?", sellerID).Order("created_at desc").Find(&payouts).Error
+	return payouts, err
+}
+
+func (d *dbRepo) UpdateResalePayout(payout *domain.ResalePayout) error {
+	return d.db.Save(payout).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"log"
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+func (d *dbRepo) GetTicketForUpdate(id string) (*domain.Ticket, error) {
+	var ticket domain.Ticket
+	err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, "id = ?", id).Error
+	return &ticket, err
+}
+
+// RecordCheckIn writes an admission unconditionally. Callers must hold the row lock
+// (GetTicketForUpdate) and have already decided this scan wins.
+func (d *dbRepo) RecordCheckIn(ticketID string, at time.Time, operatorID uint, deviceID, gate string) error {
+	return d.db.Model(&domain.Ticket{}).Where("id = ?", ticketID).
+		Updates(map[string]interface{}{
+			"checked_in_at":     at,
+			"checked_in_by":     operatorID,
+			"checked_in_device": deviceID,
+			"checked_in_gate":   gate,
+		}).Error
+}
+
+// Sold tickets for one event. With a non-zero `since`, every row touched after it
+// whatever its state, so refunds and voids reach the device as revocations.
+func (d *dbRepo) GetScannerManifest(eventID uint, since time.Time) ([]domain.Ticket, error) {
+	var tickets []domain.Ticket
+	query := d.db.Select("id", "category", "status", "checked_in_at", "checked_in_device", "credential_version", "updated_at").
+		Where("event_id = ?", eventID)
+	if since.IsZero() {
+		query = query.Where("is_sold = ? AND status = ?", true, domain.TicketSold)
+	} else {
+		query = query.Where("updated_at > ?", since)
+	}
+	err := query.Order("id asc").Find(&tickets).Error
+	return tickets, err
+}
+
+func (d *dbRepo) SetTicketInside(ticketID string, inside bool) error {
+	return d.db.Model(&domain.Ticket{}).Where("id = ?", ticketID).Update("inside", inside).Error
+}
+
+// Like RecordLog, a failed write here must never block the gate, but it is logged
+func (d *dbRepo) RecordScanEvent(scan *domain.ScanEvent) {
+	if err := d.db.Create(scan).Error; err != nil {
+		log.Printf("could not record %s scan of ticket %q: %v", scan.Result, scan.TicketID, err)
+	}
+}
+
+func (d *dbRepo) GetTicketScans(ticketID string) ([]domain.ScanEvent, error) {
+	var scans []domain.ScanEvent
+	err := d.db.Where("ticket_id = ?", ticketID).Order("scanned_at asc").Find(&scans).Error
+	return scans, err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"sort"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- RESERVED SEATING ---
+
+// CreateVenue saves a venue with its seat maps, sections, rows and seats in one go
+func (d *dbRepo) CreateVenue(venue *domain.Venue) error {
+	return d.db.Create(venue).Error
+}
+
+func (d *dbRepo) GetVenue(id uint) (*domain.Venue, error) {
+	var venue domain.Venue
+	err := d.db.Preload("SeatMaps.Sections.Rows.Seats").First(&venue, id).Error
+	return &venue, err
+}
+
+func (d *dbRepo) GetSeatMap(id uint) (*domain.SeatMap, error) {
+	var seatMap domain.SeatMap
+	err := d.db.Preload("Sections.Rows.Seats").First(&seatMap, id).Error
+	return &seatMap, err
+}
+
+// CreateEventSeats copies every seat of the map into the event as available.
+// Returns how many seats each price zone has, which becomes the zone tier's capacity.
+func (d *dbRepo) CreateEventSeats(eventID, seatMapID uint) (map[string]int, error) {
+	seatMap, err := d.GetSeatMap(seatMapID)
+	if err != nil {
+		return nil, fmt.Errorf("seat map not found")
+	}
+
+	zones := map[string]int{}
+	var seats []domain.EventSeat
+	for _, section := range seatMap.Sections {
+		for _, row := range section.Rows {
+			for _, seat := range row.Seats {
+				seats = append(seats, domain.EventSeat{
+					EventID: eventID,
+					SeatID:  seat.ID,
+					Zone:    seat.Zone,
+					Label:   domain.SeatLabel(section.Name, row.Label, seat.Number),
+					Status:  domain.SeatAvailable,
+				})
+				zones[seat.Zone]++
+			}
+		}
+	}
+	if len(seats) == 0 {
+		return nil, fmt.Errorf("seat map has no seats")
+	}
+	return zones, d.db.CreateInBatches(&seats, 500).Error
+}
+
+// HoldEventSeats holds exactly the requested seats or none of them. The rows are
+// locked in seat order (so two overlapping checkouts queue instead of deadlocking),
+// then moved available → held with a conditional UPDATE. Whoever gets there second
+// sees the seat as held and fails; nothing else is touched. The zone tiers' counters
+// move with the seats through HoldTierStock, one OrderItem per zone.
+func (d *dbRepo) HoldEventSeats(orderID, eventID uint, seatIDs []uint) ([]domain.OrderItem, error) {
+	unique := map[uint]bool{}
+	for _, id := range seatIDs {
+		if unique[id] {
+			return nil, fmt.Errorf("seat %d was requested twice", id)
+		}
+		unique[id] = true
+	}
+
+	var seats []domain.EventSeat
+	if err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND seat_id IN ?", eventID, seatIDs).
+		Order("seat_id asc").
+		Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	if len(seats) != len(seatIDs) {
+		return nil, fmt.Errorf("one or more seats are not part of this event")
+	}
+
+	byZone := map[string][]uint{}
+	for _, seat := range seats {
+		if seat.Status != domain.SeatAvailable {
+			return nil, fmt.Errorf("seat %s is no longer available", seat.Label)
+		}
+		byZone[seat.Zone] = append(byZone[seat.Zone], seat.ID)
+	}
+	zones := make([]string, 0, len(byZone))
+	for zone := range byZone {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	var items []domain.OrderItem
+	for _, zone := range zones {
+		ids := byZone[zone]
+		item, err := d.HoldTierStock(orderID, eventID, zone, len(ids))
+		if err != nil {
+			return nil, err
+		}
+
+		res := d.db.Model(&domain.EventSeat{}).
+			Where("id IN ? AND status = ?", ids, domain.SeatAvailable).
+			Updates(map[string]interface{}{
+				"status":        domain.SeatHeld,
+				"order_id":      orderID,
+				"order_item_id": item.ID,
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected != int64(len(ids)) {
+			return nil, fmt.Errorf("one or more seats were just taken, please pick again")
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+func (d *dbRepo) getItemSeats(itemID uint) ([]domain.EventSeat, error) {
+	var seats []domain.EventSeat
+	err := d.db.Where("order_item_id = ?", itemID).Order("seat_id asc").Find(&seats).Error
+	return seats, err
+}
+
+// setItemSeats moves every seat an order item holds to the given state
+func (d *dbRepo) setItemSeats(itemID uint, status string) error {
+	fields := map[string]interface{}{"status": status}
+	if status == domain.SeatAvailable {
+		fields["order_id"] = nil
+		fields["order_item_id"] = nil
+	}
+	return d.db.Model(&domain.EventSeat{}).Where("order_item_id = ?", itemID).Updates(fields).Error
+}
+
+func (d *dbRepo) GetEventSeat(eventID, seatID uint) (*domain.EventSeat, error) {
+	var seat domain.EventSeat
+	err := d.db.Where("event_id = ? AND seat_id = ?", eventID, seatID).First(&seat).Error
+	return &seat, err
+}
+
+func (d *dbRepo) GetEventSeatAvailability(eventID uint) ([]domain.SeatAvailability, error) {
+	var seats []domain.SeatAvailability
+	err := d.db.Table("event_seats").
+		Select("event_seats.seat_id, seat_sections.name AS section, seat_rows.label AS row_label, seats.number, "+
+			"event_seats.zone, ticket_tiers.price_sen AS price, event_seats.status").
+		Joins("JOIN seats ON seats.id = event_seats.seat_id").
+		Joins("JOIN seat_rows ON seat_rows.id = seats.row_id").
+		Joins("JOIN seat_sections ON seat_sections.id = seat_rows.section_id").
+		Joins("LEFT JOIN ticket_tiers ON ticket_tiers.event_id = event_seats.event_id AND ticket_tiers.category = event_seats.zone").
+		Where("event_seats.event_id = ?", eventID).
+		Order("seat_sections.id, seat_rows.id, seats.id").
+		Scan(&seats).Error
+	return seats, err
+}
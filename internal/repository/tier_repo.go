@@ -0,0 +1,422 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"neptunes-tix/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- TIER INVENTORY ---
+
+func (d *dbRepo) CreateTiers(eventID uint, tiers []domain.TicketTier) error {
+	if len(tiers) == 0 {
+		return nil
+	}
+	rows := make([]domain.TicketTier, len(tiers))
+	for i, tier := range tiers {
+		rows[i] = domain.TicketTier{
+			EventID:  eventID,
+			Category: tier.Category,
+			Price:    tier.Price,
+			Capacity: tier.Quantity,
+			Seated:   tier.Seated,
+
+			SalesStartAt: tier.SalesStartAt,
+			SalesEndAt:   tier.SalesEndAt,
+			MinPerOrder:  tier.MinPerOrder,
+			MaxPerOrder:  tier.MaxPerOrder,
+			MaxPerUser:   tier.MaxPerUser,
+
+			RequiresCode:    tier.RequiresCode,
+			AllowedRoles:    tier.AllowedRoles,
+			MinLoyaltyLevel: tier.MinLoyaltyLevel,
+		}
+	}
+	if err := d.db.Create(&rows).Error; err != nil {
+		return err
+	}
+	return d.db.Model(&domain.Event{}).Where("id = ?", eventID).Update("tier_inventory", true).Error
+}
+
+func (d *dbRepo) GetEventTiers(eventID uint) ([]domain.TicketTier, error) {
+	var tiers []domain.TicketTier
+	err := d.db.Where("event_id = ?", eventID).Order("price_sen asc, id asc").Find(&tiers).Error
+	return tiers, err
+}
+
+func (d *dbRepo) GetTicketTier(eventID uint, category string) (*domain.TicketTier, error) {
+	var tier domain.TicketTier
+	err := d.db.Where("event_id = ? AND category = ?", eventID, category).First(&tier).Error
+	return &tier, err
+}
+
+func (d *dbRepo) AddTierCapacity(eventID uint, category string, quantity int) error {
+	res := d.db.Model(&domain.TicketTier{}).
+		Where("event_id = ? AND category = ?", eventID, category).
+		Update("capacity", gorm.Expr("capacity + ?", quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("category '%s' does not exist", category)
+	}
+	return nil
+}
+
+// DeleteTier only removes a tier nobody has bought or is holding; false otherwise
+func (d *dbRepo) DeleteTier(eventID uint, category string) (bool, error) {
+	res := d.db.Where("event_id = ? AND category = ? AND sold = 0 AND held = 0", eventID, category).
+		Delete(&domain.TicketTier{})
+	return res.RowsAffected == 1, res.Error
+}
+
+// UpdateTierRules replaces a tier's sales window, purchase limits and access
+// restrictions (all of them, so a zero value clears a limit)
+func (d *dbRepo) UpdateTierRules(eventID uint, rules domain.TicketTier) error {
+	roles, err := json.Marshal(rules.AllowedRoles) // map updates skip the field's serializer
+	if err != nil {
+		return err
+	}
+	res := d.db.Model(&domain.TicketTier{}).
+		Where("event_id = ? AND category = ?", eventID, rules.Category).
+		Updates(map[string]interface{}{
+			"sales_start_at": rules.SalesStartAt,
+			"sales_end_at":   rules.SalesEndAt,
+			"min_per_order":  rules.MinPerOrder,
+			"max_per_order":  rules.MaxPerOrder,
+			"max_per_user":   rules.MaxPerUser,
+
+			"requires_code":     rules.RequiresCode,
+			"allowed_roles":     string(roles),
+			"min_loyalty_level": rules.MinLoyaltyLevel,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("category '%s' does not exist", rules.Category)
+	}
+	return nil
+}
+
+// CountUserTierQuantity is how many of a tier the user holds or owns across their
+// other live (pending or paid) orders.
+func (d *dbRepo) CountUserTierQuantity(userID, tierID, excludeOrderID uint) (int, error) {
+	var total int
+	err := d.db.Model(&domain.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.tier_id = ? AND order_items.order_id <> ?", userID, tierID, excludeOrderID).
+		Where("order_items.status IN ? AND orders.status IN ?", []string{domain.HoldHeld, domain.HoldSold}, []string{"pending", "paid", "refund_requested", "partially_refunded"}).
+		Select("COALESCE(SUM(order_items.quantity), 0)").
+		Row().Scan(&total)
+	return total, err
+}
+
+// HoldTierStock is the whole reservation in one conditional UPDATE: it either takes
+// `quantity` seats off the tier's free count or touches nothing. No row per seat is
+// locked, so concurrent checkouts only contend on the tier row for an instant.
+func (d *dbRepo) HoldTierStock(orderID, eventID uint, category string, quantity int) (*domain.OrderItem, error) {
+	tier, err := d.GetTicketTier(eventID, category)
+	if err != nil {
+		return nil, fmt.Errorf("category '%s' does not exist", category)
+	}
+
+	res := d.db.Model(&domain.TicketTier{}).
+		Where("id = ? AND capacity - sold - held >= ?", tier.ID, quantity).
+		Update("held", gorm.Expr("held + ?", quantity))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// Re-read for an accurate number in the error
+		if fresh, err := d.GetTicketTier(eventID, category); err == nil {
+			tier = fresh
+		}
+		return nil, fmt.Errorf("insufficient stock for %s. Requested: %d, Available: %d", category, quantity, tier.Available())
+	}
+
+	item := &domain.OrderItem{
+		OrderID:   orderID,
+		TierID:    tier.ID,
+		EventID:   eventID,
+		Category:  category,
+		UnitPrice: tier.Price,
+		Quantity:  quantity,
+		Status:    domain.HoldHeld,
+	}
+	return item, d.db.Create(item).Error
+}
+
+func (d *dbRepo) GetOrderItems(orderID uint) ([]domain.OrderItem, error) {
+	var items []domain.OrderItem
+	err := d.db.Where("order_id = ?", orderID).Order("id asc").Find(&items).Error
+	return items, err
+}
+
+// claimOrderItem flips one item's status; false means it already moved
+func (d *dbRepo) claimOrderItem(itemID uint, from, to string) (bool, error) {
+	res := d.db.Model(&domain.OrderItem{}).
+		Where("id = ? AND status = ?", itemID, from).
+		Update("status", to)
+	return res.RowsAffected == 1, res.Error
+}
+
+// IssueOrderTickets converts a paid order's holds into sold stock and writes its Ticket
+// rows, held by the buyer
+func (d *dbRepo) IssueOrderTickets(orderID uint) ([]domain.Ticket, error) {
+	items, err := d.GetOrderItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	var order domain.Order
+	if err := d.db.Select("id", "user_id").First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	var issued []domain.Ticket
+	for _, item := range items {
+		won, err := d.claimOrderItem(item.ID, domain.HoldHeld, domain.HoldSold)
+		if err != nil {
+			return nil, err
+		}
+		if !won {
+			continue
+		}
+		if item.ResaleListingID != nil {
+			continue // an existing ticket changes hands instead, see SellResaleListing
+		}
+
+		if err := d.db.Model(&domain.TicketTier{}).Where("id = ?", item.TierID).
+			Updates(map[string]interface{}{
+				"held": gorm.Expr("held - ?", item.Quantity),
+				"sold": gorm.Expr("sold + ?", item.Quantity),
+			}).Error; err != nil {
+			return nil, err
+		}
+
+		// Seated items get one ticket per held seat
+		seats, err := d.getItemSeats(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(seats) > 0 {
+			if err := d.setItemSeats(item.ID, domain.SeatSold); err != nil {
+				return nil, err
+			}
+		}
+
+		tickets := make([]domain.Ticket, item.Quantity)
+		for i := range tickets {
+			tickets[i] = domain.Ticket{
+				EventID:      item.EventID,
+				Category:     item.Category,
+				Price:        item.UnitPrice,
+				IsSold:       true,
+				Status:       domain.TicketSold,
+				OrderID:      &orderID,
+				HolderUserID: &order.UserID,
+			}
+			if i < len(seats) {
+				tickets[i].SeatID = &seats[i].SeatID
+				tickets[i].SeatLabel = seats[i].Label
+			}
+		}
+		if err := d.db.Create(&tickets).Error; err != nil {
+			return nil, err
+		}
+		issued = append(issued, tickets...)
+	}
+	return issued, nil
+}
+
+// ReleaseOrderHolds gives an unpaid order's stock back to its tiers (and resale
+// listings back to the market). Returns seats released.
+func (d *dbRepo) ReleaseOrderHolds(orderID uint) (int64, error) {
+	items, err := d.GetOrderItems(orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	var released int64
+	for _, item := range items {
+		won, err := d.claimOrderItem(item.ID, domain.HoldHeld, domain.HoldReleased)
+		if err != nil {
+			return released, err
+		}
+		if !won {
+			continue
+		}
+		if item.ResaleListingID != nil {
+			// Back on resale for the next fan, unless the seller withdrew it meanwhile
+			if err := d.db.Model(&domain.ResaleListing{}).
+				Where("id = ? AND status = ? AND order_id = ?", *item.ResaleListingID, domain.ListingReserved, orderID).
+				Updates(map[string]interface{}{"status": domain.ListingActive, "order_id": nil, "buyer_id": nil}).Error; err != nil {
+				return released, err
+			}
+			released += int64(item.Quantity)
+			continue
+		}
+		if err := d.db.Model(&domain.TicketTier{}).Where("id = ?", item.TierID).
+			Update("held", gorm.Expr("held - ?", item.Quantity)).Error; err != nil {
+			return released, err
+		}
+		if err := d.setItemSeats(item.ID, domain.SeatAvailable); err != nil {
+			return released, err
+		}
+		released += int64(item.Quantity)
+	}
+	return released, nil
+}
+
+// RefundTickets takes refunded tickets out of circulation: they stop being valid
+// and, with restock, go back on sale (their seats too). Tickets already refunded are
+// skipped. Returns how many tickets were refunded.
+func (d *dbRepo) RefundTickets(orderID uint, ticketIDs []string, restock bool) (int64, error) {
+	var tickets []domain.Ticket
+	if err := d.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND id IN ? AND status <> ?", orderID, ticketIDs, domain.TicketRefunded).
+		Order("id asc").
+		Find(&tickets).Error; err != nil {
+		return 0, err
+	}
+	if len(tickets) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(tickets))
+	for i, t := range tickets {
+		ids[i] = t.ID
+	}
+	if err := d.db.Model(&domain.Ticket{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": domain.TicketRefunded, "is_sold": false}).Error; err != nil {
+		return 0, err
+	}
+	if !restock {
+		return int64(len(tickets)), nil
+	}
+
+	// Only tickets that were still live go back on sale (voided ones belong to a cancelled event)
+	for _, t := range tickets {
+		if t.Status != domain.TicketSold {
+			continue
+		}
+		if err := d.db.Model(&domain.TicketTier{}).
+			Where("event_id = ? AND category = ?", t.EventID, t.Category).
+			Update("sold", gorm.Expr("sold - 1")).Error; err != nil {
+			return 0, err
+		}
+		if t.SeatID != nil {
+			if err := d.db.Model(&domain.EventSeat{}).
+				Where("event_id = ? AND seat_id = ?", t.EventID, *t.SeatID).
+				Updates(map[string]interface{}{"status": domain.SeatAvailable, "order_id": nil, "order_item_id": nil}).Error; err != nil {
+				return 0, err
+			}
+		}
+	}
+	return int64(len(tickets)), nil
+}
+
+func (d *dbRepo) CountAvailableTickets(eventID uint) (int64, error) {
+	var count int64
+	err := d.db.Model(&domain.TicketTier{}).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(SUM(capacity - sold - held), 0)").
+		Row().Scan(&count)
+	return count, err
+}
+
+// MigrateToTierInventory converts events still on the one-row-per-seat model:
+// their rows are counted into TicketTier counters, holds of pending orders become
+// OrderItems, and the unsold rows are deleted. Sold rows stay as the tickets they are.
+// Converted events are marked with TierInventory and never looked at again, even
+// after their last tier is deleted, so this is safe to run on every boot.
+func (d *dbRepo) MigrateToTierInventory() (int, error) {
+	// Events converted before the marker existed: they have tiers, or refunded tickets
+	// (refunds came after tiers) if every ticket was refunded and the tier deleted since
+	err := d.db.Exec(`UPDATE events SET tier_inventory = true WHERE NOT tier_inventory AND (
+		id IN (SELECT event_id FROM ticket_tiers) OR
+		id IN (SELECT event_id FROM tickets WHERE status = ?))`, domain.TicketRefunded).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var eventIDs []uint
+	err = d.db.Model(&domain.Ticket{}).
+		Where("event_id IN (SELECT id FROM events WHERE NOT tier_inventory)").
+		Distinct("event_id").
+		Pluck("event_id", &eventIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, eventID := range eventIDs {
+		err := d.db.Transaction(func(tx *gorm.DB) error {
+			// 1. Counters per category. A category re-stocked at a new price keeps the highest one.
+			var tiers []domain.TicketTier
+			if err := tx.Model(&domain.Ticket{}).
+				Select("event_id, category, MAX(price_sen) AS price_sen, COUNT(*) AS capacity, "+
+					"SUM(CASE WHEN is_sold THEN 1 ELSE 0 END) AS sold, "+
+					"SUM(CASE WHEN NOT is_sold AND order_id IS NOT NULL AND status <> 'voided' THEN 1 ELSE 0 END) AS held").
+				Where("event_id = ?", eventID).
+				Group("event_id, category").
+				Scan(&tiers).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&tiers).Error; err != nil {
+				return err
+			}
+			tierIDs := map[string]uint{}
+			for _, t := range tiers {
+				tierIDs[t.Category] = t.ID
+			}
+
+			// 2. Rows held by pending orders become OrderItems
+			var holds []struct {
+				OrderID  uint
+				Category string
+				Price    domain.Money
+				Quantity int
+			}
+			if err := tx.Model(&domain.Ticket{}).
+				Select("order_id, category, MAX(price_sen) AS price, COUNT(*) AS quantity").
+				Where("event_id = ? AND is_sold = ? AND order_id IS NOT NULL AND status <> ?", eventID, false, domain.TicketVoided).
+				Group("order_id, category").
+				Scan(&holds).Error; err != nil {
+				return err
+			}
+			for _, h := range holds {
+				if err := tx.Create(&domain.OrderItem{
+					OrderID:   h.OrderID,
+					TierID:    tierIDs[h.Category],
+					EventID:   eventID,
+					Category:  h.Category,
+					UnitPrice: h.Price,
+					Quantity:  h.Quantity,
+					Status:    domain.HoldHeld,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			// 3. Unsold rows are now just numbers
+			if err := tx.Unscoped().Where("event_id = ? AND is_sold = ?", eventID, false).
+				Delete(&domain.Ticket{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&domain.Ticket{}).Where("event_id = ? AND status <> ?", eventID, domain.TicketVoided).
+				Update("status", domain.TicketSold).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&domain.Event{}).Where("id = ?", eventID).Update("tier_inventory", true).Error
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("event %d: %w", eventID, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// --- TICKET TRANSFERS ---
+
+func (d *dbRepo) CreateTicketTransfer(transfer *domain.TicketTransfer) error {
+	return d.db.Omit("Ticket").Create(transfer).Error
+}
+
+func (d *dbRepo) GetTicketTransfer(id uint) (*domain.TicketTransfer, error) {
+	var transfer domain.TicketTransfer
+	err := d.db.Preload("Ticket.Event").First(&transfer, id).Error
+	return &transfer, err
+}
+
+// GetPendingTransfer finds the transfer a ticket is waiting on, if any
+func (d *dbRepo) GetPendingTransfer(ticketID string) (*domain.TicketTransfer, error) {
+	var transfer domain.TicketTransfer
+	err := d.db.Where("ticket_id = ? AND status = ?", ticketID, domain.TransferPending).First(&transfer).Error
+	return &transfer, err
+}
+
+func (d *dbRepo) UpdateTicketTransfer(transfer *domain.TicketTransfer) error {
+	return d.db.Omit("Ticket").Save(transfer).Error
+}
+
+// GetUserTransfers is everything a user sent or was sent, newest first
+func (d *dbRepo) GetUserTransfers(userID uint, email string) ([]domain.TicketTransfer, error) {
+	var transfers []domain.TicketTransfer
+	err := d.db.Preload("Ticket.Event").
+		Where("from_user_id = ? OR to_user_id = ? OR (to_email = ? AND status = ?)",
+			userID, userID, strings.ToLower(email), domain.TransferPending).
+		Order("created_at desc").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// GetTicketTransfers is a ticket's transfer history, oldest first
+func (d *dbRepo) GetTicketTransfers(ticketID string) ([]domain.TicketTransfer, error) {
+	var transfers []domain.TicketTransfer
+	err := d.db.Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&transfers).Error
+	return transfers, err
+}
+
+// MoveTicketHolder hands a ticket to its new holder and bumps its credential version,
+// which retires every QR code issued so far. Conditional on the ticket still being
+// the sender's, paid for and unused; false means it no longer is.
+func (d *dbRepo) MoveTicketHolder(ticketID string, fromUserID, toUserID uint) (bool, error) {
+	res := d.db.Model(&domain.Ticket{}).
+		Where("id = ? AND holder_user_id = ? AND is_sold = ? AND checked_in_at IS NULL", ticketID, fromUserID, true).
+		Updates(map[string]interface{}{
+			"holder_user_id":     toUserID,
+			"credential_version": gorm.Expr("credential_version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// BackfillTicketHolders makes the buyer the holder of tickets issued before
+// transfers existed. Safe to run on every boot.
+func (d *dbRepo) BackfillTicketHolders() error {
+	return d.db.Exec(`UPDATE tickets SET holder_user_id = orders.user_id
+		FROM orders WHERE orders.id = tickets.order_id AND tickets.holder_user_id IS NULL`).Error
+}
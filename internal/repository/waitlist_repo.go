@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- WAITLISTS ---
+
+var liveWaitlistStatuses = []string{domain.WaitlistWaiting, domain.WaitlistOffered}
+
+func (d *dbRepo) CreateWaitlistEntry(entry *domain.WaitlistEntry) error {
+	return d.db.Create(entry).Error
+}
+
+// GetLiveWaitlistEntry is the user's place in a tier's queue, if they have one
+func (d *dbRepo) GetLiveWaitlistEntry(tierID, userID uint) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	err := d.db.Where("tier_id = ? AND user_id = ? AND status IN ?", tierID, userID, liveWaitlistStatuses).
+		First(&entry).Error
+	return &entry, err
+}
+
+// GetWaitlistPosition counts the live entries ahead of this one, plus one
+func (d *dbRepo) GetWaitlistPosition(entry *domain.WaitlistEntry) (int, error) {
+	var ahead int64
+	err := d.db.Model(&domain.WaitlistEntry{}).
+		Where("tier_id = ? AND status IN ? AND id < ?", entry.TierID, liveWaitlistStatuses, entry.ID).
+		Count(&ahead).Error
+	return int(ahead) + 1, err
+}
+
+func (d *dbRepo) GetUserWaitlists(userID uint) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := d.db.Where("user_id = ?", userID).Order("created_at desc").Find(&entries).Error
+	return entries, err
+}
+
+// CountWaiting is how many people are still queuing for the tier without an offer
+func (d *dbRepo) CountWaiting(tierID uint) (int64, error) {
+	var count int64
+	err := d.db.Model(&domain.WaitlistEntry{}).
+		Where("tier_id = ? AND status = ?", tierID, domain.WaitlistWaiting).
+		Count(&count).Error
+	return count, err
+}
+
+// GetWaitlistedTiers lists the tiers somebody is waiting for
+func (d *dbRepo) GetWaitlistedTiers() ([]domain.TicketTier, error) {
+	var tiers []domain.TicketTier
+	err := d.db.Where("id IN (?)", d.db.Model(&domain.WaitlistEntry{}).
+		Select("tier_id").Where("status = ?", domain.WaitlistWaiting)).
+		Order("id asc").Find(&tiers).Error
+	return tiers, err
+}
+
+// GetWaitingEntries is the front of a tier's queue, in order
+func (d *dbRepo) GetWaitingEntries(tierID uint, limit int) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := d.db.Where("tier_id = ? AND status = ?", tierID, domain.WaitlistWaiting).
+		Order("id asc").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// OfferWaitlistStock holds `quantity` free tickets of the tier for a waiting entry.
+// Like HoldTierStock it is one conditional UPDATE on the tier; false means the stock
+// or the entry moved on first.
+func (d *dbRepo) OfferWaitlistStock(entry *domain.WaitlistEntry, quantity int, expiresAt time.Time) (bool, error) {
+	res := d.db.Model(&domain.TicketTier{}).
+		Where("id = ? AND capacity - sold - held >= ?", entry.TierID, quantity).
+		Update("held", gorm.Expr("held + ?", quantity))
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	res = d.db.Model(&domain.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entry.ID, domain.WaitlistWaiting).
+		Updates(map[string]interface{}{
+			"status":           domain.WaitlistOffered,
+			"offer_quantity":   quantity,
+			"offer_expires_at": expiresAt,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// The user left in between: give the stock straight back
+		return false, d.db.Model(&domain.TicketTier{}).Where("id = ?", entry.TierID).
+			Update("held", gorm.Expr("held - ?", quantity)).Error
+	}
+	entry.Status = domain.WaitlistOffered
+	entry.OfferQuantity = quantity
+	entry.OfferExpiresAt = &expiresAt
+	return true, nil
+}
+
+// CloseWaitlistEntry ends a live entry (left or expired). An open offer's stock goes
+// back to the tier. False if the entry had already moved on.
+func (d *dbRepo) CloseWaitlistEntry(entry *domain.WaitlistEntry, status string) (bool, error) {
+	res := d.db.Model(&domain.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entry.ID, entry.Status).
+		Update("status", status)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	if entry.Status == domain.WaitlistOffered && entry.OfferQuantity > 0 {
+		if err := d.db.Model(&domain.TicketTier{}).Where("id = ?", entry.TierID).
+			Update("held", gorm.Expr("held - ?", entry.OfferQuantity)).Error; err != nil {
+			return false, err
+		}
+	}
+	entry.Status = status
+	return true, nil
+}
+
+// GetExpiredWaitlistOffers finds offers whose window has closed
+func (d *dbRepo) GetExpiredWaitlistOffers(now time.Time) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := d.db.Where("status = ? AND offer_expires_at < ?", domain.WaitlistOffered, now).
+		Order("id asc").Find(&entries).Error
+	return entries, err
+}
+
+// GetActiveOffer is the user's unexpired offer for a tier, if any
+func (d *dbRepo) GetActiveOffer(userID, tierID uint, now time.Time) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	err := d.db.Where("user_id = ? AND tier_id = ? AND status = ? AND offer_expires_at > ?",
+		userID, tierID, domain.WaitlistOffered, now).First(&entry).Error
+	return &entry, err
+}
+
+// TakeWaitlistOffer turns an offer into the order's hold on `quantity` tickets. The
+// offer already counts in the tier's `held`, so only what the buyer doesn't take is
+// given back.
+func (d *dbRepo) TakeWaitlistOffer(entry *domain.WaitlistEntry, orderID uint, quantity int) (*domain.OrderItem, bool, error) {
+	res := d.db.Model(&domain.WaitlistEntry{}).
+		Where("id = ? AND status = ? AND offer_quantity >= ?", entry.ID, domain.WaitlistOffered, quantity).
+		Updates(map[string]interface{}{"status": domain.WaitlistFulfilled, "order_id": orderID})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, false, res.Error
+	}
+	if unused := entry.OfferQuantity - quantity; unused > 0 {
+		if err := d.db.Model(&domain.TicketTier{}).Where("id = ?", entry.TierID).
+			Update("held", gorm.Expr("held - ?", unused)).Error; err != nil {
+			return nil, false, err
+		}
+	}
+
+	var tier domain.TicketTier
+	if err := d.db.First(&tier, entry.TierID).Error; err != nil {
+		return nil, false, err
+	}
+	item := &domain.OrderItem{
+		OrderID:   orderID,
+		TierID:    tier.ID,
+		EventID:   tier.EventID,
+		Category:  tier.Category,
+		UnitPrice: tier.Price,
+		Quantity:  quantity,
+		Status:    domain.HoldHeld,
+	}
+	return item, true, d.db.Create(item).Error
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"strings"
+	"time"
+)
+
+// --- ACCESS CODES (admin) ---
+
+// CreateAccessCodes issues one named code or a batch of generated ones, all
+// unlocking the same code-only tiers of an event
+func (s *BookingService) CreateAccessCodes(req domain.AccessCodeRequest, actorID uint) ([]domain.AccessCode, error) {
+	values, err := req.Codes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetEventByID(req.EventID); err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	var tiers []domain.TicketTier
+	for _, category := range req.Categories {
+		tier, err := s.repo.GetTicketTier(req.EventID, category)
+		if err != nil {
+			return nil, fmt.Errorf("category '%s' does not exist", category)
+		}
+		if !tier.RequiresCode {
+			return nil, fmt.Errorf("%s is not a code-only tier (set requires_code on it first)", category)
+		}
+		tiers = append(tiers, *tier)
+	}
+
+	codes := make([]domain.AccessCode, len(values))
+	for i, value := range values {
+		codes[i] = domain.AccessCode{
+			Code:      value,
+			EventID:   req.EventID,
+			Tiers:     tiers,
+			MaxUses:   req.MaxUses,
+			Active:    true,
+			ExpiresAt: req.ExpiresAt,
+			Note:      req.Note,
+			CreatedBy: actorID,
+		}
+	}
+	if err := s.repo.CreateAccessCodes(codes); err != nil {
+		return nil, fmt.Errorf("could not save access codes (is the code already taken?)")
+	}
+	s.repo.RecordLog(actorID, "CREATE_ACCESS_CODES", fmt.Sprint(req.EventID), fmt.Sprintf(
+		"%d codes for %s, max uses %d", len(codes), strings.Join(req.Categories, ", "), req.MaxUses))
+	return codes, nil
+}
+
+func (s *BookingService) ListAccessCodes(eventID uint) ([]domain.AccessCode, error) {
+	return s.repo.ListAccessCodes(eventID)
+}
+
+// DeactivateAccessCode stops a code from being redeemed; tiers it already unlocked stay unlocked
+func (s *BookingService) DeactivateAccessCode(id, actorID uint) (*domain.AccessCode, error) {
+	code, err := s.repo.GetAccessCode(id)
+	if err != nil {
+		return nil, fmt.Errorf("access code not found")
+	}
+	if err := s.repo.SetAccessCodeActive(id, false); err != nil {
+		return nil, err
+	}
+	code.Active = false
+	s.repo.RecordLog(actorID, "DEACTIVATE_ACCESS_CODE", fmt.Sprint(id), code.Code)
+	return code, nil
+}
+
+// --- ACCESS CODES (buyers) ---
+
+// RedeemAccessCode unlocks the code's tiers for the user, so they show in the
+// marketplace and can be bought. Redeeming a code again unlocks nothing new and
+// doesn't count as another use. Every attempt is audited, refused ones included.
+func (s *BookingService) RedeemAccessCode(userID uint, value string) ([]domain.TicketTier, error) {
+	var code *domain.AccessCode
+	var refused error
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		var err error
+		if code, err = txRepo.GetAccessCodeForRedeem(value); err != nil {
+			refused = fmt.Errorf("invalid access code")
+			return refused
+		}
+
+		// 1. Already unlocked by this user: nothing to use up
+		tierIDs := make([]uint, len(code.Tiers))
+		for i, tier := range code.Tiers {
+			tierIDs[i] = tier.ID
+		}
+		unlocked, err := txRepo.GetUnlockedTierIDs(userID, tierIDs)
+		if err != nil {
+			return err
+		}
+		if len(unlocked) == len(tierIDs) {
+			return nil
+		}
+
+		// 2. A new redemption (the row lock keeps single-use codes single)
+		if refused = code.Usable(time.Now()); refused != nil {
+			return refused
+		}
+		if err := txRepo.RedeemAccessCode(code, userID, tierIDs); err != nil {
+			return err
+		}
+		txRepo.RecordLog(userID, "ACCESS_CODE_REDEEM", code.Code, fmt.Sprintf(
+			"code #%d, use %d of %s, event #%d", code.ID, code.Uses, maxUsesLabel(code.MaxUses), code.EventID))
+		return nil
+	})
+	if refused != nil && errors.Is(err, refused) {
+		s.repo.RecordLog(userID, "ACCESS_CODE_REJECTED", domain.NormalizeAccessCode(value), refused.Error())
+		return nil, refused
+	}
+	if err != nil {
+		return nil, err
+	}
+	return code.Tiers, nil
+}
+
+func maxUsesLabel(maxUses int) string {
+	if maxUses == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(maxUses)
+}
+
+// checkTierAccess applies the tier's code, role and loyalty restrictions to the user
+func checkTierAccess(txRepo domain.TicketRepository, user *domain.User, tier *domain.TicketTier) error {
+	if !tier.Restricted() {
+		return nil
+	}
+	unlocked := false
+	if tier.RequiresCode {
+		var err error
+		if unlocked, err = txRepo.HasTierUnlock(user.ID, tier.ID); err != nil {
+			return err
+		}
+	}
+	return tier.CheckAccess(user, unlocked)
+}
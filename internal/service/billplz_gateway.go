@@ -0,0 +1,186 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"neptunes-tix/internal/domain"
+)
+
+const (
+	billplzProductionURL = "https://www.billplz.com/api/v3"
+	billplzSandboxURL    = "https://www.billplz-sandbox.com/api/v3"
+)
+
+type BillplzGateway struct {
+	apiKey       string
+	collectionID string
+	signatureKey string
+	baseURL      string // Billplz API root
+	appURL       string // Our public URL, used for callback & redirect
+	client       *http.Client
+}
+
+func NewBillplzGateway(apiKey, collectionID, signatureKey, appURL string, sandbox bool) *BillplzGateway {
+	baseURL := billplzProductionURL
+	if sandbox {
+		baseURL = billplzSandboxURL
+	}
+	return &BillplzGateway{
+		apiKey:       apiKey,
+		collectionID: collectionID,
+		signatureKey: signatureKey,
+		baseURL:      baseURL,
+		appURL:       strings.TrimRight(appURL, "/"),
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *BillplzGateway) CreateBill(order *domain.Order, user *domain.User) (*Bill, error) {
+	form := url.Values{}
+	form.Set("collection_id", g.collectionID)
+	form.Set("email", user.Email)
+	form.Set("name", user.Name)
+	form.Set("amount", fmt.Sprint(order.TotalAmount.Sen)) // Billplz wants sen
+	form.Set("description", fmt.Sprintf("Neptunes Tix Order #%d", order.ID))
+	form.Set("callback_url", g.appURL+"/payments/webhook")
+	form.Set("redirect_url", g.appURL+"/payments/redirect")
+	form.Set("reference_1_label", "Order ID")
+	form.Set("reference_1", fmt.Sprint(order.ID))
+
+	req, err := http.NewRequest(http.MethodPost, g.baseURL+"/bills", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(g.apiKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("billplz unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		ID    string `json:"id"`
+		URL   string `json:"url"`
+		Error *struct {
+			Type    string `json:"type"`
+			Message any    `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("billplz returned an unreadable response (status %d)", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 || body.ID == "" {
+		if body.Error != nil {
+			return nil, fmt.Errorf("billplz rejected bill: %s %v", body.Error.Type, body.Error.Message)
+		}
+		return nil, fmt.Errorf("billplz rejected bill (status %d)", resp.StatusCode)
+	}
+
+	return &Bill{ID: body.ID, URL: body.URL}, nil
+}
+
+func (g *BillplzGateway) VerifyCallback(params url.Values) (*PaymentResult, error) {
+	return verifyBillplzCallback(params, g.signatureKey)
+}
+
+func (g *BillplzGateway) VerifyRedirect(params url.Values) (*PaymentResult, error) {
+	return verifyBillplzRedirect(params, g.signatureKey)
+}
+
+// Billplz v3 has no API to reverse a paid bill; refunds go out through the Billplz
+// dashboard (or a Payment Order to the buyer's bank). We hand back a stable reference
+// for finance to settle against, and the order is left as refund_pending until then.
+func (g *BillplzGateway) Refund(order *domain.Order, refund *domain.Refund) (*RefundResult, error) {
+	if order.BillplzID == "" {
+		return nil, fmt.Errorf("order %d has no Billplz bill to refund", order.ID)
+	}
+	return &RefundResult{
+		Reference: fmt.Sprintf("manual:%s:%d:%d", order.BillplzID, refund.ID, refund.Amount.Sen),
+		Completed: false,
+	}, nil
+}
+
+// --- X-SIGNATURE ---
+
+// billplzSignature follows the Billplz X-Signature spec: every "keyvalue" pair except
+// x_signature, sorted case-insensitively, joined with "|" and HMAC-SHA256'd with the key.
+func billplzSignature(params url.Values, key string) string {
+	var pairs []string
+	for k, vs := range params {
+		if k == "x_signature" {
+			continue
+		}
+		v := ""
+		if len(vs) > 0 {
+			v = vs[0]
+		}
+		pairs = append(pairs, k+v)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return strings.ToLower(pairs[i]) < strings.ToLower(pairs[j])
+	})
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.Join(pairs, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyBillplzCallback(params url.Values, key string) (*PaymentResult, error) {
+	given := params.Get("x_signature")
+	if given == "" || key == "" {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(given), []byte(billplzSignature(params, key))) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &PaymentResult{
+		BillID:        params.Get("id"),
+		TransactionID: params.Get("transaction_id"),
+		Paid:          params.Get("paid") == "true",
+		State:         params.Get("state"),
+		PaidAt:        params.Get("paid_at"),
+	}, nil
+}
+
+// Redirect params arrive as billplz[id], billplz[paid]... and are signed with the brackets stripped.
+func verifyBillplzRedirect(params url.Values, key string) (*PaymentResult, error) {
+	flat := url.Values{}
+	for k, vs := range params {
+		if !strings.HasPrefix(k, "billplz[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(k, "billplz["), "]")
+		if name == "x_signature" {
+			flat["x_signature"] = vs
+			continue
+		}
+		flat["billplz"+name] = vs
+	}
+
+	given := flat.Get("x_signature")
+	if given == "" || key == "" {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(given), []byte(billplzSignature(flat, key))) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &PaymentResult{
+		BillID:        flat.Get("billplzid"),
+		TransactionID: flat.Get("billplztransaction_id"),
+		Paid:          flat.Get("billplzpaid") == "true",
+		PaidAt:        flat.Get("billplzpaid_at"),
+	}, nil
+}
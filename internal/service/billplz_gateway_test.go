@@ -0,0 +1,239 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+const testBillplzKey = "S-test-signature-key"
+
+func hmacHex(source, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(source))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestBillplzSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		params url.Values
+		source string // what Billplz signs, written out by hand
+	}{
+		{
+			name: "sorted key+value pairs",
+			params: url.Values{
+				"paid":   {"true"},
+				"id":     {"W_79pJDk"},
+				"amount": {"200"},
+			},
+			source: "amount200|idW_79pJDk|paidtrue",
+		},
+		{
+			name: "x_signature is left out",
+			params: url.Values{
+				"id":          {"W_79pJDk"},
+				"x_signature": {"whatever"},
+			},
+			source: "idW_79pJDk",
+		},
+		{
+			name: "case-insensitive order",
+			params: url.Values{
+				"b":     {"1"},
+				"State": {"paid"},
+				"a":     {"2"},
+			},
+			source: "a2|b1|Statepaid",
+		},
+		{
+			name: "empty values still count",
+			params: url.Values{
+				"paid_at": {""},
+				"id":      {"W_79pJDk"},
+			},
+			source: "idW_79pJDk|paid_at",
+		},
+		{
+			name: "whole pairs are compared, not just keys",
+			params: url.Values{
+				"paid":    {"true"},
+				"paid_at": {"2026-10-16 10:00:00 +0800"},
+			},
+			source: "paid_at2026-10-16 10:00:00 +0800|paidtrue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := billplzSignature(tt.params, testBillplzKey), hmacHex(tt.source, testBillplzKey); got != want {
+				t.Errorf("billplzSignature() = %s, want HMAC of %q (%s)", got, tt.source, want)
+			}
+		})
+	}
+}
+
+func signedCallback() url.Values {
+	params := url.Values{
+		"id":             {"W_79pJDk"},
+		"transaction_id": {"AC4GC031F42H"},
+		"paid":           {"true"},
+		"state":          {"paid"},
+		"amount":         {"5000"},
+		"paid_at":        {"2026-10-16 10:00:00 +0800"},
+	}
+	params.Set("x_signature", billplzSignature(params, testBillplzKey))
+	return params
+}
+
+func TestVerifyBillplzCallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  func() url.Values
+		key     string
+		wantErr bool
+	}{
+		{name: "valid", params: signedCallback, key: testBillplzKey},
+		{
+			name: "tampered amount",
+			params: func() url.Values {
+				p := signedCallback()
+				p.Set("amount", "1")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{
+			name: "unpaid flipped to paid",
+			params: func() url.Values {
+				p := signedCallback()
+				p.Set("paid", "false")
+				p.Set("x_signature", billplzSignature(p, testBillplzKey))
+				p.Set("paid", "true")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{
+			name: "extra field added",
+			params: func() url.Values {
+				p := signedCallback()
+				p.Set("reference_1", "42")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{
+			name: "missing signature",
+			params: func() url.Values {
+				p := signedCallback()
+				p.Del("x_signature")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{name: "wrong key", params: signedCallback, key: "S-another-key", wantErr: true},
+		{name: "no key configured", params: signedCallback, key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifyBillplzCallback(tt.params(), tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("verifyBillplzCallback() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyBillplzCallback() error = %v", err)
+			}
+			if result.BillID != "W_79pJDk" || result.TransactionID != "AC4GC031F42H" || !result.Paid || result.State != "paid" {
+				t.Errorf("verifyBillplzCallback() = %+v", result)
+			}
+		})
+	}
+}
+
+// Redirect params are signed as "billplz" + name, without the brackets
+func signedRedirect() url.Values {
+	flat := url.Values{
+		"billplzid":      {"W_79pJDk"},
+		"billplzpaid":    {"true"},
+		"billplzpaid_at": {"2026-10-16 10:00:00 +0800"},
+	}
+	return url.Values{
+		"billplz[id]":          flat["billplzid"],
+		"billplz[paid]":        flat["billplzpaid"],
+		"billplz[paid_at]":     flat["billplzpaid_at"],
+		"billplz[x_signature]": {hmacHex("billplzidW_79pJDk|billplzpaid_at2026-10-16 10:00:00 +0800|billplzpaidtrue", testBillplzKey)},
+	}
+}
+
+func TestVerifyBillplzRedirect(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  func() url.Values
+		key     string
+		wantErr bool
+	}{
+		{name: "valid", params: signedRedirect, key: testBillplzKey},
+		{
+			name: "unrelated query params are ignored",
+			params: func() url.Values {
+				p := signedRedirect()
+				p.Set("utm_source", "app")
+				return p
+			},
+			key: testBillplzKey,
+		},
+		{
+			name: "tampered paid flag",
+			params: func() url.Values {
+				p := signedRedirect()
+				p.Set("billplz[paid]", "false")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{
+			name: "callback-style signature field",
+			params: func() url.Values {
+				p := signedRedirect()
+				p.Set("x_signature", p.Get("billplz[x_signature]"))
+				p.Del("billplz[x_signature]")
+				return p
+			},
+			key:     testBillplzKey,
+			wantErr: true,
+		},
+		{name: "wrong key", params: signedRedirect, key: "S-another-key", wantErr: true},
+		{name: "no key configured", params: signedRedirect, key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifyBillplzRedirect(tt.params(), tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("verifyBillplzRedirect() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyBillplzRedirect() error = %v", err)
+			}
+			if result.BillID != "W_79pJDk" || !result.Paid || result.PaidAt != "2026-10-16 10:00:00 +0800" {
+				t.Errorf("verifyBillplzRedirect() = %+v", result)
+			}
+		})
+	}
+}
@@ -3,21 +3,28 @@ package service
 import (
 	"errors"
 	"fmt"
-	"math"
+	"log"
 	"neptunes-tix/internal/domain"
+	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type BookingService struct {
-	repo domain.TicketRepository
+	repo        domain.TicketRepository
+	gateway     PaymentGateway
+	signer      *TicketSigner
+	queue       QueueStore
+	runningJobs sync.Map // job ID → true while a goroutine is working on it
 }
 
-func NewBookingService(repo domain.TicketRepository) *BookingService {
-	return &BookingService{repo: repo}
+func NewBookingService(repo domain.TicketRepository, gateway PaymentGateway, signer *TicketSigner, queue QueueStore) *BookingService {
+	return &BookingService{repo: repo, gateway: gateway, signer: signer, queue: queue}
 }
 
 // 🚀 Defined struct to fix missing type error in parameters
@@ -152,8 +159,10 @@ func (s *BookingService) UpdateEvent(eventID uint, req domain.UpdateEventRequest
 		if req.Venue != "" {
 			event.Venue = req.Venue
 		}
-		if req.Date != "" {
-			event.Date = req.Date
+		if !req.ScheduleInput.Empty() {
+			if err := event.ApplySchedule(req.ScheduleInput); err != nil {
+				return err
+			}
 		}
 		if req.Description != "" {
 			event.Description = req.Description
@@ -161,6 +170,29 @@ func (s *BookingService) UpdateEvent(eventID uint, req domain.UpdateEventRequest
 		if req.LocationURL != "" {
 			event.LocationURL = req.LocationURL
 		}
+		if err := event.ApplyRefundPolicy(req.RefundPolicy, req.RefundCutoffHours); err != nil {
+			return err
+		}
+		if err := event.ApplyTransferCutoff(req.TransferCutoffHours); err != nil {
+			return err
+		}
+		if err := event.ApplyResaleSettings(req.ResaleEnabled, req.ResaleCapPercent); err != nil {
+			return err
+		}
+		if err := event.ApplyQueueSettings(req.QueueEnabled, req.QueueAdmitRate); err != nil {
+			return err
+		}
+		if !event.ResaleEnabled {
+			if _, err := txRepo.CancelEventListings(eventID); err != nil {
+				return err
+			}
+		}
+		if req.EntryPolicy != "" {
+			if !domain.ValidEntryPolicy(req.EntryPolicy) {
+				return fmt.Errorf("unknown entry policy '%s'", req.EntryPolicy)
+			}
+			event.EntryPolicy = req.EntryPolicy
+		}
 
 		if err := txRepo.UpdateEvent(event); err != nil {
 			return err
@@ -168,31 +200,62 @@ func (s *BookingService) UpdateEvent(eventID uint, req domain.UpdateEventRequest
 
 		// 2. Remove Categories (Safety Check First!)
 		for _, category := range req.RemoveTiers {
-			// Check if any tickets are sold for this category
-			soldCount, err := txRepo.CountSoldTickets(eventID, category)
+			tier, err := txRepo.GetTicketTier(eventID, category)
 			if err != nil {
-				return err
+				return fmt.Errorf("category '%s' does not exist", category)
 			}
-			if soldCount > 0 {
-				return fmt.Errorf("cannot remove category '%s': %d tickets already sold", category, soldCount)
+			if tier.Seated {
+				return fmt.Errorf("cannot remove category '%s': it prices a zone of the seat map", category)
+			}
+			if tier.Sold > 0 || tier.Held > 0 {
+				return fmt.Errorf("cannot remove category '%s': %d tickets already sold, %d held", category, tier.Sold, tier.Held)
 			}
 
-			// Safe to delete all tickets for this category
-			if err := txRepo.DeleteTicketsByCategory(eventID, category); err != nil {
+			// Conditional delete, in case a checkout grabbed one in between
+			removed, err := txRepo.DeleteTier(eventID, category)
+			if err != nil {
 				return err
 			}
+			if !removed {
+				return fmt.Errorf("cannot remove category '%s': tickets were just reserved", category)
+			}
 		}
 
 		// 3. Add New Categories
 		if len(req.AddTiers) > 0 {
-			if err := s.generateTickets(txRepo, eventID, req.AddTiers); err != nil {
+			if event.SeatMapID != nil {
+				return fmt.Errorf("seated events take their categories from the seat map zones")
+			}
+			if err := domain.ValidateNewTiers(req.AddTiers, false); err != nil {
+				return err
+			}
+			if err := txRepo.CreateTiers(eventID, req.AddTiers); err != nil {
 				return err
 			}
 		}
 
 		// 4. Add Stock to Existing Categories
-		if len(req.AddStock) > 0 {
-			if err := s.generateTickets(txRepo, eventID, req.AddStock); err != nil {
+		for _, tier := range req.AddStock {
+			if tier.Quantity <= 0 {
+				return fmt.Errorf("tier '%s' needs a quantity above zero", tier.Category)
+			}
+			if existing, err := txRepo.GetTicketTier(eventID, tier.Category); err == nil && existing.Seated {
+				return fmt.Errorf("cannot add stock to '%s': its capacity comes from the seat map", tier.Category)
+			}
+			if err := txRepo.AddTierCapacity(eventID, tier.Category, tier.Quantity); err != nil {
+				return err
+			}
+		}
+
+		// 5. Replace Sales Rules and Access of Existing Categories
+		for _, rules := range req.TierRules {
+			if err := rules.ValidateRules(); err != nil {
+				return err
+			}
+			if err := rules.ValidateAccess(); err != nil {
+				return err
+			}
+			if err := txRepo.UpdateTierRules(eventID, rules); err != nil {
 				return err
 			}
 		}
@@ -201,173 +264,545 @@ func (s *BookingService) UpdateEvent(eventID uint, req domain.UpdateEventRequest
 	})
 }
 
-func (s *BookingService) generateTickets(repo domain.TicketRepository, eventID uint, tiers []domain.TicketTier) error {
-	var tickets []domain.Ticket
-	for _, tier := range tiers {
-		for i := 0; i < tier.Quantity; i++ {
-			tickets = append(tickets, domain.Ticket{
-				EventID:  eventID,
-				Category: tier.Category,
-				Price:    tier.Price,
-				IsSold:   false,
-			})
-		}
+// --- NEW MULTI-TIER CHECKOUT LOGIC ---
+
+// EventSelection is what a checkout takes from one event: general admission by
+// quantity (Items), reserved seats by seat ID (SeatIDs) and fans' resale listings
+// (ListingIDs)
+type EventSelection struct {
+	EventID    uint
+	Items      []CheckoutItem
+	SeatIDs    []uint
+	ListingIDs []uint
+
+	cartID uint // Items and SeatIDs are already held by this cart, see TakeCartHolds
+}
+
+// CreateMultiItemOrder reserves general admission by quantity (items), reserved
+// seats by seat ID (seatIDs) and resale tickets by listing ID; one order can mix them.
+// queueTokens are the buyer's waiting room tickets, for events that have one.
+func (s *BookingService) CreateMultiItemOrder(userID uint, eventID uint, items []CheckoutItem, seatIDs, listingIDs []uint, points int, promoCode string, queueTokens []string) (*domain.Order, error) {
+	selections := []EventSelection{{EventID: eventID, Items: items, SeatIDs: seatIDs, ListingIDs: listingIDs}}
+	var capturedOrder *domain.Order
+	pass := newQueuePass(queueTokens)
+
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		var err error
+		capturedOrder, err = s.placeOrder(txRepo, userID, selections, points, promoCode, pass)
+		return err
+	})
+	if err == nil {
+		err = s.attachBill(capturedOrder)
 	}
-	if len(tickets) > 0 {
-		return repo.CreateTicketBatch(tickets)
+	if err != nil {
+		s.returnQueueAdmissions(pass)
+		return nil, err
 	}
-	return nil
+
+	s.flipSoldOut(selections)
+	return capturedOrder, nil
 }
 
-// --- NEW MULTI-TIER CHECKOUT LOGIC ---
+// placeOrder holds stock for every selection and prices the lot as one order. It must
+// run inside the caller's transaction: any refusal rolls back every hold. The caller
+// opens the bill with attachBill after committing, and gives the waiting room
+// admissions in pass back if either fails.
+func (s *BookingService) placeOrder(txRepo domain.TicketRepository, userID uint, selections []EventSelection, points int, promoCode string, pass *queuePass) (*domain.Order, error) {
+	empty := true
+	for _, sel := range selections {
+		if len(sel.Items) > 0 || len(sel.SeatIDs) > 0 || len(sel.ListingIDs) > 0 {
+			empty = false
+		}
+	}
+	if empty {
+		return nil, fmt.Errorf("nothing to check out")
+	}
 
-func (s *BookingService) CreateMultiItemOrder(userID uint, eventID uint, items []CheckoutItem, points int) (*domain.Order, error) {
-	var capturedOrder *domain.Order
-	var mockURL string
+	// 1. Verify User Points (row locked until commit, see GetUserForUpdate)
+	user, err := txRepo.GetUserForUpdate(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Points < points {
+		return nil, fmt.Errorf("insufficient points for redemption")
+	}
 
-	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
-		// 1. Verify User Points
-		user, err := txRepo.GetUserByID(fmt.Sprint(userID))
+	// Only on_sale events can be bought; the share lock holds off status changes mid-checkout.
+	// Resale has its own rules (see Event.CheckResaleOpen), so a sold out show still trades.
+	// Waitlist offers and cart holds are already the buyer's, so sold out is fine; offers
+	// skip the waiting room too.
+	admissions := map[uint]string{}
+	for _, sel := range selections {
+		event, err := txRepo.GetEventForCheckout(sel.EventID)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("event not found")
+		}
+		if len(sel.Items) > 0 || len(sel.SeatIDs) > 0 {
+			offered := offeredOnly(txRepo, userID, sel)
+			held := offered || sel.cartID != 0
+			if !event.IsPurchasable() && !(held && event.Status == domain.EventSoldOut) {
+				return nil, fmt.Errorf("tickets for %s are not on sale (%s)", event.Name, event.Status)
+			}
+			if !offered {
+				token, err := s.checkQueueAdmission(event, userID, pass.tokens)
+				if err != nil {
+					return nil, err
+				}
+				if token != "" {
+					admissions[event.ID] = token
+				}
+			}
 		}
-		if user.Points < points {
-			return fmt.Errorf("insufficient points for redemption")
+		if len(sel.ListingIDs) > 0 {
+			if err := event.CheckResaleOpen(time.Now()); err != nil {
+				return nil, err
+			}
 		}
+	}
 
-		var totalAmount float64
-		var reservedTickets []domain.Ticket
+	// 2. Create the Main Order first so holds can point at it
+	order := &domain.Order{
+		UserID:        userID,
+		PointsApplied: points,
+		Status:        "pending",
+	}
+	if err := txRepo.CreateOrder(order); err != nil {
+		return nil, err
+	}
 
-		// 2. Validate items, calculate total, and gather tickets
-		for _, item := range items {
-			// Find specific sequential tickets available for this event/category
-			tickets, err := txRepo.GetAvailableSequential(eventID, item.Category, item.Quantity)
+	// 3. Hold stock per item (available → held, or taken over from a waitlist offer or
+	// the cart). No tickets exist yet. They are issued after payment.
+	for _, sel := range selections {
+		if sel.cartID != 0 {
+			held, err := txRepo.TakeCartHolds(sel.cartID, sel.EventID, order.ID)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			if len(tickets) < item.Quantity {
-				return fmt.Errorf("insufficient stock for %s. Requested: %d, Available: %d", item.Category, item.Quantity, len(tickets))
+			order.Items = append(order.Items, held...)
+			continue
+		}
+		for _, item := range sel.Items {
+			held, err := s.holdGeneralAdmission(txRepo, order, sel.EventID, item)
+			if err != nil {
+				return nil, err
 			}
+			order.Items = append(order.Items, *held)
+		}
 
-			// Add price to total and add tickets to reservation list
-			for _, t := range tickets {
-				totalAmount += t.Price
-				reservedTickets = append(reservedTickets, t)
+		// Specific seats: all of them or none (see HoldEventSeats)
+		if len(sel.SeatIDs) > 0 {
+			held, err := txRepo.HoldEventSeats(order.ID, sel.EventID, sel.SeatIDs)
+			if err != nil {
+				return nil, err
 			}
+			order.Items = append(order.Items, held...)
 		}
 
-		// 3. Handle point redemption logic
-		discount := float64(points) / 100.0
-		finalPrice := math.Max(0, totalAmount-discount)
-		pointsToEarn := int(finalPrice * 10) // RM1 = 10 pts
+		// Fans' tickets: each listing is one item, reserved for this order until it's paid
+		for _, listingID := range sel.ListingIDs {
+			item, err := s.reserveListing(txRepo, order, sel.EventID, listingID)
+			if err != nil {
+				return nil, err
+			}
+			order.Items = append(order.Items, *item)
+		}
+	}
+
+	// Access restrictions, sales windows and purchase limits, per tier across the whole order
+	if err := s.checkTierRules(txRepo, user, order); err != nil {
+		return nil, err
+	}
 
-		// 4. Create the Main Order
-		// 🚀 FIX: Removed EventID from struct init to match typical domain.Order schema
-		capturedOrder = &domain.Order{
-			UserID:        userID,
-			TotalAmount:   finalPrice,
-			PointsApplied: points,
-			PointsEarned:  pointsToEarn,
-			Status:        "pending",
+	// 4. Price it: tickets, promo code, booking fees, SST, then points (see domain.PriceOrder)
+	var promo *domain.PromoCode
+	promoDiscount := domain.Sen(0)
+	if promoCode != "" {
+		if promo, promoDiscount, err = s.checkPromoCode(txRepo, userID, order, promoCode); err != nil {
+			return nil, err
 		}
+	}
+	var policies []domain.ChargePolicy
+	for _, sel := range selections {
+		eventPolicies, err := txRepo.GetChargePolicies(sel.EventID)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, eventPolicies...)
+	}
+	lines, total := domain.PriceOrder(order.Items, promo, promoDiscount, policies, points)
+	for i := range lines {
+		lines[i].OrderID = order.ID
+	}
+	if err := txRepo.CreateOrderLines(lines); err != nil {
+		return nil, err
+	}
+	order.Lines = lines
+	order.TotalAmount = total
+	order.PointsEarned = int(order.TotalAmount.Sen / 10) // RM1 = 10 pts
+
+	// Only the points the discount could use are spent (it never goes below zero)
+	order.PointsApplied = 0
+	for _, line := range lines {
+		if line.Kind == domain.LineDiscount && line.Source == domain.DiscountPoints {
+			order.PointsApplied = int(line.Amount.Neg().Sen)
+		}
+	}
+
+	if err := txRepo.UpdateOrderFields(order.ID, map[string]interface{}{
+		"total_amount_sen": order.TotalAmount,
+		"points_earned":    order.PointsEarned,
+		"points_applied":   order.PointsApplied,
+	}); err != nil {
+		return nil, err
+	}
+
+	// 5. The order stands: spend the waiting room admissions it came in on
+	if err := s.useQueueAdmissions(pass, admissions); err != nil {
+		return nil, err
+	}
 
-		if err := txRepo.CreateOrder(capturedOrder); err != nil {
+	// 6. The bill is opened by the caller once this commits (see attachBill)
+	return order, nil
+}
+
+// attachBill opens the payment gateway bill for an order placeOrder just committed.
+// It runs outside the checkout transaction so the HTTP call to the gateway holds no
+// row locks. If the gateway refuses, the order is cancelled and its holds released.
+func (s *BookingService) attachBill(order *domain.Order) error {
+	user, err := s.repo.GetUserByID(fmt.Sprint(order.UserID))
+	var bill *Bill
+	if err == nil {
+		bill, err = s.gateway.CreateBill(order, user)
+	}
+	if err == nil {
+		order.BillplzID = bill.ID
+		order.PaymentURL = bill.URL
+		return s.repo.UpdateOrderFields(order.ID, map[string]interface{}{
+			"billplz_id":  bill.ID,
+			"payment_url": bill.URL,
+		})
+	}
+
+	billErr := err
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "cancelled")
+		if err != nil || !won {
 			return err
 		}
+		released, err := txRepo.ReleaseOrderHolds(order.ID)
+		if err != nil {
+			return err
+		}
+		txRepo.RecordLog(order.UserID, "ORDER_CANCEL", fmt.Sprint(order.ID), fmt.Sprintf("payment gateway refused the bill (%v), %d tickets released", billErr, released))
+		return nil
+	})
+	if err != nil {
+		// Left pending, the expiry sweep cancels it later
+		log.Printf("order %d: could not cancel after the gateway refused the bill: %v", order.ID, err)
+	}
+	s.OfferWaitlists()
+	return fmt.Errorf("could not open the payment, please try again: %w", billErr)
+}
 
-		// 5. Link Tickets to Order (This replaces UpdateStock and CreateOrderItem)
-		// Since we fetched physical ticket rows in Step 2, we just update their OrderID
-		for i := range reservedTickets {
-			reservedTickets[i].OrderID = &capturedOrder.ID
-			// Do NOT mark IsSold yet. That happens after payment.
+// flipSoldOut moves events whose last tickets were just reserved to sold_out
+// (outside the checkout's share lock)
+func (s *BookingService) flipSoldOut(selections []EventSelection) {
+	for _, sel := range selections {
+		if left, err := s.repo.CountAvailableTickets(sel.EventID); err == nil && left == 0 {
+			if won, _ := s.repo.TransitionEventStatus(sel.EventID, domain.EventOnSale, domain.EventSoldOut); won {
+				s.repo.RecordLog(0, "EVENT_STATUS", fmt.Sprint(sel.EventID), "on_sale → sold_out: last ticket reserved")
+			}
 		}
-		if err := txRepo.UpdateTicketBatch(reservedTickets); err != nil {
+	}
+}
+
+// TransitionEvent moves an event through its lifecycle (see domain/event_status.go)
+func (s *BookingService) TransitionEvent(eventID uint, to string, actorID uint, reason string) (*domain.Event, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	if err := event.CanTransitionTo(to); err != nil {
+		return nil, err
+	}
+
+	from := event.Status
+	won, err := s.repo.TransitionEventStatus(eventID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		return nil, fmt.Errorf("event status changed in the meantime, please reload")
+	}
+
+	details := fmt.Sprintf("%s → %s", from, to)
+	if reason != "" {
+		details += ": " + reason
+	}
+	s.repo.RecordLog(actorID, "EVENT_STATUS", fmt.Sprint(eventID), details)
+
+	event.Status = to
+	return event, nil
+}
+
+// --- PAYMENT GATEWAY CALLBACKS ---
+
+// HandlePaymentCallback processes the server-to-server callback. The signature is
+// checked before we even look the order up. Replays return the original event.
+func (s *BookingService) HandlePaymentCallback(params url.Values) (*domain.PaymentEvent, error) {
+	result, err := s.gateway.VerifyCallback(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.processPaymentEvent("callback", params, result)
+}
+
+// HandlePaymentRedirect processes the buyer's browser redirect. It usually shares its
+// event key with the callback, so whichever arrives second is treated as a duplicate.
+func (s *BookingService) HandlePaymentRedirect(params url.Values) (*domain.Order, error) {
+	result, err := s.gateway.VerifyRedirect(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.processPaymentEvent("redirect", params, result); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderByBillID(result.BillID)
+}
+
+func (s *BookingService) processPaymentEvent(source string, params url.Values, result *PaymentResult) (*domain.PaymentEvent, error) {
+	event := &domain.PaymentEvent{
+		TransactionID: result.EventKey(),
+		BillID:        result.BillID,
+		Source:        source,
+		Paid:          result.Paid,
+		State:         result.State,
+		PaidAt:        result.PaidAt,
+		Payload:       params.Encode(),
+	}
+
+	var processErr error
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		// 1. Claim the event key. The unique index makes concurrent duplicates wait here.
+		created, err := txRepo.CreatePaymentEvent(event)
+		if err != nil {
 			return err
 		}
+		if !created {
+			existing, err := txRepo.GetPaymentEvent(event.TransactionID)
+			if err != nil {
+				return err
+			}
+			if err := txRepo.IncrementPaymentEventDeliveries(existing.ID); err != nil {
+				return err
+			}
+			existing.Deliveries++
+			event = existing
+			// A previous attempt failed, so this delivery is our retry
+			if existing.Result != domain.PaymentResultFailed {
+				return nil
+			}
+		}
 
-		// 6. Generate Payment URL and attach to Order
-		mockURL = fmt.Sprintf("%s/mock-billplz/%d", os.Getenv("TEMP_URL"), capturedOrder.ID)
+		// 2. Reconcile against the order inside a savepoint, so a failure still leaves the event logged
+		processErr = txRepo.Transaction(func(innerRepo domain.TicketRepository) error {
+			order, err := innerRepo.GetOrderByBillID(result.BillID)
+			if err != nil {
+				return fmt.Errorf("no order for bill %s", result.BillID)
+			}
+			event.OrderID = &order.ID
 
-		return txRepo.UpdateOrderFields(capturedOrder.ID, map[string]interface{}{
-			"payment_url": mockURL,
+			outcome, err := s.reconcilePayment(innerRepo, order, result)
+			if err != nil {
+				return err
+			}
+			event.Result = outcome
+			event.Error = ""
+			return nil
 		})
+		if processErr != nil {
+			event.Result = domain.PaymentResultFailed
+			event.Error = processErr.Error()
+		}
+
+		return txRepo.UpdatePaymentEvent(event)
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	if processErr != nil {
+		// Surface it so the gateway retries; the retry picks up the failed event above
+		return event, processErr
+	}
+	return event, nil
+}
 
-	// Update local struct for response
-	capturedOrder.PaymentURL = mockURL
-	return capturedOrder, nil
+// reconcilePayment decides what a verified gateway result means for the order's current state.
+// Every combination is handled explicitly, including ones that arrive out of order.
+func (s *BookingService) reconcilePayment(txRepo domain.TicketRepository, order *domain.Order, result *PaymentResult) (string, error) {
+	switch {
+	case result.Paid && order.Status == "pending":
+		return s.finalizeOrder(txRepo, order)
+
+	case result.Paid && order.Status != "expired" && order.Status != "cancelled":
+		// Finalized before (it may have moved on to refunds since)
+		return domain.PaymentResultAlreadyPaid, nil
+
+	case result.Paid:
+		// Money arrived for an order we already gave up on (expired/cancelled).
+		// Its tickets are back in the pool, so flag it for a refund instead of dropping it.
+		if _, err := txRepo.TransitionOrderStatus(order.ID, []string{order.Status}, "refund_due"); err != nil {
+			return "", err
+		}
+		txRepo.RecordLog(order.UserID, "LATE_PAYMENT", fmt.Sprint(order.ID),
+			fmt.Sprintf("Bill %s paid after order was %s; refund required", result.BillID, order.Status))
+		return domain.PaymentResultRefundDue, nil
+
+	case order.Status == "pending":
+		// Unpaid/failed attempt: keep holding until the cleanup sweep expires it
+		return domain.PaymentResultUnpaid, nil
+
+	default:
+		// An unpaid result never downgrades an order that has moved on
+		return domain.PaymentResultStale, nil
+	}
 }
 
 func (s *BookingService) FinalizePayment(orderID string) error {
 	return s.repo.Transaction(func(txRepo domain.TicketRepository) error {
 		order, err := txRepo.GetOrderById(orderID)
-		if err != nil || order.Status != "pending" {
-			return errors.New("order not found or not pending")
-		}
-
-		// 1. Mark Order Paid
-		err = txRepo.UpdateOrderFields(order.ID, map[string]interface{}{
-			"status": "paid",
-		})
 		if err != nil {
-			return err
+			return errors.New("order not found")
 		}
+		_, err = s.finalizeOrder(txRepo, order)
+		return err
+	})
+}
 
-		// 2. Mark Tickets Sold
-		for i := range order.Tickets {
-			order.Tickets[i].IsSold = true
-		}
-		if err := txRepo.UpdateTicketBatch(order.Tickets); err != nil {
-			return err
+// finalizeOrder settles a paid pending order and returns the payment result to log.
+func (s *BookingService) finalizeOrder(txRepo domain.TicketRepository, order *domain.Order) (string, error) {
+	// 0. An event on the order was cancelled while the buyer was paying: issue nothing
+	events, err := txRepo.GetOrderEvents(order.ID)
+	if err != nil {
+		return "", err
+	}
+	for i := range events {
+		if events[i].Status == domain.EventCancelled {
+			return s.holdPaymentForRefund(txRepo, order, &events[i])
 		}
+	}
 
-		// 3. Handle Points (Deduct spent, Award earned)
-		if order.PointsApplied > 0 {
-			txRepo.IncrementUserPoints(order.UserID, -order.PointsApplied, "Used points for discount", &order.ID)
-		}
-		txRepo.IncrementUserPoints(order.UserID, order.PointsEarned, "Earned from purchase", &order.ID)
+	// 1. Mark Order Paid (conditional, so two concurrent finalizers can't both win)
+	won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "paid")
+	if err != nil {
+		return "", err
+	}
+	if !won {
+		return "", errors.New("order not pending")
+	}
+	order.Status = "paid"
 
-		return nil
-	})
+	// 2. Issue the Tickets (held → sold); resale tickets change hands instead
+	tickets, err := txRepo.IssueOrderTickets(order.ID)
+	if err != nil {
+		return "", err
+	}
+	order.Tickets = tickets
+	if err := s.completeResales(txRepo, order); err != nil {
+		return "", err
+	}
+
+	// 3. Handle Points (Deduct spent, Award earned), net of undelivered resale refunds
+	if order.PointsApplied > 0 {
+		if err := txRepo.IncrementUserPoints(order.UserID, -order.PointsApplied, "Used points for discount", &order.ID); err != nil {
+			return "", err
+		}
+	}
+	if err := txRepo.IncrementUserPoints(order.UserID, order.PointsEarned, "Earned from purchase", &order.ID); err != nil {
+		return "", err
+	}
+	return domain.PaymentResultFinalized, nil
 }
 
-func (s *BookingService) CheckInTicket(ticketID string, expectedEventID uint) (*domain.Ticket, error) {
-	ticket, err := s.repo.GetByID(ticketID)
+// holdPaymentForRefund handles money for a pending order whose event has been cancelled.
+// The holds go back and the order is flagged refund_due, the same as a late payment.
+func (s *BookingService) holdPaymentForRefund(txRepo domain.TicketRepository, order *domain.Order, event *domain.Event) (string, error) {
+	won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "refund_due")
 	if err != nil {
-		return nil, fmt.Errorf("ticket not found")
+		return "", err
 	}
+	if !won {
+		return "", errors.New("order not pending")
+	}
+	order.Status = "refund_due"
 
-	// 1. 🔒 Security Check: Wrong Event
-	// This prevents a ticket for "Event A" being scanned at "Event B"
-	if ticket.EventID != expectedEventID {
-		return nil, fmt.Errorf("WRONG EVENT: This ticket is for '%s'", ticket.Event.Name)
+	if _, err := txRepo.ReleaseOrderHolds(order.ID); err != nil {
+		return "", err
 	}
+	txRepo.RecordLog(order.UserID, "LATE_PAYMENT", fmt.Sprint(order.ID),
+		fmt.Sprintf("Order paid after %s was cancelled; refund required", event.Name))
+	s.notify(txRepo, order.UserID, "Event cancelled", fmt.Sprintf(
+		"%s has been cancelled. Your payment for order #%d will be refunded and no tickets were issued.", event.Name, order.ID))
+	return domain.PaymentResultRefundDue, nil
+}
 
-	// 2. 🔒 Security Check: Unpaid Ticket
+// IssueTicketCredential returns the signed QR payload for a paid ticket the user owns.
+func (s *BookingService) IssueTicketCredential(userID uint, ticketID string) (string, error) {
+	if _, err := uuid.Parse(ticketID); err != nil {
+		return "", fmt.Errorf("ticket not found")
+	}
+	ticket, err := s.repo.GetUserTicket(ticketID, userID)
+	if err != nil {
+		return "", fmt.Errorf("ticket not found")
+	}
 	if !ticket.IsSold {
-		return nil, fmt.Errorf("INVALID: This ticket has not been paid for.")
+		return "", fmt.Errorf("ticket has not been paid for")
 	}
+	if _, err := s.repo.GetLiveListing(ticket.ID); err == nil {
+		return "", fmt.Errorf("ticket is listed for resale, cancel the listing to use it")
+	}
+	return s.signer.Issue(ticket)
+}
 
-	// 3. 🔒 Security Check: Already Used
-	if ticket.CheckedInAt != nil {
-		// Calculate nice duration string (e.g., "5 mins ago")
-		duration := time.Since(*ticket.CheckedInAt).Round(time.Minute)
-		return nil, fmt.Errorf("ALREADY USED: Scanned %s ago at %s",
-			duration,
-			ticket.CheckedInAt.Format("3:04 PM"))
+// checkTierRules applies each tier's access restrictions, sales window and limits to
+// everything the order holds of it (items for the same tier are added up first).
+func (s *BookingService) checkTierRules(txRepo domain.TicketRepository, user *domain.User, order *domain.Order) error {
+	type tierKey struct {
+		eventID  uint
+		category string
+	}
+	quantities := map[tierKey]int{}
+	var keys []tierKey
+	for _, item := range order.Items {
+		if item.ResaleListingID != nil {
+			continue // resale isn't tier stock: windows and limits don't apply
+		}
+		key := tierKey{item.EventID, item.Category}
+		if _, seen := quantities[key]; !seen {
+			keys = append(keys, key)
+		}
+		quantities[key] += item.Quantity
 	}
 
-	// 4. Success: Mark it
 	now := time.Now()
-	ticket.CheckedInAt = &now
-
-	if err := s.repo.UpdateTicket(ticket); err != nil {
-		return nil, err
+	for _, key := range keys {
+		tier, err := txRepo.GetTicketTier(key.eventID, key.category)
+		if err != nil {
+			return err
+		}
+		if err := checkTierAccess(txRepo, user, tier); err != nil {
+			return err
+		}
+		owned := 0
+		if tier.MaxPerUser > 0 {
+			if owned, err = txRepo.CountUserTierQuantity(user.ID, tier.ID, order.ID); err != nil {
+				return err
+			}
+		}
+		if err := tier.CheckPurchase(quantities[key], owned, now); err != nil {
+			return err
+		}
 	}
-
-	return ticket, nil
+	return nil
 }
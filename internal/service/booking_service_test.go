@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+)
+
+// More buyers than tickets, all checking out at once: the tier sells exactly its
+// capacity, and once paid every ticket belongs to one order only.
+func TestCheckoutNeverSellsATicketTwice(t *testing.T) {
+	db, repo := openTestDB(t)
+	svc, _ := newTestService(t, repo)
+
+	const capacity, buyers = 5, 30
+	event := newTestEvent(t, db, repo, capacity)
+	users := make([]*domain.User, buyers)
+	for i := range users {
+		users[i] = newTestUser(t, repo)
+	}
+
+	start := make(chan struct{})
+	orders := make([]*domain.Order, buyers)
+	errs := make([]error, buyers)
+	var wg sync.WaitGroup
+	for i := range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			items := []service.CheckoutItem{{Category: "GA", Quantity: 1}}
+			orders[i], errs[i] = svc.CreateMultiItemOrder(users[i].ID, event.ID, items, nil, nil, 0, "", nil)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// 1. Exactly capacity holds were handed out
+	var placed []*domain.Order
+	for i, order := range orders {
+		if errs[i] == nil {
+			placed = append(placed, order)
+		}
+	}
+	if len(placed) != capacity {
+		t.Fatalf("%d of %d checkouts got tickets from a tier of %d (errors: %v)", len(placed), buyers, capacity, errs)
+	}
+	tier, err := repo.GetTicketTier(event.ID, "GA")
+	if err != nil {
+		t.Fatalf("load tier: %v", err)
+	}
+	if tier.Held != capacity || tier.Sold != 0 {
+		t.Fatalf("tier after checkout: held %d, sold %d; want held %d, sold 0", tier.Held, tier.Sold, capacity)
+	}
+
+	// 2. Pay for all of them at once
+	payErrs := make([]error, len(placed))
+	for i, order := range placed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payErrs[i] = svc.FinalizePayment(fmt.Sprint(order.ID))
+		}()
+	}
+	wg.Wait()
+	for i, err := range payErrs {
+		if err != nil {
+			t.Fatalf("paying order #%d: %v", placed[i].ID, err)
+		}
+	}
+
+	// 3. One ticket per order, none shared
+	var tickets []domain.Ticket
+	if err := db.Where("event_id = ?", event.ID).Find(&tickets).Error; err != nil {
+		t.Fatalf("load tickets: %v", err)
+	}
+	if len(tickets) != capacity {
+		t.Fatalf("%d tickets issued for a tier of %d", len(tickets), capacity)
+	}
+	perOrder := map[uint]int{}
+	for _, ticket := range tickets {
+		if ticket.OrderID == nil {
+			t.Fatalf("ticket %s has no order", ticket.ID)
+		}
+		perOrder[*ticket.OrderID]++
+	}
+	for _, order := range placed {
+		if perOrder[order.ID] != 1 {
+			t.Errorf("order #%d has %d tickets, want 1", order.ID, perOrder[order.ID])
+		}
+	}
+
+	tier, err = repo.GetTicketTier(event.ID, "GA")
+	if err != nil {
+		t.Fatalf("reload tier: %v", err)
+	}
+	if tier.Held != 0 || tier.Sold != capacity {
+		t.Fatalf("tier after payment: held %d, sold %d; want held 0, sold %d", tier.Held, tier.Sold, capacity)
+	}
+}
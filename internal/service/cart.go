@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// --- SHOPPING CART ---
+// What goes in the cart is held for the user until its timer runs out (see
+// domain.CartHoldTime), and checkout hands those holds to the order. Checkout is
+// still the same placeOrder as a single-event checkout, so every rule there applies;
+// the rule checks here are early warnings so the user doesn't find out at the end.
+
+func (s *BookingService) GetCart(userID uint) (*domain.Cart, error) {
+	var cart *domain.Cart
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		var err error
+		if cart, err = s.openCart(txRepo, userID); err != nil {
+			return err
+		}
+		return s.priceCart(txRepo, cart)
+	})
+	return cart, err
+}
+
+// AddToCart adds a quantity of a general admission tier (merged with what the cart
+// already has of it) or a single reserved seat. Holding stock is as good as buying
+// it, so an event with a waiting room needs an admitted queue ticket here too.
+func (s *BookingService) AddToCart(userID uint, req domain.AddCartItemRequest, queueTokens []string) (*domain.Cart, error) {
+	return s.editCart(userID, func(txRepo domain.TicketRepository, cart *domain.Cart) error {
+		event, err := txRepo.GetEventByID(req.EventID)
+		if err != nil {
+			return fmt.Errorf("event not found")
+		}
+		if !event.IsPurchasable() {
+			return fmt.Errorf("tickets for %s are not on sale (%s)", event.Name, event.Status)
+		}
+		if _, err := s.checkQueueAdmission(event, userID, queueTokens); err != nil {
+			return err
+		}
+
+		// 1. A reserved seat: one per cart item
+		if req.SeatID != nil {
+			seat, err := txRepo.GetEventSeat(req.EventID, *req.SeatID)
+			if err != nil {
+				return fmt.Errorf("seat not found")
+			}
+			if seat.Status != domain.SeatAvailable {
+				return fmt.Errorf("seat %s is no longer available", seat.Label)
+			}
+			for _, item := range cart.Items {
+				if item.SeatID != nil && *item.SeatID == seat.SeatID && item.EventID == req.EventID {
+					return fmt.Errorf("seat %s is already in your cart", seat.Label)
+				}
+			}
+			if err := s.checkCartTier(txRepo, cart, req.EventID, seat.Zone, 1, true); err != nil {
+				return err
+			}
+			return txRepo.AddCartItem(&domain.CartItem{
+				CartID:    cart.ID,
+				EventID:   req.EventID,
+				Category:  seat.Zone,
+				Quantity:  1,
+				SeatID:    &seat.SeatID,
+				SeatLabel: seat.Label,
+			})
+		}
+
+		// 2. General admission by quantity
+		if req.Category == "" || req.Quantity < 1 {
+			return fmt.Errorf("pick a category and a quantity of at least 1, or a seat")
+		}
+		if err := s.checkCartTier(txRepo, cart, req.EventID, req.Category, req.Quantity, false); err != nil {
+			return err
+		}
+		for _, item := range cart.Items {
+			if item.SeatID == nil && item.EventID == req.EventID && item.Category == req.Category {
+				_, err := txRepo.UpdateCartItemQuantity(cart.ID, item.ID, item.Quantity+req.Quantity)
+				return err
+			}
+		}
+		return txRepo.AddCartItem(&domain.CartItem{
+			CartID:   cart.ID,
+			EventID:  req.EventID,
+			Category: req.Category,
+			Quantity: req.Quantity,
+		})
+	})
+}
+
+// UpdateCartItem sets a general admission item's quantity. Seats can only be removed.
+// Like AddToCart, holding more of a queued event needs an admitted queue ticket.
+func (s *BookingService) UpdateCartItem(userID, itemID uint, quantity int, queueTokens []string) (*domain.Cart, error) {
+	if quantity < 1 {
+		return nil, fmt.Errorf("quantity must be at least 1, remove the item instead")
+	}
+	return s.editCart(userID, func(txRepo domain.TicketRepository, cart *domain.Cart) error {
+		for _, item := range cart.Items {
+			if item.ID != itemID {
+				continue
+			}
+			if item.SeatID != nil {
+				return fmt.Errorf("a reserved seat can only be removed")
+			}
+			if quantity > item.Quantity {
+				event, err := txRepo.GetEventByID(item.EventID)
+				if err != nil {
+					return fmt.Errorf("event not found")
+				}
+				if _, err := s.checkQueueAdmission(event, userID, queueTokens); err != nil {
+					return err
+				}
+			}
+			if err := s.checkCartTier(txRepo, cart, item.EventID, item.Category, quantity-item.Quantity, false); err != nil {
+				return err
+			}
+			_, err := txRepo.UpdateCartItemQuantity(cart.ID, item.ID, quantity)
+			return err
+		}
+		return fmt.Errorf("item not found in your cart")
+	})
+}
+
+func (s *BookingService) RemoveCartItem(userID, itemID uint) (*domain.Cart, error) {
+	return s.editCart(userID, func(txRepo domain.TicketRepository, cart *domain.Cart) error {
+		removed, err := txRepo.DeleteCartItem(cart.ID, itemID)
+		if err == nil && !removed {
+			return fmt.Errorf("item not found in your cart")
+		}
+		return err
+	})
+}
+
+func (s *BookingService) ClearCart(userID uint) error {
+	return s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		cart, err := txRepo.GetOrCreateCart(userID)
+		if err != nil {
+			return err
+		}
+		return txRepo.ClearCart(cart.ID)
+	})
+}
+
+// CheckoutCart turns the whole cart into one order, across however many events it
+// covers. The cart's holds become the order's, and pricing and emptying the cart
+// share that transaction; the bill is opened once it has committed.
+func (s *BookingService) CheckoutCart(userID uint, points int, promoCode string, queueTokens []string) (*domain.Order, error) {
+	var order *domain.Order
+	var selections []EventSelection
+	pass := newQueuePass(queueTokens)
+
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		cart, err := s.openCart(txRepo, userID)
+		if err != nil {
+			return err
+		}
+		if len(cart.Items) == 0 {
+			return fmt.Errorf("your cart is empty")
+		}
+
+		// One selection per event, in the order they were added
+		index := map[uint]int{}
+		for _, item := range cart.Items {
+			i, seen := index[item.EventID]
+			if !seen {
+				i = len(selections)
+				index[item.EventID] = i
+				selections = append(selections, EventSelection{EventID: item.EventID, cartID: cart.ID})
+			}
+			if item.SeatID != nil {
+				selections[i].SeatIDs = append(selections[i].SeatIDs, *item.SeatID)
+			} else {
+				selections[i].Items = append(selections[i].Items, CheckoutItem{Category: item.Category, Quantity: item.Quantity})
+			}
+		}
+
+		if order, err = s.placeOrder(txRepo, userID, selections, points, promoCode, pass); err != nil {
+			return err
+		}
+		return txRepo.ClearCart(cart.ID)
+	})
+	if err == nil {
+		err = s.attachBill(order)
+	}
+	if err != nil {
+		s.returnQueueAdmissions(pass)
+		return nil, err
+	}
+
+	s.flipSoldOut(selections)
+	return order, nil
+}
+
+// editCart runs one change against the user's cart, restarts its hold timer and
+// returns the cart as it is afterwards
+func (s *BookingService) editCart(userID uint, change func(domain.TicketRepository, *domain.Cart) error) (*domain.Cart, error) {
+	var cart *domain.Cart
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		current, err := s.openCart(txRepo, userID)
+		if err != nil {
+			return err
+		}
+		if err := change(txRepo, current); err != nil {
+			return err
+		}
+		if err := txRepo.TouchCart(current.ID, time.Now().Add(domain.CartHoldTime)); err != nil {
+			return err
+		}
+		if cart, err = txRepo.GetCartForUpdate(userID); err != nil {
+			return err
+		}
+		return s.priceCart(txRepo, cart)
+	})
+	return cart, err
+}
+
+// openCart locks the user's cart, emptying it (and releasing its holds) first if its
+// hold timer ran out
+func (s *BookingService) openCart(txRepo domain.TicketRepository, userID uint) (*domain.Cart, error) {
+	cart, err := txRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Expired(time.Now()) && len(cart.Items) > 0 {
+		if err := txRepo.ClearCart(cart.ID); err != nil {
+			return nil, err
+		}
+		cart.Items = nil
+	}
+	return cart, nil
+}
+
+// checkCartTier is the early warning for adding quantity more of a tier: it must
+// exist, be open to the user, be sold the way it's being added (by seat or by
+// quantity), be on sale and have nobody on its waitlist. Whether the stock is there
+// is settled by holding it.
+func (s *BookingService) checkCartTier(txRepo domain.TicketRepository, cart *domain.Cart, eventID uint, category string, quantity int, seated bool) error {
+	tier, err := txRepo.GetTicketTier(eventID, category)
+	if err != nil {
+		return fmt.Errorf("category '%s' not found for this event", category)
+	}
+	if tier.Restricted() {
+		user, err := txRepo.GetUserByID(fmt.Sprint(cart.UserID))
+		if err != nil {
+			return err
+		}
+		if err := checkTierAccess(txRepo, user, tier); err != nil {
+			return err
+		}
+	}
+	for _, item := range cart.Items {
+		if item.EventID == eventID && item.Category == category {
+			quantity += item.Quantity
+		}
+	}
+	if tier.Seated != seated {
+		if tier.Seated {
+			return fmt.Errorf("%s is reserved seating, please pick your seats", category)
+		}
+		return fmt.Errorf("%s is general admission, add it by quantity", category)
+	}
+	if !seated {
+		waiting, err := txRepo.CountWaiting(tier.ID)
+		if err != nil {
+			return err
+		}
+		if waiting > 0 {
+			return &domain.CheckoutError{Code: domain.CodeWaitlistActive, Message: fmt.Sprintf(
+				"%s has a waitlist: join it to be offered the next tickets that come free", category)}
+		}
+	}
+	return tier.CheckPurchase(quantity, 0, time.Now())
+}
+
+// priceCart fills in each item's current list price
+func (s *BookingService) priceCart(txRepo domain.TicketRepository, cart *domain.Cart) error {
+	for i := range cart.Items {
+		tier, err := txRepo.GetTicketTier(cart.Items[i].EventID, cart.Items[i].Category)
+		if err != nil {
+			continue // tier removed since; checkout will say so
+		}
+		cart.Items[i].UnitPrice = tier.Price
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+)
+
+// What is in a cart can't sell to someone else before its timer runs out; checking
+// out hands the hold to the order, and an abandoned cart gives it back
+func TestCartHoldsStockUntilItExpires(t *testing.T) {
+	db, repo := openTestDB(t)
+	svc, _ := newTestService(t, repo)
+	event := newTestEvent(t, db, repo, 2)
+	shopper, other := newTestUser(t, repo), newTestUser(t, repo)
+	ga := []service.CheckoutItem{{Category: "GA", Quantity: 1}}
+
+	wantTier := func(held, sold int) {
+		t.Helper()
+		tier, err := repo.GetTicketTier(event.ID, "GA")
+		if err != nil {
+			t.Fatalf("load tier: %v", err)
+		}
+		if tier.Held != held || tier.Sold != sold {
+			t.Fatalf("tier: held %d, sold %d; want held %d, sold %d", tier.Held, tier.Sold, held, sold)
+		}
+	}
+
+	// 1. The cart takes both tickets: nobody else can check them out
+	if _, err := svc.AddToCart(shopper.ID, domain.AddCartItemRequest{EventID: event.ID, Category: "GA", Quantity: 2}, nil); err != nil {
+		t.Fatalf("add to cart: %v", err)
+	}
+	wantTier(2, 0)
+	if _, err := svc.CreateMultiItemOrder(other.ID, event.ID, ga, nil, nil, 0, "", nil); err == nil {
+		t.Fatal("another buyer checked out a ticket held in a cart")
+	}
+
+	// 2. Giving one back frees it
+	cart, err := svc.UpdateCartItem(shopper.ID, mustCartItem(t, svc, shopper.ID).ID, 1, nil)
+	if err != nil {
+		t.Fatalf("update cart: %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 1 {
+		t.Fatalf("cart = %+v, want one ticket", cart.Items)
+	}
+	wantTier(1, 0)
+
+	// 3. Checkout keeps the hold rather than taking another one
+	order, err := svc.CheckoutCart(shopper.ID, 0, "", nil)
+	if err != nil {
+		t.Fatalf("checkout cart: %v", err)
+	}
+	if len(order.Items) != 1 || order.Items[0].Quantity != 1 || order.Items[0].Status != domain.HoldHeld {
+		t.Fatalf("order items = %+v, want one held ticket", order.Items)
+	}
+	wantTier(1, 0)
+
+	// 4. An abandoned cart gives its stock back once cleaned up
+	if _, err := svc.AddToCart(other.ID, domain.AddCartItemRequest{EventID: event.ID, Category: "GA", Quantity: 1}, nil); err != nil {
+		t.Fatalf("add to cart: %v", err)
+	}
+	wantTier(2, 0)
+	if err := db.Model(&domain.Cart{}).Where("user_id = ?", other.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire cart: %v", err)
+	}
+	if _, err := repo.CleanupExpiredCarts(); err != nil {
+		t.Fatalf("cleanup carts: %v", err)
+	}
+	wantTier(1, 0)
+	if _, err := svc.CreateMultiItemOrder(other.ID, event.ID, ga, nil, nil, 0, "", nil); err != nil {
+		t.Fatalf("checkout after the cart expired: %v", err)
+	}
+}
+
+func mustCartItem(t *testing.T, svc *service.BookingService, userID uint) domain.CartItem {
+	t.Helper()
+	cart, err := svc.GetCart(userID)
+	if err != nil || len(cart.Items) == 0 {
+		t.Fatalf("cart = %+v, %v; want an item", cart, err)
+	}
+	return cart.Items[0]
+}
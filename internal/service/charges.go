@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+)
+
+// SetChargePolicy creates or replaces the fee/tax policy for a scope: global
+// (eventID nil), a whole event, or one tier of it (category set).
+func (s *BookingService) SetChargePolicy(eventID *uint, category string, req domain.ChargePolicyRequest, actorID uint) (*domain.ChargePolicy, error) {
+	var tierID *uint
+	scope := "global"
+	if eventID != nil {
+		if _, err := s.repo.GetEventByID(*eventID); err != nil {
+			return nil, fmt.Errorf("event not found")
+		}
+		scope = fmt.Sprintf("event %d", *eventID)
+		if category != "" {
+			tier, err := s.repo.GetTicketTier(*eventID, category)
+			if err != nil {
+				return nil, fmt.Errorf("category '%s' does not exist", category)
+			}
+			tierID = &tier.ID
+			scope += ", " + category
+		}
+	}
+
+	policy, err := s.repo.GetChargePolicy(eventID, tierID)
+	if err != nil {
+		policy = &domain.ChargePolicy{EventID: eventID, TierID: tierID}
+	}
+	if err := req.ApplyTo(policy); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveChargePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	s.repo.RecordLog(actorID, "CHARGE_POLICY", scope, fmt.Sprintf("fee %s + %g%%, %s %g%%",
+		policy.FeeFixed, policy.FeePercent, policy.TaxName, policy.TaxRate))
+	return policy, nil
+}
+
+func (s *BookingService) GetChargePolicies(eventID uint) ([]domain.ChargePolicy, error) {
+	return s.repo.GetChargePolicies(eventID)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"strings"
+	"time"
+)
+
+var errSupersededCredential = errors.New("INVALID: This QR code was replaced when the ticket was transferred")
+
+// Scanner identifies who/what is scanning: recorded on every ScanEvent
+type Scanner struct {
+	OperatorID uint
+	Gate       string
+	DeviceID   string
+}
+
+// CheckInTicket handles one live scan at a gate according to the event's entry policy.
+// Every attempt, successful or not, is written to the scan log.
+func (s *BookingService) CheckInTicket(credential string, expectedEventID uint, scanner Scanner) (*domain.ScanEvent, error) {
+	scan := &domain.ScanEvent{
+		EventID:    expectedEventID,
+		Gate:       scanner.Gate,
+		DeviceID:   scanner.DeviceID,
+		OperatorID: scanner.OperatorID,
+		ScannedAt:  time.Now(),
+	}
+
+	ticket, err := s.admitTicket(credential, expectedEventID, scanner, scan)
+	if err != nil {
+		scan.Reason = err.Error()
+	}
+	if ticket != nil {
+		scan.TicketID = ticket.ID
+	}
+	s.repo.RecordScanEvent(scan)
+
+	if err != nil {
+		return nil, err
+	}
+	scan.Ticket = ticket
+	return scan, nil
+}
+
+// admitTicket sets scan.Result and returns the ticket (when we could identify one)
+func (s *BookingService) admitTicket(credential string, expectedEventID uint, scanner Scanner, scan *domain.ScanEvent) (*domain.Ticket, error) {
+	// 1. 🔒 Signature first: forged or tampered QR codes never reach the database
+	claims, err := s.signer.Verify(credential)
+	if err != nil {
+		scan.Result = domain.ScanResultInvalid
+		return nil, err
+	}
+	return s.admitClaims(claims, expectedEventID, scanner, scan)
+}
+
+// admitClaims applies the event's entry policy to a ticket whose credential checked out
+func (s *BookingService) admitClaims(claims *TicketClaims, expectedEventID uint, scanner Scanner, scan *domain.ScanEvent) (*domain.Ticket, error) {
+	identified := &domain.Ticket{ID: claims.TicketID}
+	if claims.EventID != expectedEventID {
+		scan.Result = domain.ScanResultWrongEvent
+		return identified, fmt.Errorf("WRONG EVENT: This ticket is for event #%d", claims.EventID)
+	}
+
+	event, err := s.repo.GetEventByID(expectedEventID)
+	if err != nil {
+		scan.Result = domain.ScanResultInvalid
+		return identified, fmt.Errorf("event not found")
+	}
+	if !event.AllowsCheckIn() {
+		scan.Result = domain.ScanResultInvalid
+		return identified, fmt.Errorf("EVENT %s: Check-in is closed", strings.ToUpper(event.Status))
+	}
+
+	if event.EntryPolicy == domain.EntryPolicyReentry || event.EntryPolicy == domain.EntryPolicyInOut {
+		return s.admitRepeatable(claims, event, scanner, scan)
+	}
+
+	// 2. Single entry. Atomic claim: one conditional UPDATE decides the winner across every gate
+	won, err := s.repo.ClaimCheckIn(claims.TicketID, expectedEventID, claims.Version, scanner.OperatorID, scanner.Gate, scan.ScannedAt)
+	if err != nil {
+		scan.Result = domain.ScanResultInvalid
+		return identified, err
+	}
+
+	ticket, err := s.repo.GetByID(claims.TicketID)
+	if err != nil {
+		scan.Result = domain.ScanResultInvalid
+		return identified, fmt.Errorf("ticket not found")
+	}
+	if won {
+		scan.Result = domain.ScanResultAdmitted
+		return ticket, nil
+	}
+
+	// 3. We lost (or the ticket was never admissible), so explain why
+	if err := checkAdmissible(ticket, expectedEventID, claims.Version, scan); err != nil {
+		return ticket, err
+	}
+
+	// 🔒 Already Used (possibly a split second ago at another gate)
+	if ticket.CheckedInAt != nil {
+		scan.Result = domain.ScanResultAlreadyUsed
+		duration := time.Since(*ticket.CheckedInAt).Round(time.Minute)
+		return ticket, fmt.Errorf("ALREADY USED: Scanned %s ago at %s%s",
+			duration,
+			ticket.CheckedInAt.Format("3:04 PM"),
+			describeGate(ticket.CheckedInGate))
+	}
+
+	scan.Result = domain.ScanResultInvalid
+	return ticket, fmt.Errorf("check-in failed, please rescan")
+}
+
+// BulkCheckIn admits tickets found at the help desk (see GetUnscannedByEmail) instead
+// of scanned at a gate. Each one goes through the same entry policy as a scan and is
+// written to the scan log; the ticket's current credential version stands in for the
+// QR code the guest couldn't show. Returns every scan and how many got in.
+func (s *BookingService) BulkCheckIn(ticketIDs []string, scanner Scanner) ([]domain.ScanEvent, int) {
+	scans := make([]domain.ScanEvent, 0, len(ticketIDs))
+	admitted := 0
+	for _, ticketID := range ticketIDs {
+		scan := &domain.ScanEvent{
+			TicketID:   ticketID,
+			Gate:       scanner.Gate,
+			DeviceID:   scanner.DeviceID,
+			OperatorID: scanner.OperatorID,
+			ScannedAt:  time.Now(),
+		}
+		ticket, err := s.repo.GetByID(ticketID)
+		if err != nil {
+			scan.Result = domain.ScanResultInvalid
+			scan.Reason = "ticket not found"
+		} else {
+			scan.EventID = ticket.EventID
+			claims := &TicketClaims{
+				TicketID: ticket.ID,
+				EventID:  ticket.EventID,
+				Category: ticket.Category,
+				Version:  ticket.CredentialVersion,
+			}
+			if _, err := s.admitClaims(claims, ticket.EventID, scanner, scan); err != nil {
+				scan.Reason = err.Error()
+			}
+		}
+		s.repo.RecordScanEvent(scan)
+		scans = append(scans, *scan)
+		if scan.Result == domain.ScanResultAdmitted || scan.Result == domain.ScanResultReentered {
+			admitted++
+		}
+	}
+
+	details := fmt.Sprintf("Checked in %d of %d tickets via email lookup", admitted, len(ticketIDs))
+	s.repo.RecordLog(scanner.OperatorID, "BULK_CHECKIN", "MULTIPLE", details)
+	return scans, admitted
+}
+
+// admitRepeatable covers reentry and in_out events, where a ticket can legitimately be
+// scanned many times. The row lock keeps two gates from toggling the same ticket at once.
+func (s *BookingService) admitRepeatable(claims *TicketClaims, event *domain.Event, scanner Scanner, scan *domain.ScanEvent) (*domain.Ticket, error) {
+	var ticket *domain.Ticket
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		var err error
+		ticket, err = txRepo.GetTicketForUpdate(claims.TicketID)
+		if err != nil {
+			scan.Result = domain.ScanResultInvalid
+			ticket = nil
+			return errors.New("ticket not found")
+		}
+		if err := checkAdmissible(ticket, event.ID, claims.Version, scan); err != nil {
+			return err
+		}
+
+		// Leaving: only in_out events track it, and only when the holder is inside
+		if event.EntryPolicy == domain.EntryPolicyInOut && ticket.Inside {
+			scan.Result = domain.ScanResultExited
+			ticket.Inside = false
+			return txRepo.SetTicketInside(ticket.ID, false)
+		}
+
+		// Entering: the first admission is the one CheckedInAt keeps
+		if ticket.CheckedInAt == nil {
+			scan.Result = domain.ScanResultAdmitted
+			if err := txRepo.RecordCheckIn(ticket.ID, scan.ScannedAt, scanner.OperatorID, scanner.DeviceID, scanner.Gate); err != nil {
+				return err
+			}
+			ticket.CheckedInAt = &scan.ScannedAt
+		} else {
+			scan.Result = domain.ScanResultReentered
+		}
+
+		if event.EntryPolicy == domain.EntryPolicyInOut {
+			ticket.Inside = true
+			return txRepo.SetTicketInside(ticket.ID, true)
+		}
+		return nil
+	})
+	return ticket, err
+}
+
+// checkAdmissible covers the rejections every policy shares
+func checkAdmissible(ticket *domain.Ticket, expectedEventID uint, version int, scan *domain.ScanEvent) error {
+	// 🔒 Wrong Event: prevents a ticket for "Event A" being scanned at "Event B"
+	if ticket.EventID != expectedEventID {
+		scan.Result = domain.ScanResultWrongEvent
+		return fmt.Errorf("WRONG EVENT: This ticket is for '%s'", ticket.Event.Name)
+	}
+
+	// 🔒 Voided (event cancelled) or refunded: it was paid for once, but not any more
+	if ticket.Status == domain.TicketVoided || ticket.Status == domain.TicketRefunded {
+		scan.Result = domain.ScanResultInvalid
+		return fmt.Errorf("INVALID: This ticket has been %s.", ticket.Status)
+	}
+
+	// 🔒 Unpaid Ticket
+	if !ticket.IsSold || ticket.Status != domain.TicketSold {
+		scan.Result = domain.ScanResultUnpaid
+		return fmt.Errorf("INVALID: This ticket has not been paid for.")
+	}
+
+	// 🔒 Superseded QR: the ticket was transferred and reissued to its new holder
+	if ticket.CredentialVersion != version {
+		scan.Result = domain.ScanResultInvalid
+		return errSupersededCredential
+	}
+	return nil
+}
+
+func describeGate(gate string) string {
+	if gate == "" {
+		return ""
+	}
+	return " (" + gate + ")"
+}
+
+// GetTicketScans is the full entry/exit/rejection history of one ticket
+func (s *BookingService) GetTicketScans(ticketID string) ([]domain.ScanEvent, error) {
+	return s.repo.GetTicketScans(ticketID)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"neptunes-tix/internal/domain"
+)
+
+func TestCheckAdmissible(t *testing.T) {
+	tests := []struct {
+		name       string
+		ticket     domain.Ticket
+		version    int
+		wantErr    bool
+		wantResult string
+	}{
+		{
+			name:    "sold",
+			ticket:  domain.Ticket{EventID: 1, IsSold: true, Status: domain.TicketSold, CredentialVersion: 1},
+			version: 1,
+		},
+		{
+			name:       "wrong event",
+			ticket:     domain.Ticket{EventID: 2, IsSold: true, Status: domain.TicketSold, CredentialVersion: 1},
+			version:    1,
+			wantErr:    true,
+			wantResult: domain.ScanResultWrongEvent,
+		},
+		{
+			name:       "voided but still flagged sold",
+			ticket:     domain.Ticket{EventID: 1, IsSold: true, Status: domain.TicketVoided, CredentialVersion: 1},
+			version:    1,
+			wantErr:    true,
+			wantResult: domain.ScanResultInvalid,
+		},
+		{
+			name:       "refunded",
+			ticket:     domain.Ticket{EventID: 1, IsSold: false, Status: domain.TicketRefunded, CredentialVersion: 1},
+			version:    1,
+			wantErr:    true,
+			wantResult: domain.ScanResultInvalid,
+		},
+		{
+			name:       "unpaid",
+			ticket:     domain.Ticket{EventID: 1, CredentialVersion: 1},
+			version:    1,
+			wantErr:    true,
+			wantResult: domain.ScanResultUnpaid,
+		},
+		{
+			name:       "superseded credential",
+			ticket:     domain.Ticket{EventID: 1, IsSold: true, Status: domain.TicketSold, CredentialVersion: 2},
+			version:    1,
+			wantErr:    true,
+			wantResult: domain.ScanResultInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := &domain.ScanEvent{}
+			err := checkAdmissible(&tt.ticket, 1, tt.version, scan)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkAdmissible() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if scan.Result != tt.wantResult {
+				t.Errorf("scan result = %q, want %q", scan.Result, tt.wantResult)
+			}
+		})
+	}
+}
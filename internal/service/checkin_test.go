@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+)
+
+// Every gate scanning the same QR code at once: exactly one of them lets the guest in
+func TestCheckInTicketAdmitsOnceUnderConcurrentScans(t *testing.T) {
+	db, repo := openTestDB(t)
+	svc, signer := newTestService(t, repo)
+	event := newTestEvent(t, db, repo, 1)
+	buyer := newTestUser(t, repo)
+
+	ticket := &domain.Ticket{
+		EventID:      event.ID,
+		Category:     "GA",
+		Price:        domain.Sen(5000),
+		IsSold:       true,
+		Status:       domain.TicketSold,
+		HolderUserID: &buyer.ID,
+	}
+	if err := repo.CreateTicket(ticket); err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	credential, err := signer.Issue(ticket)
+	if err != nil {
+		t.Fatalf("issue credential: %v", err)
+	}
+
+	const gates = 25
+	start := make(chan struct{})
+	errs := make([]error, gates)
+	var wg sync.WaitGroup
+	for i := range gates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = svc.CheckInTicket(credential, event.ID, service.Scanner{OperatorID: buyer.ID, Gate: fmt.Sprintf("gate %d", i)})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	admitted := 0
+	for _, err := range errs {
+		if err == nil {
+			admitted++
+		}
+	}
+	if admitted != 1 {
+		t.Fatalf("%d of %d concurrent scans admitted the ticket, want exactly 1 (errors: %v)", admitted, gates, errs)
+	}
+
+	// The scan log agrees: one admission, every other gate saw it already used
+	counts := map[string]int{}
+	scans, err := repo.GetTicketScans(ticket.ID)
+	if err != nil {
+		t.Fatalf("load scans: %v", err)
+	}
+	for _, scan := range scans {
+		counts[scan.Result]++
+	}
+	if counts[domain.ScanResultAdmitted] != 1 || counts[domain.ScanResultAlreadyUsed] != gates-1 {
+		t.Fatalf("scan log = %v, want 1 %s and %d %s", counts, domain.ScanResultAdmitted, gates-1, domain.ScanResultAlreadyUsed)
+	}
+
+	stored, err := repo.GetByID(ticket.ID)
+	if err != nil {
+		t.Fatalf("reload ticket: %v", err)
+	}
+	if stored.CheckedInAt == nil {
+		t.Fatal("ticket was admitted but has no CheckedInAt")
+	}
+}
+
+// A cancelled event voids its unscanned tickets: their QR codes still verify, but the
+// gate must turn them away
+func TestCheckInTicketRejectsVoidedTicket(t *testing.T) {
+	db, repo := openTestDB(t)
+	svc, signer := newTestService(t, repo)
+	event := newTestEvent(t, db, repo, 1)
+	buyer := newTestUser(t, repo)
+
+	ticket := &domain.Ticket{
+		EventID:      event.ID,
+		Category:     "GA",
+		Price:        domain.Sen(5000),
+		IsSold:       true,
+		Status:       domain.TicketSold,
+		HolderUserID: &buyer.ID,
+	}
+	if err := repo.CreateTicket(ticket); err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	credential, err := signer.Issue(ticket)
+	if err != nil {
+		t.Fatalf("issue credential: %v", err)
+	}
+	if _, err := repo.VoidUnscannedTickets(event.ID); err != nil {
+		t.Fatalf("void tickets: %v", err)
+	}
+
+	if _, err := svc.CheckInTicket(credential, event.ID, service.Scanner{OperatorID: buyer.ID, Gate: "gate 1"}); err == nil {
+		t.Fatal("voided ticket was admitted")
+	}
+
+	stored, err := repo.GetByID(ticket.ID)
+	if err != nil {
+		t.Fatalf("reload ticket: %v", err)
+	}
+	if stored.CheckedInAt != nil {
+		t.Fatal("voided ticket was marked checked in")
+	}
+	scans, err := repo.GetTicketScans(ticket.ID)
+	if err != nil {
+		t.Fatalf("load scans: %v", err)
+	}
+	if len(scans) != 1 || scans[0].Result != domain.ScanResultInvalid {
+		t.Fatalf("scan log = %+v, want one %s scan", scans, domain.ScanResultInvalid)
+	}
+}
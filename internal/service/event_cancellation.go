@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+)
+
+// Orders the cancellation job still has to deal with
+var cancellableOrderStatuses = []string{"pending", "paid", "refund_requested", "refund_processing", "partially_refunded"}
+
+// CancelEvent cancels the event straight away (no more sales or check-ins) and queues
+// a background job that voids tickets, refunds every paid order and tells the buyers.
+// Calling it again for an already cancelled event returns the existing job.
+func (s *BookingService) CancelEvent(eventID uint, actorID uint, reason string) (*domain.Job, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	if event.Status == domain.EventCancelled {
+		job, err := s.repo.FindJob(domain.JobTypeCancelEvent, fmt.Sprint(eventID))
+		if err == nil {
+			return job, nil
+		}
+	} else if _, err := s.TransitionEvent(eventID, domain.EventCancelled, actorID, reason); err != nil {
+		return nil, err
+	}
+
+	job := &domain.Job{
+		Type:      domain.JobTypeCancelEvent,
+		TargetID:  fmt.Sprint(eventID),
+		Status:    domain.JobQueued,
+		Reason:    reason,
+		CreatedBy: actorID,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	s.StartJob(job.ID)
+	return job, nil
+}
+
+func (s *BookingService) runEventCancellation(job *domain.Job) error {
+	var eventID uint
+	if _, err := fmt.Sscanf(job.TargetID, "%d", &eventID); err != nil {
+		return fmt.Errorf("bad event id '%s'", job.TargetID)
+	}
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	// 1. Nothing unscanned is valid any more (idempotent)
+	if _, err := s.repo.VoidUnscannedTickets(eventID); err != nil {
+		return err
+	}
+
+	// 2. Size the job once so progress means something
+	if job.Total == 0 {
+		total, err := s.repo.CountEventOrders(eventID, cancellableOrderStatuses)
+		if err != nil {
+			return err
+		}
+		job.Total = int(total)
+		if err := s.repo.UpdateJob(job); err != nil {
+			return err
+		}
+	}
+
+	// 3. Walk the orders in ID order, saving the cursor after every batch
+	for {
+		orders, err := s.repo.GetEventOrders(eventID, job.Cursor, cancellableOrderStatuses, jobBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		for i := range orders {
+			if err := s.cancelEventOrder(&orders[i], event, job.Reason); err != nil {
+				job.Failed++
+				job.LastError = fmt.Sprintf("order %d: %v", orders[i].ID, err)
+			} else {
+				job.Processed++
+			}
+			job.Cursor = orders[i].ID
+		}
+
+		if err := s.repo.UpdateJob(job); err != nil {
+			return err
+		}
+	}
+}
+
+// cancelEventOrder is safe to repeat: each step only fires from the status it expects.
+func (s *BookingService) cancelEventOrder(order *domain.Order, event *domain.Event, reason string) error {
+	// Unpaid: just let the held stock go
+	if order.Status == "pending" {
+		cancelled := false
+		err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+			won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "cancelled")
+			if err != nil || !won {
+				return err
+			}
+			if _, err := txRepo.ReleaseOrderHolds(order.ID); err != nil {
+				return err
+			}
+			cancelled = true
+			s.notify(txRepo, order.UserID, "Event cancelled", fmt.Sprintf(
+				"%s has been cancelled. Your unpaid order #%d was cancelled and you have not been charged.", event.Name, order.ID))
+			return nil
+		})
+		if err != nil || cancelled {
+			return err
+		}
+		// Lost to a payment callback since the batch was read, so it may be paid now:
+		// fall through and refund it like any other paid order
+	}
+
+	// 1. Finish any refund this order was in the middle of, whatever started it
+	order, err := s.repo.GetOrderById(fmt.Sprint(order.ID))
+	if err != nil {
+		return err
+	}
+	if order.Status == "refund_processing" {
+		if err := s.resumeRefund(order); err != nil {
+			return err
+		}
+		if order, err = s.repo.GetOrderById(fmt.Sprint(order.ID)); err != nil {
+			return err
+		}
+	}
+
+	// 2. Refund this event's tickets. Other events on the same order keep theirs, and
+	// an open refund request is overtaken.
+	var ticketIDs []string
+	for _, ticket := range order.Tickets {
+		if ticket.EventID == event.ID && ticket.Status != domain.TicketRefunded {
+			ticketIDs = append(ticketIDs, ticket.ID)
+		}
+	}
+	if len(ticketIDs) == 0 {
+		return nil
+	}
+	_, err = s.refundTickets(order, refundSpec{
+		ticketIDs: ticketIDs,
+		from:      []string{"paid", "partially_refunded", "refund_requested"},
+		reason:    fmt.Sprintf("%s was cancelled: %s", event.Name, reason),
+	})
+	return err
+}
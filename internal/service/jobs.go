@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// How many items a job handles before saving its progress
+const jobBatchSize = 50
+
+// StartJob runs a job in the background. A job already running in this process is
+// left alone, so a double click (or a resume during a run) can't process items twice.
+func (s *BookingService) StartJob(jobID uint) {
+	if _, busy := s.runningJobs.LoadOrStore(jobID, true); busy {
+		return
+	}
+	go func() {
+		defer s.runningJobs.Delete(jobID)
+		if err := s.runJob(jobID); err != nil {
+			log.Printf("job %d stopped: %v", jobID, err)
+		}
+	}()
+}
+
+// ResumeJobs restarts whatever was queued or running when the process last stopped
+func (s *BookingService) ResumeJobs() {
+	jobs, err := s.repo.GetUnfinishedJobs()
+	if err != nil {
+		log.Printf("could not load unfinished jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		s.StartJob(job.ID)
+	}
+}
+
+// RetryJob gives a finished job with failures another pass from the beginning.
+// Items that already succeeded are skipped because every step is conditional.
+func (s *BookingService) RetryJob(jobID uint) (*domain.Job, error) {
+	job, err := s.repo.GetJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found")
+	}
+	if !job.Finished() {
+		s.StartJob(job.ID)
+		return job, nil
+	}
+
+	job.Status = domain.JobQueued
+	job.Cursor = 0
+	job.Processed = 0
+	job.Failed = 0
+	job.Total = 0
+	job.LastError = ""
+	job.FinishedAt = nil
+	if err := s.repo.UpdateJob(job); err != nil {
+		return nil, err
+	}
+	s.StartJob(job.ID)
+	return job, nil
+}
+
+func (s *BookingService) GetJob(jobID uint) (*domain.Job, error) {
+	return s.repo.GetJob(jobID)
+}
+
+func (s *BookingService) runJob(jobID uint) error {
+	job, err := s.repo.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		return nil
+	}
+
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = domain.JobRunning
+	if err := s.repo.UpdateJob(job); err != nil {
+		return err
+	}
+
+	switch job.Type {
+	case domain.JobTypeCancelEvent:
+		err = s.runEventCancellation(job)
+	default:
+		err = fmt.Errorf("unknown job type '%s'", job.Type)
+	}
+
+	// An error here means the job itself broke (e.g. DB down), not a single item.
+	// Leave it running so the next boot or a retry picks it up from the cursor.
+	if err != nil {
+		job.LastError = err.Error()
+		s.repo.UpdateJob(job)
+		return err
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = domain.JobCompleted
+	if job.Failed > 0 {
+		job.Status = domain.JobFailed
+	}
+	return s.repo.UpdateJob(job)
+}
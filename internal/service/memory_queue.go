@@ -0,0 +1,210 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"neptunes-tix/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// MemoryQueueStore keeps waiting rooms in this process. It is the local stand-in for
+// a shared cache: lines are lost on restart and not seen by other instances.
+type MemoryQueueStore struct {
+	mu    sync.Mutex
+	lines map[uint]*memoryLine
+}
+
+type memoryLine struct {
+	issued   int64                          // last Number handed out
+	served   int64                          // Numbers up to this one have been admitted
+	tickets  map[string]*domain.QueueTicket // token → ticket
+	byUser   map[uint]string                // user → their token
+	waiting  []string                       // tokens in line; waiting[head:] still waiting
+	head     int
+	admitted []string // tokens in admission order, so expiry can trim from the front
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{lines: map[uint]*memoryLine{}}
+}
+
+func (m *MemoryQueueStore) line(eventID uint) *memoryLine {
+	l := m.lines[eventID]
+	if l == nil {
+		l = &memoryLine{tickets: map[string]*domain.QueueTicket{}, byUser: map[uint]string{}}
+		m.lines[eventID] = l
+	}
+	return l
+}
+
+func (m *MemoryQueueStore) Join(eventID, userID uint, now time.Time) (*domain.QueueTicket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.line(eventID)
+	if token, ok := l.byUser[userID]; ok {
+		if ticket := l.live(token, now); ticket != nil && ticket.Status != domain.QueueUsed {
+			return ticket, nil
+		}
+	}
+
+	l.issued++
+	ticket := &domain.QueueTicket{
+		Token:    uuid.NewString(),
+		EventID:  eventID,
+		UserID:   userID,
+		Number:   l.issued,
+		Status:   domain.QueueWaiting,
+		JoinedAt: now,
+	}
+	l.tickets[ticket.Token] = ticket
+	l.byUser[userID] = ticket.Token
+	l.waiting = append(l.waiting, ticket.Token)
+	return l.view(ticket), nil
+}
+
+func (m *MemoryQueueStore) Get(eventID uint, token string, now time.Time) (*domain.QueueTicket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l := m.lines[eventID]; l != nil {
+		if ticket := l.live(token, now); ticket != nil {
+			return ticket, nil
+		}
+	}
+	return nil, ErrQueueTicketNotFound
+}
+
+func (m *MemoryQueueStore) Leave(eventID uint, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l := m.lines[eventID]; l != nil {
+		l.drop(token)
+	}
+	return nil
+}
+
+func (m *MemoryQueueStore) Admit(eventID uint, n int, now, expiresAt time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.lines[eventID]
+	if l == nil {
+		return 0, nil
+	}
+	l.expire(now)
+
+	admitted := 0
+	for admitted < n && l.head < len(l.waiting) {
+		token := l.waiting[l.head]
+		l.head++
+		ticket := l.tickets[token]
+		if ticket == nil {
+			continue // left while waiting
+		}
+		at, until := now, expiresAt
+		ticket.Status = domain.QueueAdmitted
+		ticket.AdmittedAt = &at
+		ticket.ExpiresAt = &until
+		l.served = ticket.Number
+		l.admitted = append(l.admitted, token)
+		admitted++
+	}
+
+	// Don't let the consumed front of the line grow forever
+	if l.head > 1024 && l.head*2 > len(l.waiting) {
+		l.waiting = append([]string(nil), l.waiting[l.head:]...)
+		l.head = 0
+	}
+	if len(l.tickets) == 0 {
+		delete(m.lines, eventID)
+	}
+	return admitted, nil
+}
+
+func (m *MemoryQueueStore) Use(eventID uint, token string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l := m.lines[eventID]; l != nil {
+		if ticket := l.tickets[token]; ticket != nil && ticket.Admitted(now) {
+			ticket.Status = domain.QueueUsed
+			return nil
+		}
+	}
+	return ErrQueueTicketNotFound
+}
+
+func (m *MemoryQueueStore) Unuse(eventID uint, token string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l := m.lines[eventID]; l != nil {
+		if ticket := l.tickets[token]; ticket != nil && ticket.Status == domain.QueueUsed && ticket.InWindow(now) {
+			ticket.Status = domain.QueueAdmitted
+		}
+	}
+	return nil
+}
+
+func (m *MemoryQueueStore) Lines() ([]uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []uint
+	for id, l := range m.lines {
+		if len(l.tickets) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// live returns a copy of the ticket unless it is gone or its admission ran out
+func (l *memoryLine) live(token string, now time.Time) *domain.QueueTicket {
+	ticket := l.tickets[token]
+	if ticket == nil {
+		return nil
+	}
+	if ticket.Status != domain.QueueWaiting && !ticket.InWindow(now) {
+		l.drop(token)
+		return nil
+	}
+	return l.view(ticket)
+}
+
+// view copies the ticket with its position. People who left ahead still count, so
+// the position may run a little high; it never runs low.
+func (l *memoryLine) view(ticket *domain.QueueTicket) *domain.QueueTicket {
+	copied := *ticket
+	if copied.Status == domain.QueueWaiting {
+		copied.Position = copied.Number - l.served
+	}
+	return &copied
+}
+
+func (l *memoryLine) drop(token string) {
+	if ticket := l.tickets[token]; ticket != nil {
+		delete(l.tickets, token)
+		if l.byUser[ticket.UserID] == token {
+			delete(l.byUser, ticket.UserID)
+		}
+	}
+}
+
+// expire forgets admitted (and used) tickets whose window has passed. Every admission gets the
+// same window, so they run out in admission order.
+func (l *memoryLine) expire(now time.Time) {
+	i := 0
+	for ; i < len(l.admitted); i++ {
+		ticket := l.tickets[l.admitted[i]]
+		if ticket != nil && ticket.InWindow(now) {
+			break
+		}
+		l.drop(l.admitted[i])
+	}
+	l.admitted = l.admitted[i:]
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"neptunes-tix/internal/domain"
+)
+
+var queueStart = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// joinAll queues users 1..n for event 1 and returns their tokens in line order
+func joinAll(t *testing.T, q *MemoryQueueStore, n int, now time.Time) []string {
+	t.Helper()
+	var tokens []string
+	for i := 1; i <= n; i++ {
+		ticket, err := q.Join(1, uint(i), now)
+		if err != nil {
+			t.Fatalf("join user %d: %v", i, err)
+		}
+		tokens = append(tokens, ticket.Token)
+	}
+	return tokens
+}
+
+func mustQueueTicket(t *testing.T, q *MemoryQueueStore, token string, now time.Time) *domain.QueueTicket {
+	t.Helper()
+	ticket, err := q.Get(1, token, now)
+	if err != nil {
+		t.Fatalf("get %s: %v", token, err)
+	}
+	return ticket
+}
+
+func TestMemoryQueueAdmitsInOrder(t *testing.T) {
+	q := NewMemoryQueueStore()
+	tokens := joinAll(t, q, 5, queueStart)
+	until := queueStart.Add(domain.QueueAdmissionWindow)
+
+	for i, token := range tokens {
+		ticket := mustQueueTicket(t, q, token, queueStart)
+		if ticket.Number != int64(i+1) || ticket.Position != int64(i+1) || ticket.Status != domain.QueueWaiting {
+			t.Fatalf("ticket %d = %+v, want number and position %d, waiting", i, ticket, i+1)
+		}
+	}
+
+	n, err := q.Admit(1, 2, queueStart, until)
+	if err != nil || n != 2 {
+		t.Fatalf("Admit = %d, %v; want 2", n, err)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		wantStatus   string
+		wantPosition int64
+	}{
+		{"front of the line", tokens[0], domain.QueueAdmitted, 0},
+		{"second", tokens[1], domain.QueueAdmitted, 0},
+		{"next up", tokens[2], domain.QueueWaiting, 1},
+		{"back of the line", tokens[4], domain.QueueWaiting, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := mustQueueTicket(t, q, tt.token, queueStart)
+			if ticket.Status != tt.wantStatus || ticket.Position != tt.wantPosition {
+				t.Errorf("status %s, position %d; want %s, %d", ticket.Status, ticket.Position, tt.wantStatus, tt.wantPosition)
+			}
+			if tt.wantStatus == domain.QueueAdmitted && (ticket.ExpiresAt == nil || !ticket.ExpiresAt.Equal(until)) {
+				t.Errorf("ExpiresAt = %v, want %v", ticket.ExpiresAt, until)
+			}
+		})
+	}
+
+	// Joining again hands back the place already held
+	again, err := q.Join(1, 1, queueStart)
+	if err != nil || again.Token != tokens[0] {
+		t.Fatalf("Join again = %+v, %v; want token %s", again, err, tokens[0])
+	}
+}
+
+func TestMemoryQueueExpiresAdmissions(t *testing.T) {
+	q := NewMemoryQueueStore()
+	tokens := joinAll(t, q, 3, queueStart)
+	window := domain.QueueAdmissionWindow
+
+	// Two admitted a minute apart, the third still waiting
+	q.Admit(1, 1, queueStart, queueStart.Add(window))
+	later := queueStart.Add(time.Minute)
+	q.Admit(1, 1, later, later.Add(window))
+
+	// The first window closes: Admit trims it, the second and the waiter stay
+	afterFirst := queueStart.Add(window)
+	if n, err := q.Admit(1, 0, afterFirst, afterFirst.Add(window)); err != nil || n != 0 {
+		t.Fatalf("Admit(0) = %d, %v", n, err)
+	}
+	line := q.lines[1]
+	if _, ok := line.tickets[tokens[0]]; ok {
+		t.Error("expired admission was not trimmed")
+	}
+	if len(line.admitted) != 1 || line.admitted[0] != tokens[1] {
+		t.Errorf("admitted = %v, want only the second ticket", line.admitted)
+	}
+	if _, err := q.Get(1, tokens[0], afterFirst); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("Get(expired) error = %v, want ErrQueueTicketNotFound", err)
+	}
+	if ticket := mustQueueTicket(t, q, tokens[1], afterFirst); !ticket.Admitted(afterFirst) {
+		t.Errorf("second ticket = %+v, want still admitted", ticket)
+	}
+
+	// Get drops an expired ticket on sight too, before any Admit runs
+	afterSecond := later.Add(window)
+	if _, err := q.Get(1, tokens[1], afterSecond); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("Get(expired) error = %v, want ErrQueueTicketNotFound", err)
+	}
+	if ticket := mustQueueTicket(t, q, tokens[2], afterSecond); ticket.Status != domain.QueueWaiting || ticket.Position != 1 {
+		t.Errorf("waiter = %+v, want waiting at position 1", ticket)
+	}
+
+	// Its user gets a new place at the back of the line
+	fresh, err := q.Join(1, 1, afterSecond)
+	if err != nil || fresh.Token == tokens[0] || fresh.Number != 4 {
+		t.Errorf("Join after expiry = %+v, %v; want a new ticket number 4", fresh, err)
+	}
+}
+
+func TestMemoryQueueLeaveThenJoin(t *testing.T) {
+	q := NewMemoryQueueStore()
+	tokens := joinAll(t, q, 3, queueStart)
+
+	if err := q.Leave(1, tokens[0]); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if _, err := q.Get(1, tokens[0], queueStart); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("Get(left) error = %v, want ErrQueueTicketNotFound", err)
+	}
+
+	// Coming back goes to the back of the line, not the old place
+	back, err := q.Join(1, 1, queueStart)
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if back.Token == tokens[0] || back.Number != 4 || back.Position != 4 {
+		t.Errorf("rejoined = %+v, want a new ticket number 4 at position 4", back)
+	}
+
+	// Admission skips the ticket that left and takes the next two in line
+	until := queueStart.Add(domain.QueueAdmissionWindow)
+	if n, _ := q.Admit(1, 2, queueStart, until); n != 2 {
+		t.Fatalf("admitted %d, want 2", n)
+	}
+	for _, token := range tokens[1:] {
+		if ticket := mustQueueTicket(t, q, token, queueStart); ticket.Status != domain.QueueAdmitted {
+			t.Errorf("%s = %s, want admitted", token, ticket.Status)
+		}
+	}
+	if ticket := mustQueueTicket(t, q, back.Token, queueStart); ticket.Position != 1 {
+		t.Errorf("rejoined position = %d, want 1", ticket.Position)
+	}
+
+	// Leaving an unknown token is harmless
+	if err := q.Leave(1, "nobody"); err != nil {
+		t.Errorf("Leave(unknown) = %v", err)
+	}
+	if err := q.Leave(2, "nobody"); err != nil {
+		t.Errorf("Leave(unknown line) = %v", err)
+	}
+}
+
+func TestMemoryQueueUseSpendsAdmission(t *testing.T) {
+	q := NewMemoryQueueStore()
+	tokens := joinAll(t, q, 2, queueStart)
+	until := queueStart.Add(domain.QueueAdmissionWindow)
+	q.Admit(1, 1, queueStart, until)
+
+	if err := q.Use(1, tokens[1], queueStart); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("Use(waiting) error = %v, want ErrQueueTicketNotFound", err)
+	}
+	if err := q.Use(1, tokens[0], queueStart); err != nil {
+		t.Fatalf("use: %v", err)
+	}
+	if err := q.Use(1, tokens[0], queueStart); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("second Use error = %v, want ErrQueueTicketNotFound", err)
+	}
+	if ticket := mustQueueTicket(t, q, tokens[0], queueStart); ticket.Status != domain.QueueUsed || ticket.Admitted(queueStart) {
+		t.Errorf("used ticket = %+v, want used and no longer admitted", ticket)
+	}
+
+	// A failed checkout gives it back
+	if err := q.Unuse(1, tokens[0], queueStart); err != nil {
+		t.Fatalf("unuse: %v", err)
+	}
+	if ticket := mustQueueTicket(t, q, tokens[0], queueStart); !ticket.Admitted(queueStart) {
+		t.Errorf("given back = %+v, want admitted", ticket)
+	}
+
+	// Buying again means queueing again
+	q.Use(1, tokens[0], queueStart)
+	again, err := q.Join(1, 1, queueStart)
+	if err != nil || again.Token == tokens[0] || again.Status != domain.QueueWaiting {
+		t.Errorf("Join after use = %+v, %v; want a new waiting ticket", again, err)
+	}
+
+	// Nothing comes back once the window has closed
+	q.Unuse(1, tokens[0], until)
+	if _, err := q.Get(1, tokens[0], until); !errors.Is(err, ErrQueueTicketNotFound) {
+		t.Errorf("Get(used, expired) error = %v, want ErrQueueTicketNotFound", err)
+	}
+}
+
+func TestMemoryQueueCompactsTheFront(t *testing.T) {
+	q := NewMemoryQueueStore()
+	joinAll(t, q, 1500, queueStart)
+	until := queueStart.Add(domain.QueueAdmissionWindow)
+
+	// 1000 in: head is under the threshold, nothing moves
+	if n, _ := q.Admit(1, 1000, queueStart, until); n != 1000 {
+		t.Fatalf("admitted %d, want 1000", n)
+	}
+	line := q.lines[1]
+	if line.head != 1000 || len(line.waiting) != 1500 {
+		t.Fatalf("head %d of %d, want 1000 of 1500", line.head, len(line.waiting))
+	}
+
+	// 100 more: head passes 1024 and is over half the slice, so the front is cut
+	if n, _ := q.Admit(1, 100, queueStart, until); n != 100 {
+		t.Fatalf("admitted %d, want 100", n)
+	}
+	if line.head != 0 || len(line.waiting) != 400 {
+		t.Fatalf("head %d of %d, want 0 of 400", line.head, len(line.waiting))
+	}
+
+	// Positions and admission order survive the cut
+	next := mustQueueTicket(t, q, line.waiting[0], queueStart)
+	if next.Number != 1101 || next.Position != 1 {
+		t.Errorf("next = number %d, position %d; want 1101, 1", next.Number, next.Position)
+	}
+	q.Admit(1, 1, queueStart, until)
+	if ticket := mustQueueTicket(t, q, next.Token, queueStart); ticket.Status != domain.QueueAdmitted {
+		t.Errorf("after the cut, %d = %s, want admitted", ticket.Number, ticket.Status)
+	}
+	if last := mustQueueTicket(t, q, line.waiting[len(line.waiting)-1], queueStart); last.Number != 1500 || last.Position != 399 {
+		t.Errorf("last = number %d, position %d; want 1500, 399", last.Number, last.Position)
+	}
+}
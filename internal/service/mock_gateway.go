@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"neptunes-tix/internal/domain"
+)
+
+// MockGateway stands in for Billplz during local development. It never leaves the
+// process, but signs and verifies payloads exactly like the real thing.
+type MockGateway struct {
+	signatureKey string
+	appURL       string
+}
+
+func NewMockGateway(appURL, signatureKey string) *MockGateway {
+	if signatureKey == "" {
+		signatureKey = "mock-billplz-signature-key"
+	}
+	return &MockGateway{signatureKey: signatureKey, appURL: strings.TrimRight(appURL, "/")}
+}
+
+func (g *MockGateway) CreateBill(order *domain.Order, user *domain.User) (*Bill, error) {
+	billID := fmt.Sprintf("mock-%d", order.ID)
+	return &Bill{
+		ID:  billID,
+		URL: fmt.Sprintf("%s/mock-billplz/%s", g.appURL, billID),
+	}, nil
+}
+
+func (g *MockGateway) VerifyCallback(params url.Values) (*PaymentResult, error) {
+	return verifyBillplzCallback(params, g.signatureKey)
+}
+
+func (g *MockGateway) VerifyRedirect(params url.Values) (*PaymentResult, error) {
+	return verifyBillplzRedirect(params, g.signatureKey)
+}
+
+// The mock settles refunds instantly; the reference is derived from the bill, so repeats are harmless
+func (g *MockGateway) Refund(order *domain.Order, refund *domain.Refund) (*RefundResult, error) {
+	return &RefundResult{
+		Reference: fmt.Sprintf("mock-refund-%s-%d", order.BillplzID, refund.ID),
+		Completed: true,
+	}, nil
+}
+
+// SimulateCallback builds the signed form Billplz would POST to our webhook.
+func (g *MockGateway) SimulateCallback(billID string, amount domain.Money, paid bool) url.Values {
+	state := "due"
+	paidAt := ""
+	if paid {
+		state = "paid"
+		paidAt = time.Now().Format("2006-01-02 15:04:05 -0700")
+	}
+
+	params := url.Values{}
+	params.Set("id", billID)
+	params.Set("collection_id", "mock")
+	params.Set("paid", fmt.Sprint(paid))
+	params.Set("state", state)
+	params.Set("amount", fmt.Sprint(amount.Sen))
+	params.Set("paid_amount", fmt.Sprint(amount.Sen))
+	params.Set("paid_at", paidAt)
+	params.Set("url", fmt.Sprintf("%s/mock-billplz/%s", g.appURL, billID))
+	params.Set("x_signature", billplzSignature(params, g.signatureKey))
+	return params
+}
+
+// SimulateRedirect builds the signed billplz[...] query string Billplz appends to redirect_url.
+func (g *MockGateway) SimulateRedirect(billID string, paid bool, paidAt string) url.Values {
+	signed := url.Values{}
+	signed.Set("billplzid", billID)
+	signed.Set("billplzpaid", fmt.Sprint(paid))
+	signed.Set("billplzpaid_at", paidAt)
+
+	params := url.Values{}
+	params.Set("billplz[id]", billID)
+	params.Set("billplz[paid]", fmt.Sprint(paid))
+	params.Set("billplz[paid_at]", paidAt)
+	params.Set("billplz[x_signature]", billplzSignature(signed, g.signatureKey))
+	return params
+}
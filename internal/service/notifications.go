@@ -0,0 +1,22 @@
+package service
+
+import "neptunes-tix/internal/domain"
+
+// notify drops a message in the user's in-app inbox. Like RecordLog it is best
+// effort and goes through the repo it is given: inside a transaction pass txRepo,
+// so a rollback (and the retry after it) doesn't leave the user a stale message.
+func (s *BookingService) notify(repo domain.TicketRepository, userID uint, title, body string) {
+	repo.CreateNotification(&domain.Notification{
+		UserID: userID,
+		Title:  title,
+		Body:   body,
+	})
+}
+
+func (s *BookingService) GetNotifications(userID uint) ([]domain.Notification, error) {
+	return s.repo.GetUserNotifications(userID)
+}
+
+func (s *BookingService) MarkNotificationsRead(userID uint) error {
+	return s.repo.MarkNotificationsRead(userID)
+}
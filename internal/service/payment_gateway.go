@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"neptunes-tix/internal/domain"
+)
+
+var ErrInvalidSignature = errors.New("invalid payment signature")
+
+// PaymentGateway is the contract every payment provider (Billplz, the local mock) must follow.
+type PaymentGateway interface {
+	// CreateBill registers the order with the provider and returns where the buyer should pay.
+	CreateBill(order *domain.Order, user *domain.User) (*Bill, error)
+	// VerifyCallback checks a server-to-server callback (form post) and extracts the result.
+	VerifyCallback(params url.Values) (*PaymentResult, error)
+	// VerifyRedirect checks the query string the buyer's browser lands on after paying.
+	VerifyRedirect(params url.Values) (*PaymentResult, error)
+	// Refund returns refund.Amount of a paid bill. An order can be refunded in several
+	// parts; calling it again for the same Refund must not pay out twice, since
+	// refunds can be resumed.
+	Refund(order *domain.Order, refund *domain.Refund) (*RefundResult, error)
+}
+
+type Bill struct {
+	ID  string
+	URL string
+}
+
+type PaymentResult struct {
+	BillID        string
+	TransactionID string // gateway's own transaction reference, when it sends one
+	Paid          bool
+	State         string
+	PaidAt        string
+}
+
+type RefundResult struct {
+	Reference string
+	Completed bool // false: accepted, but settled outside the gateway API (e.g. by finance)
+}
+
+// EventKey identifies one payment outcome. A callback and redirect for the same
+// payment share it, and so does any retry of either.
+func (r *PaymentResult) EventKey() string {
+	if r.TransactionID != "" {
+		return r.TransactionID
+	}
+	return fmt.Sprintf("%s:%t:%s", r.BillID, r.Paid, r.PaidAt)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// --- PROMO CODES (admin) ---
+
+func (s *BookingService) CreatePromoCode(req domain.PromoCodeRequest, actorID uint) (*domain.PromoCode, error) {
+	promo := &domain.PromoCode{CreatedBy: actorID}
+	if err := s.fillPromoCode(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePromoCode(promo); err != nil {
+		return nil, fmt.Errorf("could not save promo code %s (is the code already taken?)", promo.Code)
+	}
+	s.repo.RecordLog(actorID, "CREATE_PROMO", fmt.Sprint(promo.ID), promo.Describe())
+	return promo, nil
+}
+
+func (s *BookingService) UpdatePromoCode(id uint, req domain.PromoCodeRequest, actorID uint) (*domain.PromoCode, error) {
+	promo, err := s.repo.GetPromoCode(id)
+	if err != nil {
+		return nil, fmt.Errorf("promo code not found")
+	}
+	if err := s.fillPromoCode(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePromoCode(promo); err != nil {
+		return nil, fmt.Errorf("could not save promo code %s (is the code already taken?)", promo.Code)
+	}
+	s.repo.RecordLog(actorID, "UPDATE_PROMO", fmt.Sprint(promo.ID), promo.Describe())
+	return promo, nil
+}
+
+func (s *BookingService) DeletePromoCode(id uint, actorID uint) error {
+	promo, err := s.repo.GetPromoCode(id)
+	if err != nil {
+		return fmt.Errorf("promo code not found")
+	}
+	if err := s.repo.DeletePromoCode(id); err != nil {
+		return err
+	}
+	s.repo.RecordLog(actorID, "DELETE_PROMO", fmt.Sprint(id), promo.Code)
+	return nil
+}
+
+func (s *BookingService) ListPromoCodes() ([]domain.PromoCode, error) {
+	return s.repo.ListPromoCodes()
+}
+
+// GetPromoCode returns the code with what it has earned and given away so far
+func (s *BookingService) GetPromoCode(id uint) (*domain.PromoCode, *domain.PromoCodeStats, error) {
+	promo, err := s.repo.GetPromoCode(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("promo code not found")
+	}
+	stats, err := s.repo.GetPromoCodeStats(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return promo, stats, nil
+}
+
+// fillPromoCode validates the request and resolves its tier scope
+func (s *BookingService) fillPromoCode(promo *domain.PromoCode, req domain.PromoCodeRequest) error {
+	if err := req.ApplyTo(promo); err != nil {
+		return err
+	}
+	promo.Tiers = nil
+	if len(req.TierIDs) == 0 {
+		return nil
+	}
+
+	tiers, err := s.repo.GetTiersByIDs(req.TierIDs)
+	if err != nil {
+		return err
+	}
+	if len(tiers) != len(req.TierIDs) {
+		return fmt.Errorf("one or more tiers do not exist")
+	}
+	for _, tier := range tiers {
+		if promo.EventID != nil && tier.EventID != *promo.EventID {
+			return fmt.Errorf("tier %d belongs to another event", tier.ID)
+		}
+	}
+	promo.Tiers = tiers
+	return nil
+}
+
+// --- CHECKOUT ---
+
+// checkPromoCode checks the code against the order and the buyer and works out the
+// discount. The code's row stays locked until the checkout commits, so two buyers
+// can't both take the last use.
+func (s *BookingService) checkPromoCode(txRepo domain.TicketRepository, userID uint, order *domain.Order, code string) (*domain.PromoCode, domain.Money, error) {
+	promo, err := txRepo.GetPromoCodeForCheckout(code)
+	if err != nil {
+		return nil, domain.Sen(0), &domain.CheckoutError{Code: domain.CodePromoInvalid, Message: fmt.Sprintf("promo code %s does not exist", domain.NormalizePromoCode(code))}
+	}
+	if err := promo.CheckUsable(time.Now()); err != nil {
+		return nil, domain.Sen(0), err
+	}
+
+	if promo.MaxUses > 0 {
+		used, err := txRepo.CountPromoRedemptions(promo.ID, 0)
+		if err != nil {
+			return nil, domain.Sen(0), err
+		}
+		if used >= int64(promo.MaxUses) {
+			return nil, domain.Sen(0), &domain.CheckoutError{Code: domain.CodePromoUsedUp, Message: fmt.Sprintf("promo code %s has been fully redeemed", promo.Code)}
+		}
+	}
+	if promo.MaxUsesPerUser > 0 {
+		used, err := txRepo.CountPromoRedemptions(promo.ID, userID)
+		if err != nil {
+			return nil, domain.Sen(0), err
+		}
+		if used >= int64(promo.MaxUsesPerUser) {
+			return nil, domain.Sen(0), &domain.CheckoutError{Code: domain.CodePromoUserLimit, Message: fmt.Sprintf("you have already used promo code %s", promo.Code)}
+		}
+	}
+
+	amount, err := promo.DiscountFor(order.Items)
+	if err != nil {
+		return nil, domain.Sen(0), err
+	}
+	return promo, amount, nil
+}
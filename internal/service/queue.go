@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// JoinQueue gives the user a place in the event's virtual waiting room, or the one
+// they already have. Lines can form before the sale opens; nobody is admitted until
+// it does.
+func (s *BookingService) JoinQueue(userID, eventID uint) (*domain.QueueTicket, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	if !event.QueueEnabled {
+		return nil, fmt.Errorf("%s has no waiting room, go straight to checkout", event.Name)
+	}
+	if event.Status != domain.EventPublished && event.Status != domain.EventOnSale {
+		return nil, fmt.Errorf("tickets for %s are not on sale (%s)", event.Name, event.Status)
+	}
+	return s.queue.Join(eventID, userID, time.Now())
+}
+
+// GetQueueTicket is the user's ticket with its current position
+func (s *BookingService) GetQueueTicket(userID, eventID uint, token string) (*domain.QueueTicket, error) {
+	ticket, err := s.queue.Get(eventID, token, time.Now())
+	if err != nil || ticket.UserID != userID {
+		return nil, fmt.Errorf("queue ticket not found, it may have expired")
+	}
+	return ticket, nil
+}
+
+func (s *BookingService) LeaveQueue(userID, eventID uint, token string) error {
+	if _, err := s.GetQueueTicket(userID, eventID, token); err != nil {
+		return err
+	}
+	return s.queue.Leave(eventID, token)
+}
+
+// StartQueueAdmitter moves every waiting room along once a second, in this process
+func (s *BookingService) StartQueueAdmitter() {
+	go func() {
+		for range time.Tick(time.Second) {
+			s.admitQueues(time.Now())
+		}
+	}()
+}
+
+// admitQueues lets each event's next AdmitRate users into checkout. Lines of events
+// not yet on sale stay put; lines of events that dropped their waiting room (or ended)
+// are let through at once, checkout decides what they can still buy.
+func (s *BookingService) admitQueues(now time.Time) {
+	eventIDs, err := s.queue.Lines()
+	if err != nil {
+		log.Printf("queue: could not list lines: %v", err)
+		return
+	}
+	for _, eventID := range eventIDs {
+		n := math.MaxInt32
+		if event, err := s.repo.GetEventByID(eventID); err == nil && event.QueueEnabled {
+			n = event.AdmitRate()
+			if event.Status == domain.EventPublished {
+				n = 0
+			}
+		}
+		if _, err := s.queue.Admit(eventID, n, now, now.Add(domain.QueueAdmissionWindow)); err != nil {
+			log.Printf("queue: could not admit event %d: %v", eventID, err)
+		}
+	}
+}
+
+// checkQueueAdmission lets a checkout for an event with a waiting room through only
+// with one of the buyer's admitted queue tickets for it, and says which one ("" when
+// the event has no waiting room)
+func (s *BookingService) checkQueueAdmission(event *domain.Event, userID uint, tokens []string) (string, error) {
+	if !event.QueueEnabled {
+		return "", nil
+	}
+	now := time.Now()
+	for _, token := range tokens {
+		if ticket, err := s.queue.Get(event.ID, token, now); err == nil && ticket.UserID == userID && ticket.Admitted(now) {
+			return token, nil
+		}
+	}
+	return "", &domain.CheckoutError{Code: domain.CodeQueueRequired, Message: fmt.Sprintf(
+		"%s sells through a waiting room: join the queue and check out once you are admitted", event.Name)}
+}
+
+// queuePass carries the buyer's waiting room tokens through one checkout. placeOrder
+// spends the admissions it needs (one admission buys one order); if the checkout
+// fails after that, returnQueueAdmissions gives them back.
+type queuePass struct {
+	tokens []string
+	used   map[uint]string // event ID → token spent on this order
+}
+
+func newQueuePass(tokens []string) *queuePass {
+	return &queuePass{tokens: tokens, used: map[uint]string{}}
+}
+
+// useQueueAdmissions spends the tokens placeOrder matched, by event. It runs under
+// the buyer's row lock, so a second checkout racing this one finds them used.
+func (s *BookingService) useQueueAdmissions(pass *queuePass, matched map[uint]string) error {
+	now := time.Now()
+	for eventID, token := range matched {
+		if err := s.queue.Use(eventID, token, now); err != nil {
+			return &domain.CheckoutError{Code: domain.CodeQueueRequired, Message: "your waiting room admission has run out or was already used, join the queue again"}
+		}
+		pass.used[eventID] = token
+	}
+	return nil
+}
+
+// returnQueueAdmissions gives a failed checkout's admissions back, so the buyer can
+// try again without queueing again
+func (s *BookingService) returnQueueAdmissions(pass *queuePass) {
+	now := time.Now()
+	for eventID, token := range pass.used {
+		if err := s.queue.Unuse(eventID, token, now); err != nil {
+			log.Printf("queue: could not give event %d admission back: %v", eventID, err)
+		}
+	}
+	pass.used = map[uint]string{}
+}
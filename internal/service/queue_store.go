@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"neptunes-tix/internal/domain"
+)
+
+var ErrQueueTicketNotFound = errors.New("queue ticket not found")
+
+// QueueStore is the contract every waiting room backend (the in-process memory store,
+// a shared cache for several API instances) must follow. Lines are per event and
+// first come first served; the store only keeps order; BookingService decides how
+// fast the line moves.
+type QueueStore interface {
+	// Join puts the user at the back of the event's line, or returns the ticket they
+	// already hold there (waiting, or admitted and not expired). A used ticket doesn't
+	// count: buying again means queueing again.
+	Join(eventID, userID uint, now time.Time) (*domain.QueueTicket, error)
+	// Get looks a ticket up by token, with its current Position. Unknown, left and
+	// expired tokens give ErrQueueTicketNotFound.
+	Get(eventID uint, token string, now time.Time) (*domain.QueueTicket, error)
+	// Leave drops the ticket; nothing happens for unknown tokens.
+	Leave(eventID uint, token string) error
+	// Admit lets up to n waiting tickets in, front first, valid until expiresAt, and
+	// says how many it admitted. It also forgets admissions that ran out, so it is
+	// called for every line even when n is 0.
+	Admit(eventID uint, n int, now, expiresAt time.Time) (int, error)
+	// Use spends an admitted ticket on an order: it stays in the store, marked used,
+	// until its window closes. Anything but an admitted ticket gives
+	// ErrQueueTicketNotFound, so two checkouts can't both spend one admission.
+	Use(eventID uint, token string, now time.Time) error
+	// Unuse makes a used ticket admitted again, for a checkout that failed after
+	// spending it; nothing happens once its window has closed.
+	Unuse(eventID uint, token string, now time.Time) error
+	// Lines lists the events with anyone in their waiting room, admitted or not.
+	Lines() ([]uint, error)
+}
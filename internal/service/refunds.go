@@ -0,0 +1,501 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"neptunes-tix/internal/domain"
+	"slices"
+	"time"
+)
+
+// CancelPendingOrder lets a buyer walk away from an unpaid order. The held stock is
+// released straight away instead of waiting for the expiry sweep.
+func (s *BookingService) CancelPendingOrder(userID, orderID uint) error {
+	order, err := s.repo.GetOrderById(fmt.Sprint(orderID))
+	if err != nil || order.UserID != userID {
+		return fmt.Errorf("order not found")
+	}
+
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		// Conditional, so a payment landing at the same moment wins or loses cleanly
+		won, err := txRepo.TransitionOrderStatus(order.ID, []string{"pending"}, "cancelled")
+		if err != nil {
+			return err
+		}
+		if !won {
+			return fmt.Errorf("only unpaid orders can be cancelled")
+		}
+		released, err := txRepo.ReleaseOrderHolds(order.ID)
+		if err != nil {
+			return err
+		}
+		txRepo.RecordLog(userID, "ORDER_CANCEL", fmt.Sprint(order.ID), fmt.Sprintf("cancelled by buyer, %d tickets released", released))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Released tickets go to the waitlist first, if the tier has one
+	s.OfferWaitlists()
+	return nil
+}
+
+// RequestRefund opens a refund request for some tickets of a paid order (all of its
+// live tickets when ticketIDs is empty). Their events must still allow refunds (see
+// Event.CheckRefundable) and none of them may have been used.
+func (s *BookingService) RequestRefund(userID, orderID uint, ticketIDs []string, reason string) (*domain.RefundRequest, error) {
+	order, err := s.repo.GetOrderById(fmt.Sprint(orderID))
+	if err != nil || order.UserID != userID {
+		return nil, fmt.Errorf("order not found")
+	}
+	if order.Status == "refund_requested" {
+		return nil, fmt.Errorf("a refund has already been requested for this order")
+	}
+	if order.Status != "paid" && order.Status != "partially_refunded" {
+		return nil, fmt.Errorf("only paid orders can be refunded")
+	}
+
+	tickets, err := pickRefundTickets(order, ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	amounts := domain.TicketPaidAmounts(order)
+	amount := domain.Sen(0)
+	eventIDs := map[uint]bool{}
+	ticketIDs = ticketIDs[:0]
+	for _, ticket := range tickets {
+		if ticket.CheckedInAt != nil {
+			return nil, fmt.Errorf("ticket %s has already been used", ticket.ID)
+		}
+		if ticket.HolderUserID != nil && *ticket.HolderUserID != userID {
+			return nil, fmt.Errorf("ticket %s has been transferred to someone else", ticket.ID)
+		}
+		if _, err := s.repo.GetLiveListing(ticket.ID); err == nil {
+			return nil, fmt.Errorf("ticket %s is listed for resale, cancel the listing first", ticket.ID)
+		}
+		amount = amount.Add(amounts[ticket.ID])
+		eventIDs[ticket.EventID] = true
+		ticketIDs = append(ticketIDs, ticket.ID)
+	}
+
+	events, err := s.repo.GetOrderEvents(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range events {
+		if !eventIDs[events[i].ID] {
+			continue
+		}
+		if err := events[i].CheckRefundable(now); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &domain.RefundRequest{
+		OrderID:   order.ID,
+		UserID:    userID,
+		TicketIDs: ticketIDs,
+		Reason:    reason,
+		Status:    domain.RefundRequested,
+		Amount:    amount,
+	}
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		won, err := txRepo.TransitionOrderStatus(order.ID, []string{order.Status}, "refund_requested")
+		if err != nil {
+			return err
+		}
+		if !won {
+			return fmt.Errorf("order changed, please try again")
+		}
+		if err := txRepo.CreateRefundRequest(req); err != nil {
+			return err
+		}
+		txRepo.RecordLog(userID, "REFUND_REQUEST", fmt.Sprint(order.ID),
+			fmt.Sprintf("request #%d for %d tickets, %s: %s", req.ID, len(ticketIDs), req.Amount, reason))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *BookingService) ListRefundRequests(status string) ([]domain.RefundRequest, error) {
+	return s.repo.ListRefundRequests(status)
+}
+
+func (s *BookingService) GetUserRefundRequests(userID uint) ([]domain.RefundRequest, error) {
+	return s.repo.GetUserRefundRequests(userID)
+}
+
+// ApproveRefundRequest refunds the requested tickets and puts them back on sale. If
+// the gateway fails, the order is left in refund_processing and approving again
+// picks up from there.
+func (s *BookingService) ApproveRefundRequest(requestID, actorID uint, note string) (*domain.RefundRequest, error) {
+	req, order, err := s.openRefundRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	req.ReviewedBy = &actorID
+	req.ReviewNote = note
+	if err := s.repo.UpdateRefundRequest(req); err != nil {
+		return nil, err
+	}
+
+	if order.Status == "refund_processing" {
+		err = s.resumeRefund(order)
+	} else {
+		// Some tickets may have been refunded since (e.g. their event was cancelled)
+		var ticketIDs []string
+		for _, id := range req.TicketIDs {
+			if !refundedTicket(order, id) {
+				ticketIDs = append(ticketIDs, id)
+			}
+		}
+		_, err = s.refundTickets(order, refundSpec{
+			ticketIDs: ticketIDs,
+			from:      []string{"refund_requested"},
+			reason:    fmt.Sprintf("Refund request #%d", req.ID),
+			restock:   true,
+			requestID: &req.ID,
+			actorID:   actorID,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetRefundRequest(req.ID)
+}
+
+// RejectRefundRequest closes the request and the order goes back to how it was
+func (s *BookingService) RejectRefundRequest(requestID, actorID uint, note string) (*domain.RefundRequest, error) {
+	if note == "" {
+		return nil, fmt.Errorf("please give the buyer a reason")
+	}
+	req, order, err := s.openRefundRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		back := "paid"
+		if order.RefundedAmount.IsPositive() {
+			back = "partially_refunded"
+		}
+		won, err := txRepo.TransitionOrderStatus(order.ID, []string{"refund_requested"}, back)
+		if err != nil {
+			return err
+		}
+		if !won {
+			return fmt.Errorf("order #%d is already being refunded", order.ID)
+		}
+
+		now := time.Now()
+		req.Status = domain.RefundRejected
+		req.ReviewedBy = &actorID
+		req.ReviewNote = note
+		req.ReviewedAt = &now
+		if err := txRepo.UpdateRefundRequest(req); err != nil {
+			return err
+		}
+
+		txRepo.RecordLog(actorID, "REFUND_REJECT", fmt.Sprint(order.ID), fmt.Sprintf("request #%d: %s", req.ID, note))
+		s.notify(txRepo, order.UserID, "Refund request declined", fmt.Sprintf(
+			"Your refund request for order #%d was declined: %s. Your tickets are still valid.", order.ID, note))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *BookingService) openRefundRequest(requestID uint) (*domain.RefundRequest, *domain.Order, error) {
+	req, err := s.repo.GetRefundRequest(requestID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("refund request not found")
+	}
+	if req.Status != domain.RefundRequested {
+		return nil, nil, fmt.Errorf("refund request #%d is already %s", req.ID, req.Status)
+	}
+	order, err := s.repo.GetOrderById(fmt.Sprint(req.OrderID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("order not found")
+	}
+	return req, order, nil
+}
+
+// RefundOrderTickets is an admin refunding tickets of a paid order directly, e.g. at
+// the box office. With restock the tickets go back on sale.
+func (s *BookingService) RefundOrderTickets(orderID uint, ticketIDs []string, reason string, restock bool, actorID uint) (*domain.Refund, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+	order, err := s.repo.GetOrderById(fmt.Sprint(orderID))
+	if err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+	return s.refundTickets(order, refundSpec{
+		ticketIDs: ticketIDs,
+		from:      []string{"paid", "partially_refunded"},
+		reason:    reason,
+		restock:   restock,
+		actorID:   actorID,
+	})
+}
+
+// --- REFUND ENGINE ---
+
+// refundSpec is one refund to make
+type refundSpec struct {
+	ticketIDs []string // empty: every live ticket of the order
+	from      []string // order statuses the refund may start from
+	reason    string
+	restock   bool
+	requestID *uint
+	actorID   uint // 0 for the system
+}
+
+// refundTickets refunds tickets of a paid order in three steps:
+//
+//  1. the order moves to refund_processing and the Refund is saved with its amounts
+//     BEFORE money moves, so a crash resumes it (resumeRefund) instead of paying twice
+//  2. money back through the gateway (idempotent per Refund)
+//  3. one transaction settles the rest (see finishRefund)
+func (s *BookingService) refundTickets(order *domain.Order, spec refundSpec) (*domain.Refund, error) {
+	tickets, err := pickRefundTickets(order, spec.ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	refund := newRefund(order, tickets, spec)
+
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		won, err := txRepo.TransitionOrderStatus(order.ID, spec.from, "refund_processing")
+		if err != nil {
+			return err
+		}
+		if !won {
+			return fmt.Errorf("order #%d is %s and can't be refunded right now", order.ID, order.Status)
+		}
+		return txRepo.CreateRefund(refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.Status = "refund_processing"
+
+	return refund, s.finishRefund(order, refund)
+}
+
+// resumeRefund finishes the refund an order was left in refund_processing with.
+// Orders interrupted before refunds were itemised get one for all their live tickets.
+func (s *BookingService) resumeRefund(order *domain.Order) error {
+	refund, err := s.repo.GetProcessingRefund(order.ID)
+	if err != nil {
+		tickets, err := pickRefundTickets(order, nil)
+		if err != nil {
+			return err
+		}
+		refund = newRefund(order, tickets, refundSpec{reason: "Resumed refund"})
+		if err := s.repo.CreateRefund(refund); err != nil {
+			return err
+		}
+	}
+	return s.finishRefund(order, refund)
+}
+
+// finishRefund pays a saved Refund out and settles it in one transaction: tickets
+// refunded (and restocked), points adjusted, order totals and status, the refund
+// request it answers. Settling only happens once, so it is safe to repeat.
+func (s *BookingService) finishRefund(order *domain.Order, refund *domain.Refund) error {
+	// 1. Money back through the gateway
+	result, err := s.gateway.Refund(order, refund)
+	if err != nil {
+		return err
+	}
+
+	ticketIDs := make([]string, len(refund.Tickets))
+	for i, t := range refund.Tickets {
+		ticketIDs[i] = t.TicketID
+	}
+	live := 0
+	for _, ticket := range order.Tickets {
+		if ticket.Status != domain.TicketRefunded && !slices.Contains(ticketIDs, ticket.ID) {
+			live++
+		}
+	}
+	finalStatus := "partially_refunded"
+	if live == 0 {
+		finalStatus = "refunded"
+		if !result.Completed {
+			finalStatus = "refund_pending"
+		}
+	}
+
+	// 2. Settle everything together
+	return s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		// A buyer's open request for other tickets (e.g. this refund is an event
+		// cancellation) keeps waiting for review; one with nothing left to ask for closes
+		var overtaken *domain.RefundRequest
+		if refund.RefundRequestID == nil {
+			if req, err := txRepo.GetOpenRefundRequest(order.ID); err == nil {
+				overtaken = req
+				for _, id := range req.TicketIDs {
+					if live > 0 && !slices.Contains(ticketIDs, id) && !refundedTicket(order, id) {
+						overtaken, finalStatus = nil, "refund_requested"
+						break
+					}
+				}
+			}
+		}
+
+		won, err := txRepo.TransitionOrderStatus(order.ID, []string{"refund_processing"}, finalStatus)
+		if err != nil || !won {
+			return err
+		}
+		order.Status = finalStatus
+
+		if _, err := txRepo.RefundTickets(order.ID, ticketIDs, refund.Restock); err != nil {
+			return err
+		}
+		if err := s.withdrawListings(txRepo, ticketIDs); err != nil {
+			return err
+		}
+		refund.Status = domain.RefundCompleted
+		if !result.Completed {
+			refund.Status = domain.RefundPending
+		}
+		refund.Reference = result.Reference
+		if err := txRepo.UpdateRefund(refund); err != nil {
+			return err
+		}
+		if err := txRepo.AddOrderRefund(order.ID, refund.Amount, refund.PointsReversed, refund.PointsRestored); err != nil {
+			return err
+		}
+		if err := txRepo.UpdateOrderFields(order.ID, map[string]interface{}{"refund_ref": result.Reference}); err != nil {
+			return err
+		}
+
+		// Points earned on the refunded part go, points spent on it come back
+		if refund.PointsReversed > 0 {
+			if err := txRepo.IncrementUserPoints(order.UserID, -refund.PointsReversed, "Reversed: "+refund.Reason, &order.ID); err != nil {
+				return err
+			}
+		}
+		if refund.PointsRestored > 0 {
+			if err := txRepo.IncrementUserPoints(order.UserID, refund.PointsRestored, "Restored: "+refund.Reason, &order.ID); err != nil {
+				return err
+			}
+		}
+
+		if refund.RefundRequestID != nil {
+			if req, err := txRepo.GetRefundRequest(*refund.RefundRequestID); err == nil && req.Status == domain.RefundRequested {
+				now := time.Now()
+				req.Status = domain.RefundApproved
+				req.ReviewedAt = &now
+				req.RefundID = &refund.ID
+				if err := txRepo.UpdateRefundRequest(req); err != nil {
+					return err
+				}
+			}
+		} else if overtaken != nil {
+			now := time.Now()
+			overtaken.Status = domain.RefundApproved
+			overtaken.ReviewNote = refund.Reason
+			overtaken.ReviewedAt = &now
+			overtaken.RefundID = &refund.ID
+			if err := txRepo.UpdateRefundRequest(overtaken); err != nil {
+				return err
+			}
+		}
+
+		txRepo.RecordLog(refund.CreatedBy, "ORDER_REFUND", fmt.Sprint(order.ID),
+			fmt.Sprintf("refund #%d: %s for %d tickets (%s): %s", refund.ID, refund.Amount, len(ticketIDs), result.Reference, refund.Reason))
+		s.notify(txRepo, order.UserID, "Refund on its way", fmt.Sprintf(
+			"%s for %d ticket(s) of order #%d is being refunded to your original payment method (%s). Your points have been adjusted.",
+			refund.Amount, len(ticketIDs), order.ID, refund.Reason))
+		return nil
+	})
+}
+
+func refundedTicket(order *domain.Order, ticketID string) bool {
+	for _, ticket := range order.Tickets {
+		if ticket.ID == ticketID {
+			return ticket.Status == domain.TicketRefunded
+		}
+	}
+	return false
+}
+
+// pickRefundTickets resolves ticket IDs against the order's live tickets; none
+// means all of them
+func pickRefundTickets(order *domain.Order, ticketIDs []string) ([]domain.Ticket, error) {
+	var picked []domain.Ticket
+	for _, ticket := range order.Tickets {
+		if ticket.Status == domain.TicketRefunded {
+			continue
+		}
+		if len(ticketIDs) == 0 || slices.Contains(ticketIDs, ticket.ID) {
+			picked = append(picked, ticket)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, fmt.Errorf("no refundable tickets on this order")
+	}
+	if len(ticketIDs) > 0 && len(picked) != len(ticketIDs) {
+		return nil, fmt.Errorf("some tickets are not on this order or were already refunded")
+	}
+	return picked, nil
+}
+
+// newRefund prices a refund: each ticket gets back its share of what the order paid
+// (domain.TicketPaidAmounts), and points move pro rata to the money. The refund
+// that empties the order takes whatever is left, so rounding never strands a sen.
+func newRefund(order *domain.Order, tickets []domain.Ticket, spec refundSpec) *domain.Refund {
+	amounts := domain.TicketPaidAmounts(order)
+	refund := &domain.Refund{
+		OrderID:         order.ID,
+		RefundRequestID: spec.requestID,
+		Amount:          domain.Sen(0),
+		Reason:          spec.reason,
+		Restock:         spec.restock,
+		Status:          domain.RefundProcessing,
+		CreatedBy:       spec.actorID,
+	}
+	for _, ticket := range tickets {
+		refund.Tickets = append(refund.Tickets, domain.RefundTicket{TicketID: ticket.ID, Amount: amounts[ticket.ID]})
+		refund.Amount = refund.Amount.Add(amounts[ticket.ID])
+	}
+
+	remaining := order.TotalAmount.Sub(order.RefundedAmount).Sub(order.ResoldAmount)
+	live := 0
+	for _, ticket := range order.Tickets {
+		if ticket.Status != domain.TicketRefunded {
+			live++
+		}
+	}
+	if len(tickets) == live {
+		refund.Amount = remaining
+		refund.PointsReversed = order.PointsEarned
+		refund.PointsRestored = order.PointsApplied
+		return refund
+	}
+	if remaining.IsPositive() {
+		refund.PointsReversed = proRata(order.PointsEarned, refund.Amount.Sen, remaining.Sen)
+		refund.PointsRestored = proRata(order.PointsApplied, refund.Amount.Sen, remaining.Sen)
+	} else {
+		refund.PointsReversed = proRata(order.PointsEarned, int64(len(tickets)), int64(live))
+		refund.PointsRestored = proRata(order.PointsApplied, int64(len(tickets)), int64(live))
+	}
+	return refund
+}
+
+// proRata is n * num / den, rounded
+func proRata(n int, num, den int64) int {
+	if den == 0 {
+		return 0
+	}
+	return int(math.Round(float64(n) * float64(num) / float64(den)))
+}
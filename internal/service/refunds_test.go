@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+
+	"neptunes-tix/internal/domain"
+)
+
+// threeTicketOrder is one GA item of three tickets a, b and c; refundedIDs are already refunded
+func threeTicketOrder(total, refunded int64, pointsEarned, pointsApplied int, refundedIDs ...string) *domain.Order {
+	itemID := uint(1)
+	order := &domain.Order{
+		ID:             7,
+		TotalAmount:    domain.Sen(total),
+		RefundedAmount: domain.Sen(refunded),
+		PointsEarned:   pointsEarned,
+		PointsApplied:  pointsApplied,
+		Items:          []domain.OrderItem{{ID: itemID, EventID: 1, Category: "GA", Quantity: 3, Status: domain.HoldSold}},
+		Lines:          []domain.OrderLine{{Kind: domain.LineTicket, OrderItemID: &itemID, Amount: domain.Sen(9000)}},
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		status := domain.TicketSold
+		for _, r := range refundedIDs {
+			if r == id {
+				status = domain.TicketRefunded
+			}
+		}
+		order.Tickets = append(order.Tickets, domain.Ticket{ID: id, EventID: 1, Category: "GA", Status: status})
+	}
+	return order
+}
+
+func ticketsByID(order *domain.Order, ids ...string) []domain.Ticket {
+	var picked []domain.Ticket
+	for _, ticket := range order.Tickets {
+		for _, id := range ids {
+			if ticket.ID == id {
+				picked = append(picked, ticket)
+			}
+		}
+	}
+	return picked
+}
+
+func TestNewRefund(t *testing.T) {
+	tests := []struct {
+		name          string
+		order         *domain.Order
+		tickets       []string
+		wantAmount    int64
+		wantReversed  int
+		wantRestored  int
+		wantPerTicket map[string]int64
+	}{
+		{
+			name:          "one of three tickets",
+			order:         threeTicketOrder(9000, 0, 90, 30),
+			tickets:       []string{"a"},
+			wantAmount:    3000,
+			wantReversed:  30,
+			wantRestored:  10,
+			wantPerTicket: map[string]int64{"a": 3000},
+		},
+		{
+			name:          "points pro rata to the money still on the order",
+			order:         threeTicketOrder(9000, 3000, 60, 20, "a"),
+			tickets:       []string{"b"},
+			wantAmount:    3000,
+			wantReversed:  30,
+			wantRestored:  10,
+			wantPerTicket: map[string]int64{"b": 3000},
+		},
+		{
+			name:          "the refund that empties the order takes what is left",
+			order:         threeTicketOrder(9001, 3000, 61, 21, "a"),
+			tickets:       []string{"b", "c"},
+			wantAmount:    6001,
+			wantReversed:  61,
+			wantRestored:  21,
+			wantPerTicket: map[string]int64{"b": 3000, "c": 3001},
+		},
+		{
+			name:          "order paid entirely with points: points move by ticket count",
+			order:         threeTicketOrder(0, 0, 0, 900),
+			tickets:       []string{"c"},
+			wantAmount:    0,
+			wantReversed:  0,
+			wantRestored:  300,
+			wantPerTicket: map[string]int64{"c": 0},
+		},
+	}
+
+	requestID := uint(4)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := refundSpec{reason: "test", restock: true, requestID: &requestID, actorID: 9}
+			refund := newRefund(tt.order, ticketsByID(tt.order, tt.tickets...), spec)
+
+			if refund.Amount.Sen != tt.wantAmount {
+				t.Errorf("Amount = %s, want %s", refund.Amount, domain.Sen(tt.wantAmount))
+			}
+			if refund.PointsReversed != tt.wantReversed || refund.PointsRestored != tt.wantRestored {
+				t.Errorf("points reversed %d, restored %d; want %d, %d", refund.PointsReversed, refund.PointsRestored, tt.wantReversed, tt.wantRestored)
+			}
+			if len(refund.Tickets) != len(tt.wantPerTicket) {
+				t.Fatalf("refund covers %d tickets, want %d", len(refund.Tickets), len(tt.wantPerTicket))
+			}
+			for _, rt := range refund.Tickets {
+				if want, ok := tt.wantPerTicket[rt.TicketID]; !ok || rt.Amount.Sen != want {
+					t.Errorf("ticket %s: %s, want %s", rt.TicketID, rt.Amount, domain.Sen(want))
+				}
+			}
+			if refund.OrderID != tt.order.ID || refund.Status != domain.RefundProcessing || !refund.Restock ||
+				refund.RefundRequestID != &requestID || refund.CreatedBy != 9 || refund.Reason != "test" {
+				t.Errorf("refund = %+v, want the spec copied onto a processing refund", refund)
+			}
+		})
+	}
+}
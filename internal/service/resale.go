@@ -0,0 +1,313 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListTicketForResale offers one of the user's paid, unused tickets to other fans at
+// no more than the event's cap. Their QR code for it stops working until the listing
+// is cancelled, so it can't be sold and used at the same time.
+func (s *BookingService) ListTicketForResale(userID uint, ticketID string, price domain.Money) (*domain.ResaleListing, error) {
+	if _, err := uuid.Parse(ticketID); err != nil {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("price must be above zero")
+	}
+
+	var listing *domain.ResaleListing
+	err := s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		// 1. Lock the ticket so a scan, transfer or refund can't slip in meanwhile
+		ticket, err := txRepo.GetTicketForUpdate(ticketID)
+		if err != nil || ticket.HolderUserID == nil || *ticket.HolderUserID != userID {
+			return fmt.Errorf("ticket not found")
+		}
+		if err := checkTransferable(txRepo, ticket); err != nil {
+			return err
+		}
+		if _, err := txRepo.GetPendingTransfer(ticket.ID); err == nil {
+			return fmt.Errorf("this ticket has a pending transfer, cancel it first")
+		}
+
+		// 2. The organiser's rules: resale switched on, price within the cap
+		event, err := txRepo.GetEventByID(ticket.EventID)
+		if err != nil {
+			return fmt.Errorf("event not found")
+		}
+		if err := event.CheckResaleOpen(time.Now()); err != nil {
+			return err
+		}
+		if maxPrice := event.ResalePriceCap(ticket.Price); maxPrice.Less(price) {
+			return fmt.Errorf("resale price for %s is capped at %s (%d%% of %s)", event.Name, maxPrice, event.ResaleCapPercent, ticket.Price)
+		}
+
+		listing = &domain.ResaleListing{
+			TicketID:  ticket.ID,
+			EventID:   ticket.EventID,
+			Category:  ticket.Category,
+			SeatLabel: ticket.SeatLabel,
+			SellerID:  userID,
+			FaceValue: ticket.Price,
+			Price:     price,
+			Status:    domain.ListingActive,
+		}
+		if err := txRepo.CreateResaleListing(listing); err != nil {
+			return err
+		}
+		txRepo.RecordLog(userID, "RESALE_LIST", ticket.ID, fmt.Sprintf("listing #%d at %s (face %s)", listing.ID, price, ticket.Price))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return listing, nil
+}
+
+// CancelResaleListing takes the seller's listing off the market; a fresh QR code can
+// be fetched again. Listings a buyer is paying for can't be withdrawn.
+func (s *BookingService) CancelResaleListing(userID, listingID uint) (*domain.ResaleListing, error) {
+	listing, err := s.repo.GetResaleListing(listingID)
+	if err != nil || listing.SellerID != userID {
+		return nil, fmt.Errorf("listing not found")
+	}
+	won, err := s.repo.TransitionListing(listing.ID, domain.ListingActive, domain.ListingCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		if listing.Status == domain.ListingReserved {
+			return nil, fmt.Errorf("a buyer is checking out this ticket right now")
+		}
+		return nil, fmt.Errorf("listing is already %s", listing.Status)
+	}
+	s.repo.RecordLog(userID, "RESALE_CANCEL", listing.TicketID, fmt.Sprintf("listing #%d", listing.ID))
+	listing.Status = domain.ListingCancelled
+	return listing, nil
+}
+
+// GetResaleListings is the fan-to-fan market; eventID 0 lists every event
+func (s *BookingService) GetResaleListings(eventID uint) ([]domain.ResaleListing, error) {
+	return s.repo.ListResaleListings(eventID)
+}
+
+// GetUserResale is the seller's side: their listings and what they are owed
+func (s *BookingService) GetUserResale(userID uint) ([]domain.ResaleListing, []domain.ResalePayout, error) {
+	listings, err := s.repo.GetUserListings(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	payouts, err := s.repo.GetUserPayouts(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return listings, payouts, nil
+}
+
+func (s *BookingService) ListResalePayouts(status string) ([]domain.ResalePayout, error) {
+	return s.repo.ListResalePayouts(status)
+}
+
+// MarkPayoutPaid records that finance has paid the seller
+func (s *BookingService) MarkPayoutPaid(payoutID, actorID uint, reference string) (*domain.ResalePayout, error) {
+	if reference == "" {
+		return nil, fmt.Errorf("a payment reference is required")
+	}
+	payout, err := s.repo.GetResalePayout(payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("payout not found")
+	}
+	if payout.Status != domain.PayoutPending {
+		return nil, fmt.Errorf("payout is already %s", payout.Status)
+	}
+
+	now := time.Now()
+	payout.Status = domain.PayoutPaid
+	payout.Reference = reference
+	payout.PaidAt = &now
+	if err := s.repo.UpdateResalePayout(payout); err != nil {
+		return nil, err
+	}
+	s.repo.RecordLog(actorID, "RESALE_PAYOUT", fmt.Sprint(payout.ID), fmt.Sprintf("%s to user #%d (%s)", payout.Amount, payout.SellerID, reference))
+	s.notify(s.repo, payout.SellerID, "Resale payout sent", fmt.Sprintf("%s for your resold ticket has been paid out (ref %s).", payout.Amount, reference))
+	return payout, nil
+}
+
+// reserveListing holds a listing for the order being placed (see placeOrder)
+func (s *BookingService) reserveListing(txRepo domain.TicketRepository, order *domain.Order, eventID, listingID uint) (*domain.OrderItem, error) {
+	listing, err := txRepo.GetResaleListing(listingID)
+	if err != nil || listing.EventID != eventID {
+		return nil, fmt.Errorf("resale listing #%d not found", listingID)
+	}
+	if listing.SellerID == order.UserID {
+		return nil, fmt.Errorf("you can't buy your own listing")
+	}
+	item, won, err := txRepo.ReserveResaleListing(listing.ID, order.ID, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		return nil, fmt.Errorf("resale listing #%d is no longer available", listingID)
+	}
+	return item, nil
+}
+
+// completeResales hands paid-for resale tickets to the buyer (runs inside finalizeOrder):
+// the ticket moves to the buyer's account and order with a new credential, the
+// seller's original order books the ticket's share as resold and they get a payout.
+// A ticket that can't be delivered any more is refunded to the buyer instead.
+func (s *BookingService) completeResales(txRepo domain.TicketRepository, order *domain.Order) error {
+	items, err := txRepo.GetOrderItems(order.ID)
+	if err != nil {
+		return err
+	}
+	undelivered := 0
+	for _, item := range items {
+		if item.ResaleListingID == nil || item.Status != domain.HoldSold {
+			continue
+		}
+		listing, err := txRepo.GetResaleListing(*item.ResaleListingID)
+		if err != nil {
+			return err
+		}
+
+		// 1. What the seller paid for it, before it leaves their order
+		ticket, err := txRepo.GetByID(listing.TicketID)
+		if err != nil {
+			return err
+		}
+		var sellerOrder *domain.Order
+		share := domain.Sen(0)
+		if ticket.OrderID != nil {
+			if sellerOrder, err = txRepo.GetOrderById(fmt.Sprint(*ticket.OrderID)); err != nil {
+				return err
+			}
+			share = domain.TicketPaidAmounts(sellerOrder)[ticket.ID]
+		}
+
+		// 2. Hand it over
+		won, err := txRepo.SellResaleListing(listing, order.ID, order.UserID)
+		if err != nil {
+			return err
+		}
+		if !won {
+			// The ticket stopped being sellable while the buyer paid (e.g. it was used at
+			// the door via a staff lookup). The money is in, so it goes back to them.
+			if _, err := txRepo.TransitionListing(listing.ID, domain.ListingReserved, domain.ListingCancelled); err != nil {
+				return err
+			}
+			refund, err := s.refundUndelivered(txRepo, order, item.ID, listing)
+			if err != nil {
+				return err
+			}
+			undelivered++
+			txRepo.RecordLog(0, "RESALE_FAILED", fmt.Sprint(order.ID),
+				fmt.Sprintf("listing #%d could not be delivered; refund #%d for %s", listing.ID, refund.ID, refund.Amount))
+			s.notify(txRepo, order.UserID, "Resale ticket unavailable", fmt.Sprintf(
+				"The %s ticket from listing #%d could not be delivered. %s will be refunded to you.", listing.Category, listing.ID, refund.Amount))
+			continue
+		}
+		if sellerOrder != nil {
+			if err := txRepo.AddOrderResold(sellerOrder.ID, share); err != nil {
+				return err
+			}
+		}
+		ticket.HolderUserID = &order.UserID
+		ticket.OrderID = &order.ID
+		order.Tickets = append(order.Tickets, *ticket)
+
+		// 3. The seller is owed the listing price
+		payout := &domain.ResalePayout{
+			ListingID: listing.ID,
+			SellerID:  listing.SellerID,
+			OrderID:   order.ID,
+			Amount:    listing.Price,
+			Status:    domain.PayoutPending,
+		}
+		if err := txRepo.CreateResalePayout(payout); err != nil {
+			return err
+		}
+		txRepo.RecordLog(order.UserID, "RESALE_SOLD", ticket.ID,
+			fmt.Sprintf("listing #%d: user #%d → user #%d for %s, payout #%d", listing.ID, listing.SellerID, order.UserID, listing.Price, payout.ID))
+		s.notify(txRepo, listing.SellerID, "Your ticket was resold", fmt.Sprintf(
+			"Your %s ticket for %s sold for %s. The payout will follow shortly.", listing.Category, listing.Event.Name, listing.Price))
+	}
+	if undelivered == 0 {
+		return nil
+	}
+
+	// Nothing delivered at all: the whole order waits on its refunds
+	status := "partially_refunded"
+	if len(order.Tickets) == 0 {
+		status = "refund_pending"
+	}
+	if _, err := txRepo.TransitionOrderStatus(order.ID, []string{"paid"}, status); err != nil {
+		return err
+	}
+	order.Status = status
+	return nil
+}
+
+// refundUndelivered refunds the buyer for a resale item they paid for but can't be
+// given. Nothing goes through the gateway inside the payment transaction, so the
+// Refund is saved as pending for finance to pay out and booked on the order now.
+// Points move pro rata to the money, before finalizeOrder settles them.
+func (s *BookingService) refundUndelivered(txRepo domain.TicketRepository, order *domain.Order, itemID uint, listing *domain.ResaleListing) (*domain.Refund, error) {
+	paid, err := txRepo.GetOrderById(fmt.Sprint(order.ID))
+	if err != nil {
+		return nil, err
+	}
+	amount := domain.ItemPaidAmount(paid, itemID)
+	remaining := order.TotalAmount.Sub(order.RefundedAmount)
+	refund := &domain.Refund{
+		OrderID:        order.ID,
+		Amount:         amount,
+		PointsReversed: proRata(order.PointsEarned, amount.Sen, remaining.Sen),
+		PointsRestored: proRata(order.PointsApplied, amount.Sen, remaining.Sen),
+		Reason:         fmt.Sprintf("Resale listing #%d could not be delivered", listing.ID),
+		Status:         domain.RefundPending,
+	}
+	if err := txRepo.CreateRefund(refund); err != nil {
+		return nil, err
+	}
+	if err := txRepo.AddOrderRefund(order.ID, refund.Amount, refund.PointsReversed, refund.PointsRestored); err != nil {
+		return nil, err
+	}
+	order.RefundedAmount = order.RefundedAmount.Add(refund.Amount)
+	order.PointsEarned -= refund.PointsReversed
+	order.PointsApplied -= refund.PointsRestored
+	return refund, nil
+}
+
+// withdrawListings takes refunded tickets off resale. A buyer in the middle of paying
+// for one has their order cancelled, so a late payment is flagged for a refund.
+func (s *BookingService) withdrawListings(txRepo domain.TicketRepository, ticketIDs []string) error {
+	listings, err := txRepo.CancelTicketListings(ticketIDs)
+	if err != nil {
+		return err
+	}
+	for _, listing := range listings {
+		if listing.Status != domain.ListingReserved || listing.OrderID == nil {
+			continue
+		}
+		won, err := txRepo.TransitionOrderStatus(*listing.OrderID, []string{"pending"}, "cancelled")
+		if err != nil {
+			return err
+		}
+		if !won {
+			continue
+		}
+		if _, err := txRepo.ReleaseOrderHolds(*listing.OrderID); err != nil {
+			return err
+		}
+		txRepo.RecordLog(0, "ORDER_CANCEL", fmt.Sprint(*listing.OrderID), fmt.Sprintf("resale listing #%d withdrawn: ticket refunded", listing.ID))
+		if listing.BuyerID != nil {
+			s.notify(txRepo, *listing.BuyerID, "Resale ticket withdrawn", fmt.Sprintf(
+				"The %s ticket you were buying is no longer available, so order #%d was cancelled.", listing.Category, *listing.OrderID))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How far ahead of the server clock a device's scanned_at may be before we distrust it
+const maxScannerClockSkew = 5 * time.Minute
+
+// Per-scan outcomes reported back to the device
+const (
+	ScanAdmitted  = "admitted"
+	ScanDuplicate = "duplicate" // this device already uploaded this exact scan
+	ScanConflict  = "conflict"  // another device admitted the same ticket
+	ScanRejected  = "rejected"
+)
+
+type ScannerManifest struct {
+	EventID     uint                    `json:"event_id"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Keys        map[string]string       `json:"keys"` // kid → base64 Ed25519 public key
+	Tickets     []ScannerManifestTicket `json:"tickets"`
+}
+
+type ScannerManifestTicket struct {
+	ID              string     `json:"id"`
+	Category        string     `json:"category"`
+	Status          string     `json:"status"` // anything but "sold" is revoked: drop it from the device
+	CheckedInAt     *time.Time `json:"checked_in_at"`
+	CheckedInDevice string     `json:"checked_in_device,omitempty"`
+	Version         int        `json:"credential_version"` // credentials with another "ver" were reissued
+}
+
+type OfflineScan struct {
+	Credential string    `json:"credential"` // signed QR payload, verified again on upload
+	TicketID   string    `json:"ticket_id"`  // optional, must match the credential
+	ScannedAt  time.Time `json:"scanned_at" binding:"required"`
+}
+
+type ScanBatch struct {
+	DeviceID string        `json:"device_id" binding:"required"`
+	Gate     string        `json:"gate"`
+	Scans    []OfflineScan `json:"scans" binding:"required,gt=0"`
+}
+
+type ScanSyncResult struct {
+	TicketID  string    `json:"ticket_id"`
+	ScannedAt time.Time `json:"scanned_at"`
+	Result    string    `json:"result"`
+	Reason    string    `json:"reason,omitempty"`
+
+	// Filled in for conflicts: the scan that now stands as the official admission
+	AdmittedAt     *time.Time `json:"admitted_at,omitempty"`
+	AdmittedDevice string     `json:"admitted_device,omitempty"`
+
+	scanResult string // what goes in the ScanEvent log
+}
+
+type ScanSyncReport struct {
+	Admitted  int              `json:"admitted"`
+	Conflicts int              `json:"conflicts"`
+	Rejected  int              `json:"rejected"`
+	Results   []ScanSyncResult `json:"results"`
+}
+
+// GetScannerManifest is what a gate device downloads before going offline. Pass a
+// non-zero `since` to only get tickets that changed after the previous download;
+// that delta also carries tickets refunded or voided since, with their new status.
+func (s *BookingService) GetScannerManifest(eventID uint, since time.Time) (*ScannerManifest, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	if !event.AllowsCheckIn() {
+		return nil, fmt.Errorf("event is %s, check-in is closed", event.Status)
+	}
+
+	generatedAt := time.Now()
+	tickets, err := s.repo.GetScannerManifest(eventID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ScannerManifest{
+		EventID:     eventID,
+		GeneratedAt: generatedAt,
+		Keys:        s.signer.PublicKeys(),
+		Tickets:     make([]ScannerManifestTicket, 0, len(tickets)),
+	}
+	for _, t := range tickets {
+		manifest.Tickets = append(manifest.Tickets, ScannerManifestTicket{
+			ID:              t.ID,
+			Category:        t.Category,
+			Status:          t.Status,
+			CheckedInAt:     t.CheckedInAt,
+			CheckedInDevice: t.CheckedInDevice,
+			Version:         t.CredentialVersion,
+		})
+	}
+	return manifest, nil
+}
+
+// SyncScans applies a batch of offline scans. When two devices admitted the same ticket
+// at a single-entry event, the earliest scan wins and becomes the ticket's CheckedInAt;
+// the loser is reported back as a conflict and written to the audit log. Events that
+// allow re-entry have no conflicts, only the earliest admission time is kept.
+func (s *BookingService) SyncScans(eventID uint, operatorID uint, batch ScanBatch) (*ScanSyncReport, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	scanner := Scanner{OperatorID: operatorID, Gate: batch.Gate, DeviceID: batch.DeviceID}
+	report := &ScanSyncReport{Results: make([]ScanSyncResult, 0, len(batch.Scans))}
+
+	for _, scan := range batch.Scans {
+		result := s.applyOfflineScan(event, scanner, scan)
+
+		if result.Result != ScanDuplicate {
+			s.repo.RecordScanEvent(&domain.ScanEvent{
+				TicketID:   result.TicketID,
+				EventID:    eventID,
+				Result:     result.scanResult,
+				Reason:     result.Reason,
+				Gate:       batch.Gate,
+				DeviceID:   batch.DeviceID,
+				OperatorID: operatorID,
+				Offline:    true,
+				ScannedAt:  scan.ScannedAt,
+			})
+		}
+
+		switch result.Result {
+		case ScanAdmitted, ScanDuplicate:
+			report.Admitted++
+		case ScanConflict:
+			report.Conflicts++
+			s.repo.RecordLog(operatorID, "SCAN_CONFLICT", result.TicketID, fmt.Sprintf(
+				"Device %s scanned at %s; admission stands for %s at %s",
+				batch.DeviceID, result.ScannedAt.Format(time.RFC3339),
+				result.AdmittedDevice, result.AdmittedAt.Format(time.RFC3339)))
+		default:
+			report.Rejected++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	s.repo.RecordLog(operatorID, "SCANNER_SYNC", fmt.Sprint(eventID), fmt.Sprintf(
+		"Device %s uploaded %d scans: %d admitted, %d conflicts, %d rejected",
+		batch.DeviceID, len(batch.Scans), report.Admitted, report.Conflicts, report.Rejected))
+
+	return report, nil
+}
+
+func (s *BookingService) applyOfflineScan(event *domain.Event, scanner Scanner, scan OfflineScan) ScanSyncResult {
+	result := ScanSyncResult{TicketID: scan.TicketID, ScannedAt: scan.ScannedAt, scanResult: domain.ScanResultInvalid}
+	deviceID := scanner.DeviceID
+
+	reject := func(reason string) ScanSyncResult {
+		result.Result = ScanRejected
+		result.Reason = reason
+		return result
+	}
+
+	// 1. Work out which ticket this is. Only the signature says so: a bare ID skips the
+	// credential version, so a reissued (transferred, resold) ticket's old QR would pass.
+	if scan.Credential == "" {
+		return reject("a signed credential is required")
+	}
+	claims, err := s.signer.Verify(scan.Credential)
+	if err != nil {
+		return reject(err.Error())
+	}
+	if scan.TicketID != "" && scan.TicketID != claims.TicketID {
+		return reject("ticket_id does not match credential")
+	}
+	result.TicketID = claims.TicketID
+	version := claims.Version
+	// Checked against the event as it is now, not when the device went offline
+	if !event.AllowsCheckIn() {
+		return reject(fmt.Sprintf("EVENT %s: Check-in is closed", strings.ToUpper(event.Status)))
+	}
+	if _, err := uuid.Parse(result.TicketID); err != nil {
+		return reject("ticket not found")
+	}
+	if scan.ScannedAt.After(time.Now().Add(maxScannerClockSkew)) {
+		return reject("scanned_at is in the future, check the device clock")
+	}
+
+	// 2. Resolve against the current admission under a row lock
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		ticket, err := txRepo.GetTicketForUpdate(result.TicketID)
+		if err != nil {
+			return errors.New("ticket not found")
+		}
+		if ticket.EventID != event.ID {
+			result.scanResult = domain.ScanResultWrongEvent
+			return fmt.Errorf("WRONG EVENT: This ticket is for event #%d", ticket.EventID)
+		}
+		if ticket.Status == domain.TicketVoided || ticket.Status == domain.TicketRefunded {
+			return fmt.Errorf("INVALID: This ticket has been %s.", ticket.Status)
+		}
+		if !ticket.IsSold || ticket.Status != domain.TicketSold {
+			result.scanResult = domain.ScanResultUnpaid
+			return errors.New("INVALID: This ticket has not been paid for.")
+		}
+		if ticket.CredentialVersion != version {
+			return errSupersededCredential
+		}
+
+		switch {
+		case ticket.CheckedInAt == nil:
+			result.Result = ScanAdmitted
+			result.scanResult = domain.ScanResultAdmitted
+
+		case ticket.CheckedInDevice == deviceID && ticket.CheckedInAt.Equal(scan.ScannedAt):
+			// Re-upload of a batch we've already applied
+			result.Result = ScanDuplicate
+			return nil
+
+		case event.EntryPolicy != domain.EntryPolicySingle:
+			// Re-entry is allowed, so a second admission is not a conflict
+			result.Result = ScanAdmitted
+			result.scanResult = domain.ScanResultReentered
+			if !scan.ScannedAt.Before(*ticket.CheckedInAt) {
+				return nil
+			}
+
+		case scan.ScannedAt.Before(*ticket.CheckedInAt):
+			// We were physically first: take over the admission, the other scan becomes the conflict
+			result.Result = ScanConflict
+			result.scanResult = domain.ScanResultAdmitted
+			result.Reason = fmt.Sprintf("superseded later scan by %s", deviceLabel(ticket.CheckedInDevice))
+
+		default:
+			result.Result = ScanConflict
+			result.scanResult = domain.ScanResultAlreadyUsed
+			result.Reason = "ALREADY USED"
+			result.AdmittedAt = ticket.CheckedInAt
+			result.AdmittedDevice = deviceLabel(ticket.CheckedInDevice)
+			return nil
+		}
+
+		if err := txRepo.RecordCheckIn(ticket.ID, scan.ScannedAt, scanner.OperatorID, deviceID, scanner.Gate); err != nil {
+			return err
+		}
+		if result.Result == ScanConflict {
+			admittedAt := scan.ScannedAt
+			result.AdmittedAt = &admittedAt
+			result.AdmittedDevice = deviceID
+		}
+		return nil
+	})
+	if err != nil {
+		return reject(err.Error())
+	}
+	return result
+}
+
+func deviceLabel(deviceID string) string {
+	if deviceID == "" {
+		return "online scanner"
+	}
+	return deviceID
+}
@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// Scans a device took while offline are judged against the ticket and event as they
+// are at upload time
+func TestSyncScansRejectsTicketsNoLongerValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *gorm.DB, repo domain.TicketRepository, event *domain.Event)
+	}{
+		{
+			name: "voided ticket",
+			setup: func(t *testing.T, db *gorm.DB, repo domain.TicketRepository, event *domain.Event) {
+				if _, err := repo.VoidUnscannedTickets(event.ID); err != nil {
+					t.Fatalf("void tickets: %v", err)
+				}
+			},
+		},
+		{
+			name: "cancelled event",
+			setup: func(t *testing.T, db *gorm.DB, repo domain.TicketRepository, event *domain.Event) {
+				if err := db.Model(event).Update("status", domain.EventCancelled).Error; err != nil {
+					t.Fatalf("cancel event: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, repo := openTestDB(t)
+			svc, signer := newTestService(t, repo)
+			event := newTestEvent(t, db, repo, 1)
+			buyer := newTestUser(t, repo)
+
+			ticket := &domain.Ticket{
+				EventID:      event.ID,
+				Category:     "GA",
+				Price:        domain.Sen(5000),
+				IsSold:       true,
+				Status:       domain.TicketSold,
+				HolderUserID: &buyer.ID,
+			}
+			if err := repo.CreateTicket(ticket); err != nil {
+				t.Fatalf("create ticket: %v", err)
+			}
+			credential, err := signer.Issue(ticket)
+			if err != nil {
+				t.Fatalf("issue credential: %v", err)
+			}
+			tt.setup(t, db, repo, event)
+
+			report, err := svc.SyncScans(event.ID, buyer.ID, service.ScanBatch{
+				DeviceID: "device-1",
+				Scans:    []service.OfflineScan{{Credential: credential, ScannedAt: time.Now().Add(-time.Minute)}},
+			})
+			if err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			if report.Admitted != 0 || report.Rejected != 1 {
+				t.Fatalf("report = %+v, want the scan rejected", report)
+			}
+
+			stored, err := repo.GetByID(ticket.ID)
+			if err != nil {
+				t.Fatalf("reload ticket: %v", err)
+			}
+			if stored.CheckedInAt != nil {
+				t.Fatal("ticket was marked checked in")
+			}
+		})
+	}
+}
+
+// A device that synced before a ticket was revoked must learn about it from the delta,
+// or it keeps admitting the ticket from its cached manifest
+func TestScannerManifestDeltaRevokesTickets(t *testing.T) {
+	tests := []struct {
+		name       string
+		revoke     func(t *testing.T, repo domain.TicketRepository, event *domain.Event, ticket *domain.Ticket)
+		wantStatus string
+	}{
+		{
+			name: "refunded",
+			revoke: func(t *testing.T, repo domain.TicketRepository, event *domain.Event, ticket *domain.Ticket) {
+				if _, err := repo.RefundTickets(*ticket.OrderID, []string{ticket.ID}, false); err != nil {
+					t.Fatalf("refund ticket: %v", err)
+				}
+			},
+			wantStatus: domain.TicketRefunded,
+		},
+		{
+			name: "voided",
+			revoke: func(t *testing.T, repo domain.TicketRepository, event *domain.Event, ticket *domain.Ticket) {
+				if _, err := repo.VoidUnscannedTickets(event.ID); err != nil {
+					t.Fatalf("void tickets: %v", err)
+				}
+			},
+			wantStatus: domain.TicketVoided,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, repo := openTestDB(t)
+			svc, _ := newTestService(t, repo)
+			event := newTestEvent(t, db, repo, 1)
+			buyer := newTestUser(t, repo)
+
+			order := &domain.Order{UserID: buyer.ID, TotalAmount: domain.Sen(5000), Status: "paid"}
+			if err := db.Create(order).Error; err != nil {
+				t.Fatalf("create order: %v", err)
+			}
+			ticket := &domain.Ticket{
+				EventID:      event.ID,
+				Category:     "GA",
+				Price:        domain.Sen(5000),
+				IsSold:       true,
+				Status:       domain.TicketSold,
+				OrderID:      &order.ID,
+				HolderUserID: &buyer.ID,
+			}
+			if err := repo.CreateTicket(ticket); err != nil {
+				t.Fatalf("create ticket: %v", err)
+			}
+
+			full, err := svc.GetScannerManifest(event.ID, time.Time{})
+			if err != nil {
+				t.Fatalf("full manifest: %v", err)
+			}
+			if len(full.Tickets) != 1 || full.Tickets[0].Status != domain.TicketSold {
+				t.Fatalf("full manifest = %+v, want the one sold ticket", full.Tickets)
+			}
+
+			tt.revoke(t, repo, event, ticket)
+
+			delta, err := svc.GetScannerManifest(event.ID, full.GeneratedAt)
+			if err != nil {
+				t.Fatalf("delta manifest: %v", err)
+			}
+			if len(delta.Tickets) != 1 || delta.Tickets[0].ID != ticket.ID || delta.Tickets[0].Status != tt.wantStatus {
+				t.Fatalf("delta = %+v, want ticket %s as %s", delta.Tickets, ticket.ID, tt.wantStatus)
+			}
+
+			again, err := svc.GetScannerManifest(event.ID, time.Time{})
+			if err != nil {
+				t.Fatalf("second full manifest: %v", err)
+			}
+			if len(again.Tickets) != 0 {
+				t.Fatalf("full manifest = %+v, want revoked tickets left out", again.Tickets)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"slices"
+)
+
+// CreateVenue saves a venue and builds the seats of every seat map in the request
+func (s *BookingService) CreateVenue(req domain.CreateVenueRequest, actorID uint) (*domain.Venue, error) {
+	venue := &domain.Venue{Name: req.Name, Address: req.Address}
+	for _, m := range req.SeatMaps {
+		seatMap, err := m.NewSeatMap(0)
+		if err != nil {
+			return nil, fmt.Errorf("seat map '%s': %w", m.Name, err)
+		}
+		venue.SeatMaps = append(venue.SeatMaps, *seatMap)
+	}
+	if err := s.repo.CreateVenue(venue); err != nil {
+		return nil, err
+	}
+	s.repo.RecordLog(actorID, "CREATE_VENUE", fmt.Sprint(venue.ID), fmt.Sprintf("%s with %d seat maps", venue.Name, len(venue.SeatMaps)))
+	return venue, nil
+}
+
+func (s *BookingService) GetVenue(id uint) (*domain.Venue, error) {
+	venue, err := s.repo.GetVenue(id)
+	if err != nil {
+		return nil, fmt.Errorf("venue not found")
+	}
+	return venue, nil
+}
+
+// GetSeatAvailability is the live seat map buyers pick from. Only listed events have one.
+func (s *BookingService) GetSeatAvailability(eventID uint) ([]domain.SeatAvailability, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil || !slices.Contains(domain.VisibleEventStatuses, event.Status) {
+		return nil, fmt.Errorf("event not found")
+	}
+	if event.SeatMapID == nil {
+		return nil, fmt.Errorf("this event is general admission")
+	}
+	return s.repo.GetEventSeatAvailability(eventID)
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"testing"
+	"time"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/repository"
+	"neptunes-tix/internal/service"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The concurrency guarantees live in Postgres (row locks, conditional UPDATEs), so
+// these tests need a real database. Point TEST_DATABASE_URL at a throwaway one, e.g.
+//
+//	TEST_DATABASE_URL="host=localhost user=postgres dbname=neptunes_test sslmode=disable" go test ./...
+//
+// Without it they are skipped. Every test makes its own event and users.
+func openTestDB(t *testing.T) (*gorm.DB, domain.TicketRepository) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	err = db.AutoMigrate(
+		&domain.User{}, &domain.Ticket{}, &domain.Order{},
+		&domain.Event{}, &domain.AuditLog{}, &domain.PointTransaction{},
+		&domain.PaymentEvent{}, &domain.ScanEvent{},
+		&domain.Job{}, &domain.Notification{},
+		&domain.TicketTier{}, &domain.OrderItem{},
+		&domain.PromoCode{}, &domain.OrderLine{}, &domain.ChargePolicy{},
+		&domain.Cart{}, &domain.CartItem{}, &domain.IdempotencyKey{}, &domain.RefundRequest{},
+		&domain.Refund{}, &domain.RefundTicket{}, &domain.TicketTransfer{},
+		&domain.ResaleListing{}, &domain.ResalePayout{}, &domain.WaitlistEntry{}, &domain.AccessCode{}, &domain.TierUnlock{},
+		&domain.Venue{}, &domain.SeatMap{}, &domain.SeatSection{}, &domain.SeatRow{}, &domain.Seat{}, &domain.EventSeat{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(50)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db, repository.NewDBRepo(db)
+}
+
+func newTestService(t *testing.T, repo domain.TicketRepository) (*service.BookingService, *service.TicketSigner) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := service.NewTicketSigner("test", map[string]ed25519.PrivateKey{"test": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.NewBookingService(repo, service.NewMockGateway("http://localhost", ""), signer, service.NewMemoryQueueStore()), signer
+}
+
+// newTestEvent is an on-sale general admission event with one "GA" tier
+func newTestEvent(t *testing.T, db *gorm.DB, repo domain.TicketRepository, capacity int) *domain.Event {
+	t.Helper()
+	startsAt := time.Now().Add(7 * 24 * time.Hour)
+	event := &domain.Event{
+		Name:        "Concurrency test " + uuid.NewString(),
+		Status:      domain.EventOnSale,
+		EntryPolicy: domain.EntryPolicySingle,
+		StartsAt:    &startsAt,
+	}
+	if err := db.Create(event).Error; err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if err := repo.CreateTiers(event.ID, []domain.TicketTier{{Category: "GA", Price: domain.Sen(5000), Quantity: capacity}}); err != nil {
+		t.Fatalf("create tier: %v", err)
+	}
+	return event
+}
+
+func newTestUser(t *testing.T, repo domain.TicketRepository) *domain.User {
+	t.Helper()
+	user := &domain.User{Name: "Test buyer", Email: uuid.NewString() + "@example.com", Role: "customer"}
+	if err := repo.CreateUser(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
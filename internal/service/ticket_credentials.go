@@ -0,0 +1,118 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidCredential = errors.New("INVALID: QR code is not a genuine ticket")
+
+// TicketClaims is what a ticket's QR code carries. It's a compact JWS
+// (header.payload.signature) signed with Ed25519; the header's "kid" names the key.
+type TicketClaims struct {
+	TicketID string `json:"tid"`
+	EventID  uint   `json:"eid"`
+	Category string `json:"cat"`
+	Version  int    `json:"ver,omitempty"` // the ticket's CredentialVersion when issued
+	jwt.RegisteredClaims
+}
+
+// TicketSigner issues and verifies ticket credentials. New credentials are always
+// signed with the active key; any key still in the set can verify, which is how
+// rotation works: add the new key, make it active, drop the old one once its
+// credentials are no longer in circulation.
+type TicketSigner struct {
+	activeKID string
+	keys      map[string]ed25519.PrivateKey
+}
+
+func NewTicketSigner(activeKID string, keys map[string]ed25519.PrivateKey) (*TicketSigner, error) {
+	if _, ok := keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active ticket key %q is not in the key set", activeKID)
+	}
+	return &TicketSigner{activeKID: activeKID, keys: keys}, nil
+}
+
+// NewTicketSignerFromEnv reads TICKET_SIGNING_KEYS ("kid:base64seed,kid:base64seed")
+// and TICKET_SIGNING_KID. With no keys configured it falls back to a throwaway key,
+// which means every QR code stops working on restart. Fine for dev only.
+func NewTicketSignerFromEnv() (*TicketSigner, error) {
+	raw := os.Getenv("TICKET_SIGNING_KEYS")
+	if raw == "" {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewTicketSigner("dev", map[string]ed25519.PrivateKey{"dev": priv})
+	}
+
+	keys := map[string]ed25519.PrivateKey{}
+	for _, entry := range strings.Split(raw, ",") {
+		kid, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("malformed TICKET_SIGNING_KEYS entry %q", entry)
+		}
+		seed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("ticket key %q must be a base64 %d-byte seed", kid, ed25519.SeedSize)
+		}
+		keys[kid] = ed25519.NewKeyFromSeed(seed)
+	}
+
+	activeKID := os.Getenv("TICKET_SIGNING_KID")
+	if activeKID == "" && len(keys) == 1 {
+		for kid := range keys {
+			activeKID = kid
+		}
+	}
+	return NewTicketSigner(activeKID, keys)
+}
+
+func (s *TicketSigner) Issue(ticket *domain.Ticket) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, TicketClaims{
+		TicketID: ticket.ID,
+		EventID:  ticket.EventID,
+		Category: ticket.Category,
+		Version:  ticket.CredentialVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	})
+	token.Header["kid"] = s.activeKID
+	return token.SignedString(s.keys[s.activeKID])
+}
+
+// Verify checks the signature only; it never touches the database.
+func (s *TicketSigner) Verify(credential string) (*TicketClaims, error) {
+	claims := &TicketClaims{}
+	_, err := jwt.ParseWithClaims(credential, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown ticket key %q", kid)
+		}
+		return key.Public(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil || claims.TicketID == "" {
+		return nil, ErrInvalidCredential
+	}
+	return claims, nil
+}
+
+// PublicKeys is handed to offline scanners so they can verify credentials themselves.
+func (s *TicketSigner) PublicKeys() map[string]string {
+	out := make(map[string]string, len(s.keys))
+	for kid, key := range s.keys {
+		out[kid] = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	"neptunes-tix/internal/domain"
+	"neptunes-tix/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newSigner(t *testing.T, activeKID string, keys map[string]ed25519.PrivateKey) *service.TicketSigner {
+	t.Helper()
+	signer, err := service.NewTicketSigner(activeKID, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// signRaw builds a credential by hand, for headers and claims Issue would never produce
+func signRaw(t *testing.T, kid string, key ed25519.PrivateKey, claims service.TicketClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestTicketSignerVerify(t *testing.T) {
+	oldKey, newKey, otherKey := generateKey(t), generateKey(t), generateKey(t)
+	ticket := &domain.Ticket{ID: "7f9c2ba4-e88f-4d1b-9c3a-2f1e0d5b6a77", EventID: 3, Category: "VIP", CredentialVersion: 2}
+
+	// Before rotation only "2025" exists; during it both do and "2026" signs; after it "2025" is gone
+	before := newSigner(t, "2025", map[string]ed25519.PrivateKey{"2025": oldKey})
+	during := newSigner(t, "2026", map[string]ed25519.PrivateKey{"2025": oldKey, "2026": newKey})
+	after := newSigner(t, "2026", map[string]ed25519.PrivateKey{"2026": newKey})
+
+	issue := func(signer *service.TicketSigner) func(t *testing.T) string {
+		return func(t *testing.T) string {
+			credential, err := signer.Issue(ticket)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return credential
+		}
+	}
+
+	tests := []struct {
+		name       string
+		verifier   *service.TicketSigner
+		credential func(t *testing.T) string
+		wantErr    bool
+	}{
+		{name: "same key", verifier: before, credential: issue(before)},
+		{name: "old key still in the set during rotation", verifier: during, credential: issue(before)},
+		{name: "new active key", verifier: during, credential: issue(during)},
+		{name: "new key verified after rotation", verifier: after, credential: issue(during)},
+		{name: "old key dropped after rotation", verifier: after, credential: issue(before), wantErr: true},
+		{name: "new key not yet known", verifier: before, credential: issue(during), wantErr: true},
+		{
+			name:     "kid names a known key but another key signed",
+			verifier: during,
+			credential: func(t *testing.T) string {
+				return signRaw(t, "2026", otherKey, service.TicketClaims{TicketID: ticket.ID, EventID: ticket.EventID})
+			},
+			wantErr: true,
+		},
+		{
+			name:     "no kid",
+			verifier: during,
+			credential: func(t *testing.T) string {
+				return signRaw(t, "", newKey, service.TicketClaims{TicketID: ticket.ID, EventID: ticket.EventID})
+			},
+			wantErr: true,
+		},
+		{
+			name:     "no ticket id",
+			verifier: during,
+			credential: func(t *testing.T) string {
+				return signRaw(t, "2026", newKey, service.TicketClaims{EventID: ticket.EventID})
+			},
+			wantErr: true,
+		},
+		{
+			name:     "payload swapped from another credential",
+			verifier: during,
+			credential: func(t *testing.T) string {
+				genuine := strings.Split(issue(during)(t), ".")
+				forged := strings.Split(signRaw(t, "2026", otherKey, service.TicketClaims{TicketID: "someone-else", EventID: 3}), ".")
+				return genuine[0] + "." + forged[1] + "." + genuine[2]
+			},
+			wantErr: true,
+		},
+		{
+			name:     "HMAC instead of Ed25519",
+			verifier: during,
+			credential: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, service.TicketClaims{TicketID: ticket.ID, EventID: ticket.EventID})
+				token.Header["kid"] = "2026"
+				signed, err := token.SignedString([]byte(newKey.Public().(ed25519.PublicKey)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name:       "not a credential",
+			verifier:   during,
+			credential: func(t *testing.T) string { return ticket.ID },
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Verify(tt.credential(t))
+			if tt.wantErr {
+				if !errors.Is(err, service.ErrInvalidCredential) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidCredential", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.TicketID != ticket.ID || claims.EventID != ticket.EventID || claims.Category != ticket.Category || claims.Version != ticket.CredentialVersion {
+				t.Errorf("Verify() = %+v, want the ticket's claims", claims)
+			}
+		})
+	}
+}
+
+func TestNewTicketSignerRequiresActiveKey(t *testing.T) {
+	if _, err := service.NewTicketSigner("2026", map[string]ed25519.PrivateKey{"2025": generateKey(t)}); err == nil {
+		t.Fatal("NewTicketSigner accepted an active kid that isn't in the key set")
+	}
+}
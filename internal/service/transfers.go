@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"neptunes-tix/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StartTicketTransfer offers one of the user's tickets to whoever owns (or will
+// register) toEmail. The ticket stays the sender's until the recipient accepts.
+func (s *BookingService) StartTicketTransfer(userID uint, ticketID, toEmail, note string) (*domain.TicketTransfer, error) {
+	toEmail = strings.ToLower(strings.TrimSpace(toEmail))
+	if _, err := uuid.Parse(ticketID); err != nil {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	sender, err := s.repo.GetUserByID(fmt.Sprint(userID))
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if strings.EqualFold(sender.Email, toEmail) {
+		return nil, fmt.Errorf("you already hold this ticket")
+	}
+
+	transfer := &domain.TicketTransfer{
+		TicketID:   ticketID,
+		FromUserID: userID,
+		ToEmail:    toEmail,
+		Status:     domain.TransferPending,
+		Note:       note,
+	}
+	var event *domain.Event
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		// 1. Lock the ticket so it can't be scanned, refunded or offered twice meanwhile
+		ticket, err := txRepo.GetTicketForUpdate(ticketID)
+		if err != nil || ticket.HolderUserID == nil || *ticket.HolderUserID != userID {
+			return fmt.Errorf("ticket not found")
+		}
+		if err := checkTransferable(txRepo, ticket); err != nil {
+			return err
+		}
+		if event, err = txRepo.GetEventByID(ticket.EventID); err != nil {
+			return fmt.Errorf("event not found")
+		}
+
+		// 2. One open offer per ticket
+		if _, err := txRepo.GetPendingTransfer(ticketID); err == nil {
+			return fmt.Errorf("this ticket already has a pending transfer, cancel it first")
+		}
+		if err := txRepo.CreateTicketTransfer(transfer); err != nil {
+			return err
+		}
+		txRepo.RecordLog(userID, "TICKET_TRANSFER_START", ticketID, fmt.Sprintf("transfer #%d to %s", transfer.ID, toEmail))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Recipients without an account see it once they register with this email
+	if recipient, err := s.repo.GetUserByEmail(toEmail); err == nil {
+		s.notify(s.repo, recipient.ID, "A ticket is waiting for you", fmt.Sprintf(
+			"%s wants to give you a ticket for %s. Accept it under My Transfers.", sender.Name, event.Name))
+	}
+	return transfer, nil
+}
+
+// AcceptTicketTransfer moves the ticket to the recipient. Its credential version goes
+// up, so the sender's QR code stops working and the recipient gets a fresh one.
+func (s *BookingService) AcceptTicketTransfer(userID, transferID uint) (*domain.TicketTransfer, error) {
+	transfer, recipient, err := s.openIncomingTransfer(userID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refused error
+	err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+		ticket, err := txRepo.GetTicketForUpdate(transfer.TicketID)
+		if err != nil {
+			return fmt.Errorf("ticket not found")
+		}
+
+		// 1. The ticket may have been used, refunded or voided since it was offered
+		refused = checkTransferable(txRepo, ticket)
+		if refused == nil {
+			won, err := txRepo.MoveTicketHolder(ticket.ID, transfer.FromUserID, recipient.ID)
+			if err != nil {
+				return err
+			}
+			if !won {
+				refused = errors.New("this ticket can no longer be transferred")
+			}
+		}
+		if refused != nil {
+			// Dead offers are closed rather than left pending forever
+			transfer.Status = domain.TransferCancelled
+			transfer.Note = refused.Error()
+			return txRepo.UpdateTicketTransfer(transfer)
+		}
+
+		// 2. Record the handover
+		now := time.Now()
+		transfer.Status = domain.TransferAccepted
+		transfer.ToUserID = &recipient.ID
+		transfer.AcceptedAt = &now
+		if err := txRepo.UpdateTicketTransfer(transfer); err != nil {
+			return err
+		}
+		txRepo.RecordLog(recipient.ID, "TICKET_TRANSFER", ticket.ID,
+			fmt.Sprintf("transfer #%d: user #%d → user #%d, credential v%d", transfer.ID, transfer.FromUserID, recipient.ID, ticket.CredentialVersion+1))
+		s.notify(txRepo, transfer.FromUserID, "Ticket transferred", fmt.Sprintf(
+			"%s accepted your %s ticket. Your old QR code for it no longer works.", recipient.Email, ticket.Category))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refused != nil {
+		return nil, refused
+	}
+	return s.repo.GetTicketTransfer(transfer.ID)
+}
+
+// DeclineTicketTransfer is the recipient saying no; the ticket stays with the sender
+func (s *BookingService) DeclineTicketTransfer(userID, transferID uint) (*domain.TicketTransfer, error) {
+	transfer, recipient, err := s.openIncomingTransfer(userID, transferID)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Status = domain.TransferDeclined
+	transfer.ToUserID = &recipient.ID
+	if err := s.repo.UpdateTicketTransfer(transfer); err != nil {
+		return nil, err
+	}
+	s.repo.RecordLog(userID, "TICKET_TRANSFER_DECLINE", transfer.TicketID, fmt.Sprintf("transfer #%d", transfer.ID))
+	s.notify(s.repo, transfer.FromUserID, "Ticket transfer declined", fmt.Sprintf(
+		"%s declined your ticket. It is still yours.", recipient.Email))
+	return transfer, nil
+}
+
+// CancelTicketTransfer is the sender taking the offer back before it was accepted
+func (s *BookingService) CancelTicketTransfer(userID, transferID uint) (*domain.TicketTransfer, error) {
+	transfer, err := s.repo.GetTicketTransfer(transferID)
+	if err != nil || transfer.FromUserID != userID {
+		return nil, fmt.Errorf("transfer not found")
+	}
+	if transfer.Status != domain.TransferPending {
+		return nil, fmt.Errorf("transfer is already %s", transfer.Status)
+	}
+	transfer.Status = domain.TransferCancelled
+	if err := s.repo.UpdateTicketTransfer(transfer); err != nil {
+		return nil, err
+	}
+	s.repo.RecordLog(userID, "TICKET_TRANSFER_CANCEL", transfer.TicketID, fmt.Sprintf("transfer #%d", transfer.ID))
+	return transfer, nil
+}
+
+// GetUserTransfers lists transfers the user sent, received, or is being offered
+func (s *BookingService) GetUserTransfers(userID uint) ([]domain.TicketTransfer, error) {
+	user, err := s.repo.GetUserByID(fmt.Sprint(userID))
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return s.repo.GetUserTransfers(userID, user.Email)
+}
+
+// GetTicketTransfers is the ownership history of a ticket the user holds now
+func (s *BookingService) GetTicketTransfers(userID uint, ticketID string) ([]domain.TicketTransfer, error) {
+	if _, err := uuid.Parse(ticketID); err != nil {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	if _, err := s.repo.GetUserTicket(ticketID, userID); err != nil {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	return s.repo.GetTicketTransfers(ticketID)
+}
+
+// GetTicketTransferHistory is the same history for staff, whoever holds the ticket
+func (s *BookingService) GetTicketTransferHistory(ticketID string) ([]domain.TicketTransfer, error) {
+	if _, err := uuid.Parse(ticketID); err != nil {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	return s.repo.GetTicketTransfers(ticketID)
+}
+
+func (s *BookingService) openIncomingTransfer(userID, transferID uint) (*domain.TicketTransfer, *domain.User, error) {
+	recipient, err := s.repo.GetUserByID(fmt.Sprint(userID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found")
+	}
+	transfer, err := s.repo.GetTicketTransfer(transferID)
+	if err != nil || !strings.EqualFold(transfer.ToEmail, recipient.Email) {
+		return nil, nil, fmt.Errorf("transfer not found")
+	}
+	if transfer.Status != domain.TransferPending {
+		return nil, nil, fmt.Errorf("transfer is already %s", transfer.Status)
+	}
+	return transfer, recipient, nil
+}
+
+// checkTransferable: paid, unused, and the event's transfer cutoff hasn't passed
+func checkTransferable(repo domain.TicketRepository, ticket *domain.Ticket) error {
+	if !ticket.IsSold || ticket.Status != domain.TicketSold {
+		return fmt.Errorf("this ticket is no longer valid")
+	}
+	if ticket.CheckedInAt != nil {
+		return fmt.Errorf("this ticket has already been used")
+	}
+	if _, err := repo.GetLiveListing(ticket.ID); err == nil {
+		return fmt.Errorf("this ticket is listed for resale")
+	}
+	event, err := repo.GetEventByID(ticket.EventID)
+	if err != nil {
+		return fmt.Errorf("event not found")
+	}
+	return event.CheckTransferable(time.Now())
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"neptunes-tix/internal/domain"
+	"time"
+)
+
+// JoinWaitlist queues the user for a general admission tier that can't sell them
+// `quantity` tickets right now. Whatever comes free is offered in joining order (see
+// OfferWaitlists).
+func (s *BookingService) JoinWaitlist(userID, eventID uint, category string, quantity int) (*domain.WaitlistEntry, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	if !waitlistOpen(event) {
+		return nil, fmt.Errorf("tickets for %s are not on sale (%s)", event.Name, event.Status)
+	}
+	tier, err := s.repo.GetTicketTier(eventID, category)
+	if err != nil {
+		return nil, fmt.Errorf("category '%s' does not exist", category)
+	}
+	if tier.Seated {
+		return nil, fmt.Errorf("%s is reserved seating and has no waitlist", category)
+	}
+	if tier.Restricted() {
+		user, err := s.repo.GetUserByID(fmt.Sprint(userID))
+		if err != nil {
+			return nil, fmt.Errorf("user not found")
+		}
+		if err := checkTierAccess(s.repo, user, tier); err != nil {
+			return nil, err
+		}
+	}
+
+	// 1. Ask for what one checkout could buy
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be at least 1")
+	}
+	if tier.MaxPerOrder > 0 && quantity > tier.MaxPerOrder {
+		return nil, fmt.Errorf("%s is limited to %d per order", category, tier.MaxPerOrder)
+	}
+	if quantity < tier.MinPerOrder {
+		return nil, fmt.Errorf("%s must be bought at least %d at a time", category, tier.MinPerOrder)
+	}
+
+	// 2. Only when buying directly isn't possible
+	if _, err := s.repo.GetLiveWaitlistEntry(tier.ID, userID); err == nil {
+		return nil, fmt.Errorf("you are already on the waitlist for %s", category)
+	}
+	waiting, err := s.repo.CountWaiting(tier.ID)
+	if err != nil {
+		return nil, err
+	}
+	if waiting == 0 && tier.Available() >= quantity {
+		return nil, fmt.Errorf("%s still has %d tickets available, buy them directly", category, tier.Available())
+	}
+
+	entry := &domain.WaitlistEntry{
+		TierID:   tier.ID,
+		EventID:  eventID,
+		Category: category,
+		UserID:   userID,
+		Quantity: quantity,
+		Status:   domain.WaitlistWaiting,
+	}
+	if err := s.repo.CreateWaitlistEntry(entry); err != nil {
+		return nil, err
+	}
+	s.repo.RecordLog(userID, "WAITLIST_JOIN", fmt.Sprint(tier.ID), fmt.Sprintf("entry #%d for %d x %s", entry.ID, quantity, category))
+
+	// A few tickets may be free already (fewer than asked): they go to the front now
+	s.offerTier(event, tier)
+	return s.GetWaitlistPosition(userID, eventID, category)
+}
+
+// LeaveWaitlist gives up the user's place; tickets offered to them go to the next in line
+func (s *BookingService) LeaveWaitlist(userID, eventID uint, category string) error {
+	tier, err := s.repo.GetTicketTier(eventID, category)
+	if err != nil {
+		return fmt.Errorf("category '%s' does not exist", category)
+	}
+	entry, err := s.repo.GetLiveWaitlistEntry(tier.ID, userID)
+	if err != nil {
+		return fmt.Errorf("you are not on the waitlist for %s", category)
+	}
+	offered := entry.Status == domain.WaitlistOffered
+
+	closed, err := s.repo.CloseWaitlistEntry(entry, domain.WaitlistLeft)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return fmt.Errorf("your waitlist entry changed in the meantime, please reload")
+	}
+	s.repo.RecordLog(userID, "WAITLIST_LEAVE", fmt.Sprint(tier.ID), fmt.Sprintf("entry #%d", entry.ID))
+
+	if offered {
+		if event, err := s.repo.GetEventByID(eventID); err == nil {
+			s.offerTier(event, tier)
+		}
+	}
+	return nil
+}
+
+// GetWaitlistPosition is the user's live entry for a tier with its place in the queue
+// (1 = next to be offered, or holding an offer)
+func (s *BookingService) GetWaitlistPosition(userID, eventID uint, category string) (*domain.WaitlistEntry, error) {
+	tier, err := s.repo.GetTicketTier(eventID, category)
+	if err != nil {
+		return nil, fmt.Errorf("category '%s' does not exist", category)
+	}
+	entry, err := s.repo.GetLiveWaitlistEntry(tier.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("you are not on the waitlist for %s", category)
+	}
+	if entry.Position, err = s.repo.GetWaitlistPosition(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *BookingService) GetUserWaitlists(userID uint) ([]domain.WaitlistEntry, error) {
+	entries, err := s.repo.GetUserWaitlists(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Live() {
+			entries[i].Position, _ = s.repo.GetWaitlistPosition(&entries[i])
+		}
+	}
+	return entries, nil
+}
+
+// OfferWaitlists is the waitlist sweep, run by the background worker and whenever
+// stock is handed back by a buyer:
+//
+//  1. offers nobody used in time expire and their tickets are released
+//  2. every tier with people waiting offers its free tickets, front of the queue first
+//
+// It must run before ReopenSoldOutEvents so that offered stock never reopens an event.
+func (s *BookingService) OfferWaitlists() {
+	expired, err := s.repo.GetExpiredWaitlistOffers(time.Now())
+	if err != nil {
+		log.Printf("waitlist: could not load expired offers: %v", err)
+		return
+	}
+	for i := range expired {
+		entry := &expired[i]
+		if closed, err := s.repo.CloseWaitlistEntry(entry, domain.WaitlistExpired); err != nil || !closed {
+			continue
+		}
+		s.repo.RecordLog(0, "WAITLIST_EXPIRE", fmt.Sprint(entry.TierID), fmt.Sprintf("entry #%d, %d tickets released", entry.ID, entry.OfferQuantity))
+		s.notify(s.repo, entry.UserID, "Your waitlist offer expired", fmt.Sprintf(
+			"The %d %s tickets held for you were not bought in time and went to the next person on the waitlist.", entry.OfferQuantity, entry.Category))
+	}
+
+	tiers, err := s.repo.GetWaitlistedTiers()
+	if err != nil {
+		log.Printf("waitlist: could not load tiers: %v", err)
+		return
+	}
+	for i := range tiers {
+		event, err := s.repo.GetEventByID(tiers[i].EventID)
+		if err != nil {
+			continue
+		}
+		s.offerTier(event, &tiers[i])
+	}
+}
+
+// offerTier holds the tier's free tickets for the people at the front of its queue.
+// The queue is strict: if the first person can't be served (fewer free than the
+// tier's minimum per order) nobody behind them is.
+func (s *BookingService) offerTier(event *domain.Event, tier *domain.TicketTier) {
+	if !waitlistOpen(event) {
+		return
+	}
+	minimum := max(tier.MinPerOrder, 1)
+	for {
+		fresh, err := s.repo.GetTicketTier(tier.EventID, tier.Category)
+		if err != nil || fresh.Available() < minimum {
+			return
+		}
+		next, err := s.repo.GetWaitingEntries(tier.ID, 1)
+		if err != nil || len(next) == 0 {
+			return
+		}
+		entry := &next[0]
+		quantity := min(entry.Quantity, fresh.Available())
+		expiresAt := time.Now().Add(domain.WaitlistOfferWindow)
+
+		var offered bool
+		err = s.repo.Transaction(func(txRepo domain.TicketRepository) error {
+			offered, err = txRepo.OfferWaitlistStock(entry, quantity, expiresAt)
+			return err
+		})
+		if err != nil {
+			log.Printf("waitlist: could not offer entry #%d: %v", entry.ID, err)
+			return
+		}
+		if !offered {
+			continue // taken or left in the meantime, look again
+		}
+
+		s.repo.RecordLog(0, "WAITLIST_OFFER", fmt.Sprint(tier.ID), fmt.Sprintf("entry #%d: %d of %d tickets until %s", entry.ID, quantity, entry.Quantity, expiresAt.Format(time.RFC3339)))
+		s.notify(s.repo, entry.UserID, "Your waitlist tickets are here", fmt.Sprintf(
+			"%d %s tickets for %s are held for you until %s. Check out before then or they go to the next person on the waitlist.",
+			quantity, tier.Category, event.Name, expiresAt.Format("15:04")))
+	}
+}
+
+// holdGeneralAdmission holds an item's tickets for the order being placed. A buyer
+// with a waitlist offer takes it from the offer; while anyone is still waiting for the
+// tier, its released stock is theirs and everyone else is pointed to the waitlist.
+func (s *BookingService) holdGeneralAdmission(txRepo domain.TicketRepository, order *domain.Order, eventID uint, item CheckoutItem) (*domain.OrderItem, error) {
+	tier, err := txRepo.GetTicketTier(eventID, item.Category)
+	if err != nil {
+		return nil, fmt.Errorf("category '%s' does not exist", item.Category)
+	}
+	if tier.Seated {
+		return nil, fmt.Errorf("%s is reserved seating, please pick your seats", item.Category)
+	}
+
+	if offer, err := txRepo.GetActiveOffer(order.UserID, tier.ID, time.Now()); err == nil {
+		if item.Quantity > offer.OfferQuantity {
+			return nil, fmt.Errorf("your waitlist offer for %s is for %d tickets", item.Category, offer.OfferQuantity)
+		}
+		held, taken, err := txRepo.TakeWaitlistOffer(offer, order.ID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			txRepo.RecordLog(order.UserID, "WAITLIST_FULFIL", fmt.Sprint(tier.ID), fmt.Sprintf("entry #%d → order #%d", offer.ID, order.ID))
+			return held, nil
+		}
+	}
+
+	waiting, err := txRepo.CountWaiting(tier.ID)
+	if err != nil {
+		return nil, err
+	}
+	if waiting > 0 {
+		return nil, &domain.CheckoutError{Code: domain.CodeWaitlistActive, Message: fmt.Sprintf(
+			"%s has a waitlist: join it to be offered the next tickets that come free", item.Category)}
+	}
+	return txRepo.HoldTierStock(order.ID, eventID, item.Category, item.Quantity)
+}
+
+// offeredOnly says whether a selection is only general admission the user holds
+// waitlist offers for. Those can still be bought once the event shows sold out.
+func offeredOnly(txRepo domain.TicketRepository, userID uint, sel EventSelection) bool {
+	if len(sel.SeatIDs) > 0 || len(sel.Items) == 0 {
+		return false
+	}
+	for _, item := range sel.Items {
+		tier, err := txRepo.GetTicketTier(sel.EventID, item.Category)
+		if err != nil {
+			return false
+		}
+		if _, err := txRepo.GetActiveOffer(userID, tier.ID, time.Now()); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// waitlistOpen: a waitlist serves events still selling, whether or not they show sold out
+func waitlistOpen(event *domain.Event) bool {
+	if event.StartsAt != nil && !time.Now().Before(*event.StartsAt) {
+		return false
+	}
+	return event.Status == domain.EventOnSale || event.Status == domain.EventSoldOut
+}